@@ -0,0 +1,369 @@
+package abnf
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/elimity-com/abnf/operators"
+)
+
+// maxLanguageSize is the maximum amount of inputs that get generated for a single operator.
+const maxLanguageSize = 100000
+
+// Ambiguity is an input that has more than one parse tree for a rule.
+type Ambiguity struct {
+	Rule   string
+	Input  []byte
+	Report operators.AmbiguityReport
+}
+
+func (a Ambiguity) String() string {
+	return fmt.Sprintf("%s %q: %s", a.Rule, a.Input, a.Report)
+}
+
+// FindAmbiguities searches all the inputs, up to the given length, that are accepted by the given rule for inputs with
+// more than one parse tree. All referenced rules need to be part of the set (e.g. add the CoreRules). Bytes that can not
+// be distinguished by the grammar are only tried once.
+func (set RuleSet) FindAmbiguities(rule string, length int) ([]Ambiguity, error) {
+	if _, ok := set[rule]; !ok {
+		return nil, fmt.Errorf("unknown rule: %s", rule)
+	}
+	l, err := newLanguage(set, length)
+	if err != nil {
+		return nil, err
+	}
+	if name := l.leftRecursive(); name != "" {
+		return nil, fmt.Errorf("left recursive rule: %s", name)
+	}
+
+	operator := new(ParserGenerator).generate(set)[rule]
+	var ambiguities []Ambiguity
+	for _, input := range l.sorted(rule) {
+		if report := operators.Ambiguities(operator, []byte(input)); report.Ambiguous() {
+			ambiguities = append(ambiguities, Ambiguity{
+				Rule:   rule,
+				Input:  []byte(input),
+				Report: report,
+			})
+		}
+	}
+	return ambiguities, nil
+}
+
+// language contains all the inputs, up to a certain length, that are accepted by the rules of a set.
+type language struct {
+	set    RuleSet
+	length int
+	// bytes that represent all the other bytes that can not be distinguished from them by the grammar
+	representatives []byte
+	rules           map[string]map[string]struct{}
+}
+
+// newLanguage computes the languages of all the rules of the set.
+func newLanguage(set RuleSet, length int) (*language, error) {
+	representatives, err := representatives(set)
+	if err != nil {
+		return nil, err
+	}
+	return newRulesLanguage(set, set.names(), length, representatives)
+}
+
+// newRulesLanguage computes the languages of the given rules and the rules they refer to, only the given representatives
+// are used as bytes (see representatives).
+func newRulesLanguage(set RuleSet, names []string, length int, representatives []byte) (*language, error) {
+	l := &language{
+		set:             set,
+		length:          length,
+		representatives: representatives,
+		rules:           make(map[string]map[string]struct{}),
+	}
+	var reachable []string
+	var reach func(name string) error
+	reach = func(name string) error {
+		if _, ok := l.rules[name]; ok {
+			return nil
+		}
+		rule, ok := set[name]
+		if !ok {
+			return fmt.Errorf("unknown rule: %s", name)
+		}
+		l.rules[name] = make(map[string]struct{})
+		reachable = append(reachable, name)
+		return walkOperators(rule.operator, func(operator Operator) error {
+			if reference, ok := operator.(RuleNameOperator); ok {
+				return reach(reference.key)
+			}
+			return nil
+		})
+	}
+	for _, name := range names {
+		if err := reach(name); err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(reachable)
+
+	// the languages of the rules only grow, and are limited in length, so this ends eventually
+	for changed := true; changed; {
+		changed = false
+		for _, name := range reachable {
+			inputs, err := l.operator(set[name].operator)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s", name, err)
+			}
+			if len(l.rules[name]) < len(inputs) {
+				l.rules[name] = inputs
+				changed = true
+			}
+		}
+	}
+	return l, nil
+}
+
+// representatives returns a byte for every group of bytes that are accepted by exactly the same terminals of the given
+// sets, these bytes can not be distinguished by the rules. e.g. for %x30-39 / "x" these are 0x00, 0x30 and 0x78.
+func representatives(sets ...RuleSet) ([]byte, error) {
+	var terminals []operators.ByteSet
+	for _, set := range sets {
+		for _, name := range set.names() {
+			if err := walkOperators(set[name].operator, func(operator Operator) error {
+				switch operator.(type) {
+				case CharacterValueOperator, NumericValueOperator:
+					bytes, err := terminalBytes(operator)
+					if err != nil {
+						return err
+					}
+					terminals = append(terminals, bytes...)
+				}
+				return nil
+			}); err != nil {
+				return nil, fmt.Errorf("%s: %s", name, err)
+			}
+		}
+	}
+	var representatives []byte
+	groups := make(map[string]bool)
+	for b := 0; b < 256; b++ {
+		signature := make([]byte, len(terminals))
+		for i, terminal := range terminals {
+			if terminal.Contains(byte(b)) {
+				signature[i] = 1
+			}
+		}
+		if !groups[string(signature)] {
+			groups[string(signature)] = true
+			representatives = append(representatives, byte(b))
+		}
+	}
+	return representatives, nil
+}
+
+// operator returns all inputs accepted by the given operator, based on the current languages of the rules.
+func (l *language) operator(operator Operator) (map[string]struct{}, error) {
+	switch operator := operator.(type) {
+	case AlternationOperator:
+		inputs := make(map[string]struct{})
+		for _, subOperator := range operator.subOperators {
+			subInputs, err := l.operator(subOperator)
+			if err != nil {
+				return nil, err
+			}
+			for input := range subInputs {
+				inputs[input] = struct{}{}
+			}
+		}
+		return inputs, l.check(inputs)
+	case ConcatenationOperator:
+		inputs := map[string]struct{}{"": {}}
+		for _, subOperator := range operator.subOperators {
+			subInputs, err := l.operator(subOperator)
+			if err != nil {
+				return nil, err
+			}
+			if inputs, err = l.concat(inputs, subInputs); err != nil {
+				return nil, err
+			}
+		}
+		return inputs, nil
+	case RepetitionOperator:
+		subInputs, err := l.operator(operator.subOperator)
+		if err != nil {
+			return nil, err
+		}
+		inputs := make(map[string]struct{})
+		current := map[string]struct{}{"": {}}
+		// more than length (non empty) repetitions do not result in new inputs
+		for i := 0; (operator.max < 0 || i <= operator.max) && len(current) != 0; i++ {
+			if operator.min <= i {
+				for input := range current {
+					inputs[input] = struct{}{}
+				}
+				if l.length < i {
+					break
+				}
+			}
+			if current, err = l.concat(current, subInputs); err != nil {
+				return nil, err
+			}
+		}
+		return inputs, l.check(inputs)
+	case RuleNameOperator:
+		return l.rules[operator.key], nil
+	case OptionOperator:
+		subInputs, err := l.operator(operator.subOperator)
+		if err != nil {
+			return nil, err
+		}
+		inputs := map[string]struct{}{"": {}}
+		for input := range subInputs {
+			inputs[input] = struct{}{}
+		}
+		return inputs, nil
+	case CharacterValueOperator, NumericValueOperator:
+		sets, err := terminalBytes(operator)
+		if err != nil {
+			return nil, err
+		}
+		if l.length < len(sets) {
+			return nil, nil
+		}
+		inputs := map[string]struct{}{"": {}}
+		for _, bytes := range sets {
+			position := make(map[string]struct{})
+			for _, b := range l.representatives {
+				if bytes.Contains(b) {
+					position[string([]byte{b})] = struct{}{}
+				}
+			}
+			if inputs, err = l.concat(inputs, position); err != nil {
+				return nil, err
+			}
+		}
+		return inputs, nil
+	default:
+		return nil, fmt.Errorf("unsupported operator: %s", operator.Key())
+	}
+}
+
+// concat returns all the concatenations of the given inputs that do not exceed the maximum length.
+func (l *language) concat(as, bs map[string]struct{}) (map[string]struct{}, error) {
+	inputs := make(map[string]struct{})
+	for a := range as {
+		for b := range bs {
+			if len(a)+len(b) <= l.length {
+				inputs[a+b] = struct{}{}
+			}
+		}
+	}
+	return inputs, l.check(inputs)
+}
+
+func (l *language) check(inputs map[string]struct{}) error {
+	if maxLanguageSize < len(inputs) {
+		return fmt.Errorf("too many inputs, try a smaller length")
+	}
+	return nil
+}
+
+// sorted returns the inputs of the given rule, sorted by length.
+func (l *language) sorted(rule string) []string {
+	var inputs []string
+	for input := range l.rules[rule] {
+		inputs = append(inputs, input)
+	}
+	sort.Slice(inputs, func(i, j int) bool {
+		if len(inputs[i]) != len(inputs[j]) {
+			return len(inputs[i]) < len(inputs[j])
+		}
+		return inputs[i] < inputs[j]
+	})
+	return inputs
+}
+
+// leftRecursive returns the name of a left recursive rule, if there is one.
+func (l *language) leftRecursive() string {
+	left := make(map[string][]string)
+	for name, rule := range l.set {
+		left[name] = l.leftReferences(rule.operator)
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int)
+	var visit func(name string) string
+	visit = func(name string) string {
+		switch state[name] {
+		case visiting:
+			return name
+		case visited:
+			return ""
+		}
+		state[name] = visiting
+		for _, reference := range left[name] {
+			if recursive := visit(reference); recursive != "" {
+				return recursive
+			}
+		}
+		state[name] = visited
+		return ""
+	}
+	for _, name := range l.set.names() {
+		if recursive := visit(name); recursive != "" {
+			return recursive
+		}
+	}
+	return ""
+}
+
+// leftReferences returns the rules that can be referenced by the given operator without consuming any input.
+func (l *language) leftReferences(operator Operator) []string {
+	switch operator := operator.(type) {
+	case AlternationOperator:
+		var references []string
+		for _, subOperator := range operator.subOperators {
+			references = append(references, l.leftReferences(subOperator)...)
+		}
+		return references
+	case ConcatenationOperator:
+		var references []string
+		for _, subOperator := range operator.subOperators {
+			references = append(references, l.leftReferences(subOperator)...)
+			if inputs, _ := l.operator(subOperator); !contains(inputs, "") {
+				break
+			}
+		}
+		return references
+	case RepetitionOperator:
+		if operator.max == 0 {
+			return nil
+		}
+		return l.leftReferences(operator.subOperator)
+	case OptionOperator:
+		return l.leftReferences(operator.subOperator)
+	case RuleNameOperator:
+		return []string{operator.key}
+	default:
+		return nil
+	}
+}
+
+func contains(inputs map[string]struct{}, input string) bool {
+	_, ok := inputs[input]
+	return ok
+}
+
+// walkOperators calls the given function for the operator and all its sub operators.
+func walkOperators(operator Operator, f func(operator Operator) error) error {
+	if err := f(operator); err != nil {
+		return err
+	}
+	for _, subOperator := range subOperators(operator) {
+		if err := walkOperators(subOperator, f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package abnf
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFindAmbiguities(t *testing.T) {
+	set := NewRuleSet([]byte("list = *item\nitem = \"a\" / \"aa\" / %x62-7A\n"))
+	ambiguities, err := set.FindAmbiguities("list", 3)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	var inputs []string
+	for _, ambiguity := range ambiguities {
+		inputs = append(inputs, string(ambiguity.Input))
+	}
+	// "aa" can be parsed as one or two items, "aaa" as three or two (2x), b-z are represented by "b"
+	if strings.Join(inputs, ",") != "aa,aaa,aab,baa" {
+		t.Errorf("invalid ambiguous inputs: %q", inputs)
+		return
+	}
+	if l := len(ambiguities[1].Report.Trees); l != 3 {
+		t.Errorf("expected three trees, got %d", l)
+	}
+
+	t.Run("Recursive", func(t *testing.T) {
+		set := NewRuleSet([]byte("expr = term \"+\" expr / term\nterm = \"1\" / \"(\" expr \")\"\n"))
+		ambiguities, err := set.FindAmbiguities("expr", 7)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if len(ambiguities) != 0 {
+			t.Errorf("expected no ambiguities, got %s", ambiguities[0])
+		}
+	})
+
+	t.Run("Core", func(t *testing.T) {
+		set := CoreRules(false)
+		set["line"] = NewRuleSet([]byte("line = *(ALPHA / HEXDIG)\n"))["line"]
+		ambiguities, err := set.FindAmbiguities("line", 1)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		// A-F and a-f are both ALPHA and HEXDIG
+		if len(ambiguities) != 12 {
+			t.Errorf("expected twelve ambiguities, got %d", len(ambiguities))
+		}
+	})
+
+	for _, test := range []struct {
+		abnf, rule string
+	}{
+		{"a = a \"x\" / \"x\"\n", "a"},
+		{"a = [\"x\"] b\nb = a / \"y\"\n", "a"},
+		{"a = ALPHA\n", "a"},
+		{"a = %x100\n", "a"},
+		{"a = \"x\"\n", "b"},
+	} {
+		if _, err := NewRuleSet([]byte(test.abnf)).FindAmbiguities(test.rule, 2); err == nil {
+			t.Errorf("expected an error for %s", test.abnf)
+		}
+	}
+}
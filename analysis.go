@@ -0,0 +1,246 @@
+package abnf
+
+import (
+	"reflect"
+
+	"github.com/elimity-com/abnf/operators"
+)
+
+// Analysis contains basic facts about the rules (and operators) of a set: whether they match the empty input (nullable),
+// the bytes a non-empty match can start with (FIRST) and the bytes that can follow a match within the other rules of
+// the set (FOLLOW, the end of the input is not included).
+//
+// Repetitions are analysed with their bounds, e.g. 0"a" is nullable and its FIRST set is empty. Numeric values are
+// matched byte by byte, so the FIRST set of a value of multiple bytes only contains its first byte (for ranges of
+// multiple bytes it contains all the bytes from the first byte of the low value). Undefined rules are assumed to match
+// anything, including the empty input.
+type Analysis struct {
+	set      RuleSet
+	nullable map[operatorID]bool
+	first    map[operatorID]operators.ByteSet
+	follow   map[operatorID]*follow
+}
+
+// operatorID identifies an operator within a set, operators with the same ABNF text (and type) are the same.
+type operatorID struct {
+	t   reflect.Type
+	key string
+}
+
+func idOf(operator Operator) operatorID {
+	return operatorID{t: reflect.TypeOf(operator), key: operator.Key()}
+}
+
+// follow contains the bytes that directly follow an operator, and the rules it can end.
+type follow struct {
+	bytes operators.ByteSet
+	rules map[string]bool
+}
+
+// Analyze computes the nullable, FIRST and FOLLOW sets of all the rules and operators of the set.
+func (set RuleSet) Analyze() *Analysis {
+	a := &Analysis{
+		set:      set,
+		nullable: make(map[operatorID]bool),
+		first:    make(map[operatorID]operators.ByteSet),
+		follow:   make(map[operatorID]*follow),
+	}
+	names := set.names()
+
+	// both sets can only grow, so they are computed until they do not change anymore
+	for changed := true; changed; {
+		changed = false
+		for _, name := range names {
+			id := idOf(RuleNameOperator{key: name})
+			nullable, first := a.analyze(set[name].operator)
+			if nullable != a.nullable[id] || first != a.first[id] {
+				changed = true
+			}
+			a.nullable[id], a.first[id] = nullable, first
+		}
+	}
+
+	for _, name := range names {
+		a.addFollow(set[name].operator, name, operators.ByteSet{}, true)
+	}
+	// a rule is followed by the bytes that follow the rules it can end
+	for changed := true; changed; {
+		changed = false
+		for _, name := range names {
+			f, ok := a.follow[idOf(RuleNameOperator{key: name})]
+			if !ok {
+				continue
+			}
+			for rule := range f.rules {
+				if other, ok := a.follow[idOf(RuleNameOperator{key: rule})]; ok {
+					if union := f.bytes.Union(other.bytes); union != f.bytes {
+						f.bytes = union
+						changed = true
+					}
+				}
+			}
+		}
+	}
+	return a
+}
+
+// analyze returns whether the given operator is nullable and its FIRST set, based on the current values of the rules.
+// The values of all the operators are stored along the way.
+func (a *Analysis) analyze(operator Operator) (bool, operators.ByteSet) {
+	var nullable bool
+	var first operators.ByteSet
+	switch operator := operator.(type) {
+	case AlternationOperator:
+		for _, subOperator := range operator.subOperators {
+			subNullable, subFirst := a.analyze(subOperator)
+			nullable = nullable || subNullable
+			first = first.Union(subFirst)
+		}
+	case ConcatenationOperator:
+		nullable = true
+		for _, subOperator := range operator.subOperators {
+			subNullable, subFirst := a.analyze(subOperator)
+			if nullable {
+				first = first.Union(subFirst)
+			}
+			nullable = nullable && subNullable
+		}
+	case RepetitionOperator:
+		subNullable, subFirst := a.analyze(operator.subOperator)
+		switch {
+		case 0 <= operator.max && operator.max < operator.min:
+			// matches nothing
+		case operator.max == 0:
+			nullable = true
+		default:
+			nullable = operator.min == 0 || subNullable
+			first = subFirst
+		}
+	case RuleNameOperator:
+		if _, ok := a.set[operator.key]; !ok {
+			nullable = true
+			first.AddRange(0, 255)
+			break
+		}
+		id := idOf(operator)
+		return a.nullable[id], a.first[id]
+	case OptionOperator:
+		_, first = a.analyze(operator.subOperator)
+		nullable = true
+	case CharacterValueOperator:
+		nullable = operator.value == ""
+		if !nullable {
+			first.Add(operator.value[0])
+		}
+	case NumericValueOperator:
+		first = numericFirst(operator)
+	}
+	a.nullable[idOf(operator)], a.first[idOf(operator)] = nullable, first
+	return nullable, first
+}
+
+// numericFirst returns the first bytes of the given numeric value.
+func numericFirst(operator NumericValueOperator) operators.ByteSet {
+	var first operators.ByteSet
+	if sets, err := terminalBytes(operator); err == nil {
+		return sets[0]
+	}
+	low, _ := operator.bytes()
+	switch {
+	case len(low) == 0:
+	case operator.hyphen:
+		// ranges of multiple bytes are compared byte by byte, so only the first byte of the low value is certain
+		first.AddRange(low[0], 255)
+	default:
+		first.Add(low[0])
+	}
+	return first
+}
+
+// addFollow adds the FOLLOW sets of the given operator (of the given rule) and its sub operators. The operator is
+// followed by the given bytes and, if end is true, by the bytes that follow the rule.
+func (a *Analysis) addFollow(operator Operator, rule string, bytes operators.ByteSet, end bool) {
+	id := idOf(operator)
+	f, ok := a.follow[id]
+	if !ok {
+		f = &follow{rules: make(map[string]bool)}
+		a.follow[id] = f
+	}
+	f.bytes = f.bytes.Union(bytes)
+	if end {
+		f.rules[rule] = true
+	}
+
+	switch operator := operator.(type) {
+	case AlternationOperator:
+		for _, subOperator := range operator.subOperators {
+			a.addFollow(subOperator, rule, bytes, end)
+		}
+	case ConcatenationOperator:
+		// from right to left
+		for i := len(operator.subOperators) - 1; 0 <= i; i-- {
+			subOperator := operator.subOperators[i]
+			a.addFollow(subOperator, rule, bytes, end)
+			if !a.nullable[idOf(subOperator)] {
+				bytes, end = operators.ByteSet{}, false
+			}
+			bytes = bytes.Union(a.first[idOf(subOperator)])
+		}
+	case RepetitionOperator:
+		if operator.max < 0 || 1 < operator.max {
+			// followed by the next repetition
+			bytes = bytes.Union(a.first[idOf(operator.subOperator)])
+		}
+		a.addFollow(operator.subOperator, rule, bytes, end)
+	case OptionOperator:
+		a.addFollow(operator.subOperator, rule, bytes, end)
+	}
+}
+
+// Nullable returns whether the rule with the given name matches the empty input.
+func (a *Analysis) Nullable(rule string) bool {
+	return a.NullableOperator(RuleNameOperator{key: rule})
+}
+
+// First returns the bytes a non-empty match of the rule with the given name can start with.
+func (a *Analysis) First(rule string) operators.ByteSet {
+	return a.FirstOperator(RuleNameOperator{key: rule})
+}
+
+// Follow returns the bytes that can follow a match of the rule with the given name, within the other rules.
+func (a *Analysis) Follow(rule string) operators.ByteSet {
+	return a.FollowOperator(RuleNameOperator{key: rule})
+}
+
+// NullableOperator returns whether the given operator of the set matches the empty input.
+func (a *Analysis) NullableOperator(operator Operator) bool {
+	if nullable, ok := a.nullable[idOf(operator)]; ok {
+		return nullable
+	}
+	nullable, _ := a.analyze(operator)
+	return nullable
+}
+
+// FirstOperator returns the bytes a non-empty match of the given operator of the set can start with.
+func (a *Analysis) FirstOperator(operator Operator) operators.ByteSet {
+	if first, ok := a.first[idOf(operator)]; ok {
+		return first
+	}
+	_, first := a.analyze(operator)
+	return first
+}
+
+// FollowOperator returns the bytes that can follow a match of the given operator of the set.
+func (a *Analysis) FollowOperator(operator Operator) operators.ByteSet {
+	f, ok := a.follow[idOf(operator)]
+	if !ok {
+		return operators.ByteSet{}
+	}
+	bytes := f.bytes
+	for rule := range f.rules {
+		if other, ok := a.follow[idOf(RuleNameOperator{key: rule})]; ok {
+			bytes = bytes.Union(other.bytes)
+		}
+	}
+	return bytes
+}
@@ -0,0 +1,56 @@
+package abnf
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	set := NewRuleSet([]byte(strings.Join([]string{
+		`list = item *("," item) [";"]`,
+		`item = *DIGIT / name "=" value`,
+		`name = 1*ALPHA`,
+		`value = 0"x" / %x22 name %x22`,
+		`never = 2*1"y"`,
+		`other = unknown "z"`,
+	}, "\n") + "\n"))
+	for name, rule := range CoreRules(false) {
+		set[name] = rule
+	}
+	a := set.Analyze()
+
+	for _, test := range []struct {
+		rule          string
+		nullable      bool
+		first, follow string
+	}{
+		{"list", true, "%x2C / %x30-39 / %x3B / %x41-5A / %x61-7A", ""},
+		{"item", true, "%x30-39 / %x41-5A / %x61-7A", "%x2C / %x3B"},
+		{"name", false, "%x41-5A / %x61-7A", "%x22 / %x3D"},
+		{"value", true, "%x22", "%x2C / %x3B"},
+		{"never", false, "", ""},
+		{"other", false, "%x00-FF", ""},
+		{"DIGIT", false, "%x30-39", "%x2C / %x30-39 / %x3B"},
+		{"ALPHA", false, "%x41-5A / %x61-7A", "%x22 / %x3D / %x41-5A / %x61-7A"},
+		{"CRLF", false, "%x0A / %x0D", "%x09 / %x20"},
+	} {
+		if nullable := a.Nullable(test.rule); nullable != test.nullable {
+			t.Errorf("%s: expected nullable %t", test.rule, test.nullable)
+		}
+		if first := a.First(test.rule).String(); first != test.first {
+			t.Errorf("%s: expected FIRST %s, got %s", test.rule, test.first, first)
+		}
+		if follow := a.Follow(test.rule).String(); follow != test.follow {
+			t.Errorf("%s: expected FOLLOW %s, got %s", test.rule, test.follow, follow)
+		}
+	}
+
+	// operators are looked up by their ABNF text
+	repetition := set["list"].operator.(ConcatenationOperator).subOperators[1]
+	if !a.NullableOperator(repetition) || a.FirstOperator(repetition).String() != "%x2C" {
+		t.Errorf("invalid analysis of %s", repetition.Key())
+	}
+	if follow := a.FollowOperator(repetition); follow.String() != "%x3B" {
+		t.Errorf("invalid FOLLOW of %s: %s", repetition.Key(), follow)
+	}
+}
@@ -0,0 +1,125 @@
+// Command abnf inspects ABNF grammars.
+//
+//	abnf diff [-core] old.abnf new.abnf
+//	abnf analyze [-core] grammar.abnf
+//
+// Grammars are loaded with the abnf.Loader, so they can import other files. With -core, the core rules are always
+// available.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/elimity-com/abnf"
+)
+
+const usage = `usage: abnf <command> [arguments]
+
+commands:
+  diff [-core] old.abnf new.abnf    list the rules that were added, removed or modified
+  analyze [-core] grammar.abnf      list whether the rules are nullable, and their FIRST and FOLLOW sets
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	var err error
+	switch command, args := os.Args[1], os.Args[2:]; command {
+	case "diff":
+		err = diff(os.Stdout, args)
+	case "analyze":
+		err = analyze(os.Stdout, args)
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// load loads the rules of the given file, and of the core rules if core is true.
+func load(file string, core bool) (abnf.RuleSet, error) {
+	files := []string{file}
+	if core {
+		files = []string{"core", file}
+	}
+	grammar, err := abnf.Loader{}.Load(files...)
+	if err != nil {
+		return nil, err
+	}
+	return grammar.Rules, nil
+}
+
+func diff(w io.Writer, args []string) error {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	core := flags.Bool("core", false, "load the core rules")
+	_ = flags.Parse(args)
+	if flags.NArg() != 2 {
+		return fmt.Errorf("usage: abnf diff [-core] old.abnf new.abnf")
+	}
+	old, err := load(flags.Arg(0), *core)
+	if err != nil {
+		return err
+	}
+	updated, err := load(flags.Arg(1), *core)
+	if err != nil {
+		return err
+	}
+
+	for _, diff := range abnf.Diff(old, updated) {
+		switch diff.Change {
+		case abnf.ChangeAdded:
+			fmt.Fprintf(w, "+ %s\n", diff.Name)
+		case abnf.ChangeRemoved:
+			fmt.Fprintf(w, "- %s\n", diff.Name)
+		case abnf.ChangeModified:
+			fmt.Fprintf(w, "~ %s (%s)\n", diff.Name, diff.Effect)
+			for _, operator := range diff.Operators {
+				switch {
+				case operator.Old == "":
+					fmt.Fprintf(w, "\t+ %s\n", operator.New)
+				case operator.New == "":
+					fmt.Fprintf(w, "\t- %s\n", operator.Old)
+				default:
+					fmt.Fprintf(w, "\t%s -> %s\n", operator.Old, operator.New)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func analyze(w io.Writer, args []string) error {
+	flags := flag.NewFlagSet("analyze", flag.ExitOnError)
+	core := flags.Bool("core", false, "load the core rules")
+	_ = flags.Parse(args)
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: abnf analyze [-core] grammar.abnf")
+	}
+	set, err := load(flags.Arg(0), *core)
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	a := set.Analyze()
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "RULE\tNULLABLE\tFIRST\tFOLLOW")
+	for _, name := range names {
+		fmt.Fprintf(tw, "%s\t%t\t%s\t%s\n", name, a.Nullable(name), a.First(name), a.Follow(name))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	var b bytes.Buffer
+	if err := diff(&b, []string{"-core", "testdata/old.abnf", "testdata/new.abnf"}); err != nil {
+		t.Fatal(err)
+	}
+	// the list rule is recursive, so removing "y" does not prove that it narrows
+	expected := "~ a (widens)\n" +
+		"\t1*DIGIT -> *DIGIT\n" +
+		"- c\n" +
+		"+ d\n" +
+		"~ list (unknown)\n" +
+		"\t- \"y\"\n" +
+		"\t+ \"z\"\n"
+	if b.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, b.String())
+	}
+}
@@ -5,9 +5,16 @@ import (
 	"io"
 	"sort"
 	"strconv"
+	"strings"
+
+	"github.com/elimity-com/abnf/operators"
 )
 
-const operatorsPkg = "github.com/elimity-com/abnf/operators"
+const (
+	operatorsPkg = "github.com/elimity-com/abnf/operators"
+	pegPkg       = "github.com/elimity-com/abnf/operators/peg"
+	recognizePkg = "github.com/elimity-com/abnf/operators/recognize"
+)
 
 type CodeGenerator struct {
 	writer io.Writer
@@ -21,9 +28,57 @@ type CodeGenerator struct {
 	// ExternalABNF reference to abnf syntax
 	// e.g. ALPHA from github.com/elimity-com/abnf/core
 	ExternalABNF map[string]ExternalABNF
+	// PEG generates operators of the peg package, with ordered choice and greedy repetitions
+	// external (operators) references get lifted to peg operators
+	PEG bool
+	// DFA compiles the rules that are not recursive to deterministic automata, these only return a leaf node per match
+	// references to the (strict) core package get inlined, other ExternalABNF can not be compiled
+	// it is ignored in PEG mode
+	DFA bool
+	// Dispatch generates alternations that only try the alternatives that can start with the next byte of the input,
+	// based on their FIRST sets (see Analyze), references to the (strict) core package are analysed as well, other
+	// ExternalABNF are assumed to start with any byte
+	// it is ignored in PEG mode
+	Dispatch bool
+	// Recognizers also generates a recognizer for every rule (e.g. RecognizeALPHA), these do not build parse trees but
+	// only return the lengths of the matches (see recognize.Recognize)
+	// it is ignored in PEG mode
+	Recognizers bool
+	// RecursiveDescent generates plain functions for the rules and their operators, instead of operators that get
+	// constructed on every call, the parse trees are the same
+	// it is ignored in PEG mode
+	RecursiveDescent bool
+	// Actions also generates a function for every (exported) rule that parses the whole input and runs the given
+	// operators.Actions bottom-up on the longest parse tree (e.g. ParseALPHA, see operators.Parse)
+	// it is ignored in PEG mode
+	Actions bool
+	// Registry also generates a constant for every rule name (e.g. RuleALPHA), a Rules map from those names to the
+	// operators and the Metadata of the rules (their ABNF text and the rules they refer to)
+	Registry bool
+	// RuleNames overrides the Go names of rules, e.g. "rulename" as "Name" instead of "Rulename"
+	RuleNames map[string]string
+	// FormatRuleName returns the Go names of the other rules, by default the hyphen separated parts get title-cased
+	// e.g. "foo-bar" as "FooBar", names that collide get a numeric suffix (see Names)
+	FormatRuleName func(name string) string
+	// StartRules are the entry rules of the grammar, see Prune and UnexportHelpers
+	StartRules []string
+	// Prune only generates the StartRules and the rules they (indirectly) refer to, the other rules are left out
+	Prune bool
+	// UnexportHelpers generates the rules that are not part of the StartRules as unexported functions (and unexported
+	// recognizers and constants), e.g. "foo-bar" as fooBar and "ALPHA" as alpha
+	UnexportHelpers bool
 
 	isOperator bool
-	synonyms   map[string]string
+	analysis   *Analysis
+	names      map[string]string
+	bases      map[string]string
+
+	descentDFAs      map[string]*operators.DFA
+	descentRule      string
+	descentFunctions []descentFunction
+
+	// element is the last operator of the current rule that got written, see generateElement
+	element operators.Element
 }
 
 func (g *CodeGenerator) c(format string, args ...interface{}) error {
@@ -75,14 +130,9 @@ type ExternalABNF struct {
 	PackagePath string
 	// PackageName: e.g. core
 	PackageName string
-}
-
-func (g *CodeGenerator) syn(key string) string {
-	if syn, ok := g.synonyms[key]; ok {
-		delete(g.synonyms, key) // consume
-		return syn
-	}
-	return key
+	// Recognizers: the package contains recognizers of the rules (e.g. RecognizeALPHA), otherwise the operators get
+	// lifted (see recognize.Lift)
+	Recognizers bool
 }
 
 // GenerateABNFAsOperators returns a *jen.File containing the given ABNF syntax as Go Operator functions.
@@ -95,81 +145,420 @@ func (g *CodeGenerator) GenerateABNFAsOperators(w io.Writer) {
 // GenerateABNFAsAlternatives returns a *jen.File containing the given ABNF syntax as Go functions that return Alternatives.
 func (g *CodeGenerator) GenerateABNFAsAlternatives(w io.Writer) {
 	g.writer = w
+	g.isOperator = false
 	g.generate()
 }
 
 func (g *CodeGenerator) generate() {
-	g.synonyms = make(map[string]string) // synonyms
-
 	g.c("This file is generated - do not edit.")
 	g.ln()
 	g.wlnf("package %s", g.PackageName)
 	g.ln()
-	g.w("import ")
-	if len(g.ExternalABNF) != 0 {
-		imports := make(map[string]struct{})
-		for _, i := range g.ExternalABNF {
-			imports[i.PackagePath] = struct{}{}
-		}
-		g.wln("(")
-		g.in(func() {
-			for i := range imports {
-				g.wlnf("%q", i)
+
+	ruleSet := g.ruleSet()
+	g.names, g.bases = g.ruleNames(ruleSet)
+	dfas := make(map[string]*operators.DFA)
+	if g.DFA && !g.PEG {
+		dfaRuleSet := g.dfaRuleSet(ruleSet)
+		for _, name := range dfaRuleSet.NonRecursive() {
+			if _, ok := ruleSet[name]; !ok {
+				continue
 			}
-			g.ln()
-			g.wlnf("%q", operatorsPkg)
-		})
-		g.wln(")")
+			if dfa, err := dfaRuleSet.DFA(name); err == nil {
+				dfas[name] = dfa
+			}
+		}
+	}
+	g.analysis = nil
+	if g.Dispatch && !g.PEG {
+		g.analysis = g.dispatchRuleSet(ruleSet).Analyze()
+	}
+	g.imports(ruleSet, dfas)
+
+	descent := g.RecursiveDescent && !g.PEG
+	if descent {
+		g.descent(ruleSet, dfas)
 	} else {
-		g.wlnf("%q", operatorsPkg)
+		g.operators(ruleSet)
+	}
+	recognizers := g.Recognizers && !g.PEG
+	if recognizers {
+		g.recognizers(ruleSet)
+	}
+	if g.Actions && !g.PEG {
+		g.parsers(ruleSet)
 	}
+	if g.Registry {
+		g.registry(ruleSet)
+	}
+	if descent && !recognizers && !g.Registry {
+		return
+	}
+
+	g.ln()
+	g.wln("func init() {")
+	g.in(func() {
+		if !descent {
+			for _, name := range ruleSet.names() {
+				g.wf("operator%s = ", g.base(name))
+				if dfa, ok := dfas[name]; ok {
+					g.w("(")
+					g.dfa(dfa)
+					g.wf(").Operator(%q)", name)
+				} else {
+					ruleSet[name].generate(g)
+				}
+				g.ln()
+			}
+		}
+		if recognizers {
+			for _, name := range ruleSet.names() {
+				g.wf("%s = ", g.recognizerVariable(name))
+				if dfa, ok := dfas[name]; ok {
+					g.w("recognize.DFA(")
+					g.dfa(dfa)
+					g.w(")")
+				} else {
+					ruleSet[name].operator.recognizer(g)
+				}
+				g.ln()
+			}
+		}
+		if g.Registry {
+			g.registryInit(ruleSet)
+		}
+	})
+	g.wln("}")
+}
 
+// ruleSet returns the rules of the RawABNF that get generated, without the ones that are not reachable from the
+// StartRules if Prune is set.
+func (g *CodeGenerator) ruleSet() RuleSet {
 	ruleSet := NewRuleSet(g.RawABNF)
+	if !g.Prune {
+		return ruleSet
+	}
+	reachable := make(RuleSet)
+	var reach func(name string)
+	reach = func(name string) {
+		rule, ok := ruleSet[name]
+		if _, done := reachable[name]; !ok || done {
+			return
+		}
+		reachable[name] = rule
+		_ = walkOperators(rule.operator, func(operator Operator) error {
+			if reference, ok := operator.(RuleNameOperator); ok {
+				if _, external := g.ExternalABNF[reference.key]; !external {
+					reach(reference.key)
+				}
+			}
+			return nil
+		})
+	}
+	for _, name := range g.StartRules {
+		reach(name)
+	}
+	return reachable
+}
 
-	keys := make([]string, 0)
-	for k, _ := range ruleSet {
-		keys = append(keys, k)
+// operators writes a function for every rule. The operators are stored in variables that get initialized at the start
+// of the program, so they are only constructed once. References to other rules go through functions (e.g. parseALPHA
+// for operators), so the rules can refer to each other before they are initialized.
+func (g *CodeGenerator) operators(ruleSet RuleSet) {
+	referenced := make(map[string]bool)
+	for _, rule := range ruleSet {
+		_ = walkOperators(rule.operator, func(operator Operator) error {
+			if reference, ok := operator.(RuleNameOperator); ok {
+				if _, ok := g.ExternalABNF[reference.key]; !ok {
+					referenced[reference.key] = true
+				}
+			}
+			return nil
+		})
 	}
-	sort.Strings(keys)
+	for _, name := range ruleSet.names() {
+		operator := "operator" + g.base(name)
+		g.ln()
+		g.c("%s = %s", name, ruleSet[name].operator.Key())
+		if g.isOperator {
+			g.wlnf("func %s() %s.Operator {", g.name(name), g.pkg())
+			g.in(func() {
+				g.wlnf("return %s", operator)
+			})
+			g.wln("}")
+			g.ln()
+		}
+		if !g.isOperator || referenced[name] {
+			if g.isOperator {
+				g.wlnf("func parse%s(s []byte) %s {", g.base(name), g.result())
+			} else {
+				g.wlnf("func %s(s []byte) %s {", g.name(name), g.result())
+			}
+			g.in(func() {
+				g.wlnf("return %s(s)", operator)
+			})
+			g.wln("}")
+			g.ln()
+		}
+		g.wlnf("var %s %s.Operator", operator, g.pkg())
+	}
+}
 
-	for _, k := range keys {
-		rule := ruleSet[k]
+// result returns the type of the result of the operators.
+func (g *CodeGenerator) result() string {
+	if g.PEG {
+		return "*operators.Node"
+	}
+	return "operators.Alternatives"
+}
 
+// recognizers writes a recognizer for every rule. They are stored in variables that get initialized at the start of
+// the program (like the operators), so they are only constructed once and can refer to each other.
+func (g *CodeGenerator) recognizers(ruleSet RuleSet) {
+	for _, name := range ruleSet.names() {
+		g.ln()
+		g.c("%s = %s", name, ruleSet[name].operator.Key())
+		g.wlnf("func %s(s []byte, lengths []int) []int {", g.recognizerName(name))
+		g.in(func() {
+			g.wlnf("return %s(s, lengths)", g.recognizerVariable(name))
+		})
+		g.wln("}")
 		g.ln()
-		g.c("%s = %s", rule.name, rule.operator.Key())
-		g.wf("func %s(", formatRuleName(rule.name))
+		g.wlnf("var %s recognize.Operator", g.recognizerVariable(name))
+	}
+}
+
+// parsers writes a function for every exported rule that parses the whole input and runs the given actions on the parse
+// tree, helpers are not parsed on their own.
+func (g *CodeGenerator) parsers(ruleSet RuleSet) {
+	for _, name := range ruleSet.names() {
+		if !g.exported(name) {
+			continue
+		}
+		operator := g.name(name)
 		if g.isOperator {
-			g.wln(") operators.Operator {")
-		} else {
-			g.wln("s []byte) operators.Alternatives {")
+			operator += "()"
 		}
+		g.ln()
+		g.c("%s parses the whole input as %s and runs the actions on the longest parse tree, see operators.Parse.",
+			g.parserName(name), name)
+		g.wlnf("func %s(s []byte, actions operators.Actions) (interface{}, error) {", g.parserName(name))
 		g.in(func() {
-			g.w("return ")
-			rule.generate(g)
-			if !g.isOperator {
-				g.w("(s)")
-			}
-			g.ln()
+			g.wlnf("return operators.Parse(%s, s, actions)", operator)
 		})
 		g.wln("}")
 	}
 }
 
+// dfaRuleSet returns the rules that can be used to compile DFAs, including the referenced (strict) core rules.
+func (g *CodeGenerator) dfaRuleSet(ruleSet RuleSet) RuleSet {
+	set := make(RuleSet)
+	for name, rule := range ruleSet {
+		set[name] = rule
+	}
+	for name, external := range g.ExternalABNF {
+		if _, ok := set[name]; ok {
+			continue
+		}
+		var core RuleSet
+		switch external.PackagePath {
+		case corePkgPath:
+			core = CoreRules(false)
+		case strictCorePkgPath:
+			core = CoreRules(true)
+		}
+		if rule, ok := core[name]; ok {
+			set[name] = rule
+		}
+	}
+	return set
+}
+
+// dispatchRuleSet returns the rules that are used to analyse the alternations, the ExternalABNF replace the rules of
+// the set (like in the generated code). Only the (strict) core rules are known, together with the core rules they refer
+// to, unless those are part of the set.
+func (g *CodeGenerator) dispatchRuleSet(ruleSet RuleSet) RuleSet {
+	internal, set := make(RuleSet), make(RuleSet)
+	for name, rule := range ruleSet {
+		if _, ok := g.ExternalABNF[name]; !ok {
+			internal[name], set[name] = rule, rule
+		}
+	}
+	for name, external := range g.ExternalABNF {
+		var core RuleSet
+		switch external.PackagePath {
+		case corePkgPath:
+			core = CoreRules(false)
+		case strictCorePkgPath:
+			core = CoreRules(true)
+		}
+		rules := make(RuleSet)
+		var add func(name string) bool
+		add = func(name string) bool {
+			if _, ok := rules[name]; ok {
+				return true
+			}
+			rule, ok := core[name]
+			if _, isInternal := internal[name]; !ok || isInternal {
+				return false
+			}
+			rules[name] = rule
+			return walkOperators(rule.operator, func(operator Operator) error {
+				if reference, ok := operator.(RuleNameOperator); ok && !add(reference.key) {
+					return fmt.Errorf("unknown rule: %s", reference.key)
+				}
+				return nil
+			}) == nil
+		}
+		if add(name) {
+			for name, rule := range rules {
+				set[name] = rule
+			}
+		}
+	}
+	return set
+}
+
+// dfa writes the given DFA.
+func (g *CodeGenerator) dfa(dfa *operators.DFA) {
+	g.wln("&operators.DFA{States: []operators.DFAState{")
+	g.in(func() {
+		for _, state := range dfa.States {
+			g.w("{")
+			if state.Accepting {
+				g.w("Accepting: true")
+				if len(state.Edges) != 0 {
+					g.w(", ")
+				}
+			}
+			if len(state.Edges) != 0 {
+				g.w("Edges: []operators.DFAEdge{")
+				for i, edge := range state.Edges {
+					if i != 0 {
+						g.w(", ")
+					}
+					g.wf("{Low: %d, High: %d, Target: %d}", edge.Low, edge.High, edge.Target)
+				}
+				g.w("}")
+			}
+			g.wln("},")
+		}
+	})
+	g.w("}}")
+}
+
+// pkg returns the name of the package that contains the operators.
+func (g *CodeGenerator) pkg() string {
+	if g.PEG {
+		return "peg"
+	}
+	return "operators"
+}
+
+// imports writes the imports of all the packages that are used by the given rules, rules that get compiled to a DFA do
+// not use any external packages.
+func (g *CodeGenerator) imports(ruleSet RuleSet, dfas map[string]*operators.DFA) {
+	var external, internal []string
+	paths := make(map[string]struct{})
+	var references bool
+	for _, name := range ruleSet.names() {
+		if _, ok := dfas[name]; ok {
+			continue
+		}
+		_ = walkOperators(ruleSet[name].operator, func(operator Operator) error {
+			i, ok := g.ExternalABNF[operator.Key()]
+			if _, isName := operator.(RuleNameOperator); !isName || !ok {
+				references = references || isName
+				return nil
+			}
+			if _, ok := paths[i.PackagePath]; !ok {
+				paths[i.PackagePath] = struct{}{}
+				external = append(external, i.PackagePath)
+			}
+			return nil
+		})
+	}
+	sort.Strings(external)
+	if g.PEG {
+		// the references to other rules return nodes
+		if !g.isOperator || references {
+			internal = append(internal, operatorsPkg)
+		}
+		internal = append(internal, pegPkg)
+	} else {
+		internal = append(internal, operatorsPkg)
+		if g.Recognizers {
+			internal = append(internal, recognizePkg)
+		}
+	}
+
+	g.w("import ")
+	if len(external)+len(internal) == 1 {
+		g.wlnf("%q", internal[0])
+		return
+	}
+	g.wln("(")
+	g.in(func() {
+		for _, i := range external {
+			g.wlnf("%q", i)
+		}
+		if len(external) != 0 {
+			g.ln()
+		}
+		for _, i := range internal {
+			g.wlnf("%q", i)
+		}
+	})
+	g.wln(")")
+}
+
 type codeGeneratorNode interface {
 	generate(g *CodeGenerator)
+	recognizer(g *CodeGenerator)
+	descent(g *CodeGenerator, name string, element operators.Element)
 }
 
 func (r Rule) generate(g *CodeGenerator) {
-	g.synonyms[r.operator.Key()] = r.name
+	g.wf("%s.Rule(%q, ", g.pkg(), r.name)
+	g.element = operators.Element{Rule: r.name}
 	r.operator.generate(g)
+	g.w(")")
+}
+
+// generateElement writes the given sub operator of the current rule. The operators of a rule are written in pre-order,
+// so they get numbered as they are written. Anonymous operators get wrapped, so their nodes refer to their element
+// (see operators.WithElement).
+func (g *CodeGenerator) generateElement(operator Operator) {
+	g.element.Index++
+	if _, ok := operator.(RuleNameOperator); ok {
+		operator.generate(g)
+		return
+	}
+	g.wf("%s.WithElement(%q, %d, ", g.pkg(), g.element.Rule, g.element.Index)
+	operator.generate(g)
+	g.w(")")
 }
 
 func (alt AlternationOperator) generate(g *CodeGenerator) {
-	g.wln("operators.Alts(")
+	first, ok := dispatch(g.analysis, alt)
+	if ok {
+		g.wlnf("%s.AltsFirst(", g.pkg())
+	} else {
+		g.wlnf("%s.Alts(", g.pkg())
+	}
 	g.in(func() {
-		g.wlnf("%q,", g.syn(alt.key))
+		g.wlnf("%q,", alt.key)
+		if ok {
+			g.wln("[]operators.ByteSet{")
+			g.in(func() {
+				for _, bytes := range first {
+					g.wlnf("{%#x, %#x, %#x, %#x},", bytes[0], bytes[1], bytes[2], bytes[3])
+				}
+			})
+			g.wln("},")
+		}
 		for _, operator := range alt.subOperators {
-			operator.generate(g)
+			g.generateElement(operator)
 			g.wln(",")
 		}
 	})
@@ -177,11 +566,11 @@ func (alt AlternationOperator) generate(g *CodeGenerator) {
 }
 
 func (concat ConcatenationOperator) generate(g *CodeGenerator) {
-	g.wln("operators.Concat(")
+	g.wlnf("%s.Concat(", g.pkg())
 	g.in(func() {
-		g.wlnf("%q,", g.syn(concat.key))
+		g.wlnf("%q,", concat.key)
 		for _, operator := range concat.subOperators {
-			operator.generate(g)
+			g.generateElement(operator)
 			g.wln(",")
 		}
 	})
@@ -189,44 +578,47 @@ func (concat ConcatenationOperator) generate(g *CodeGenerator) {
 }
 
 func (rep RepetitionOperator) generate(g *CodeGenerator) {
-	if rep.min == rep.max {
-		g.wf("operators.RepeatN(%q, %q, ", g.syn(rep.key), rep.min)
-	} else if rep.max == -1 {
-		switch rep.min {
-		case 0:
-			g.wf("operators.Repeat0Inf(%q, ", g.syn(rep.key))
-		case 1:
-			g.wf("operators.Repeat1Inf(%q, ", g.syn(rep.key))
-		}
-	} else {
-		g.wf("operators.Repeat(%q, %q, %q, ", g.syn(rep.key), rep.min, rep.max)
+	switch {
+	case rep.min == rep.max:
+		g.wf("%s.RepeatN(%q, %d, ", g.pkg(), rep.key, rep.min)
+	case rep.min == 0 && rep.max == -1:
+		g.wf("%s.Repeat0Inf(%q, ", g.pkg(), rep.key)
+	case rep.min == 1 && rep.max == -1:
+		g.wf("%s.Repeat1Inf(%q, ", g.pkg(), rep.key)
+	default:
+		g.wf("%s.Repeat(%q, %d, %d, ", g.pkg(), rep.key, rep.min, rep.max)
 	}
-	rep.subOperator.generate(g)
+	g.generateElement(rep.subOperator)
 	g.w(")")
 }
 
 func (name RuleNameOperator) generate(g *CodeGenerator) {
 	if external, ok := g.ExternalABNF[name.key]; ok {
+		if g.PEG {
+			g.w("peg.Lift(")
+		}
 		g.wf("%s.%s", external.PackageName, name.key)
 		if external.IsOperator {
 			g.w("()")
 		}
-	} else {
-		g.w(formatRuleName(g.syn(name.key)))
-		if g.isOperator {
-			g.w("()")
+		if g.PEG {
+			g.w(")")
 		}
+	} else if g.isOperator {
+		g.w("parse" + g.base(name.key))
+	} else {
+		g.w(g.name(name.key))
 	}
 }
 
 func (opt OptionOperator) generate(g *CodeGenerator) {
-	g.wf("operators.Optional(%q, ", g.syn(opt.key))
-	opt.subOperator.generate(g)
+	g.wf("%s.Optional(%q, ", g.pkg(), opt.key)
+	g.generateElement(opt.subOperator)
 	g.w(")")
 }
 
 func (value CharacterValueOperator) generate(g *CodeGenerator) {
-	g.wf("operators.String(%q, %q)", g.syn(value.value), value.value)
+	g.wf("%s.String(%q, %q)", g.pkg(), value.value, value.value)
 }
 
 func (value NumericValueOperator) generate(g *CodeGenerator) {
@@ -242,7 +634,7 @@ func (value NumericValueOperator) generate(g *CodeGenerator) {
 		for _, v := range max {
 			maxValues = strconv.Itoa(v)
 		}
-		g.wf("operators.Range(%q, []byte{%s}, []byte{%s})", g.syn(value.key), minValues, maxValues)
+		g.wf("%s.Range(%q, []byte{%s}, []byte{%s})", g.pkg(), value.key, minValues, maxValues)
 	} else if value.points {
 		var str string
 		for _, part := range values {
@@ -252,12 +644,108 @@ func (value NumericValueOperator) generate(g *CodeGenerator) {
 			}
 			str += string(bytes)
 		}
-		g.wf("operators.String(%q, %q)", g.syn(value.key), str)
+		g.wf("%s.String(%q, %q)", g.pkg(), value.key, str)
 	} else {
 		var bytes string
 		for _, v := range values[0] {
 			bytes = strconv.Itoa(v)
 		}
-		g.wf("operators.Terminal(%q, []byte{%s})", g.syn(value.key), bytes)
+		g.wf("%s.Terminal(%q, []byte{%s})", g.pkg(), value.key, bytes)
+	}
+}
+
+func (alt AlternationOperator) recognizer(g *CodeGenerator) {
+	first, ok := dispatch(g.analysis, alt)
+	if ok {
+		g.wln("recognize.AltsFirst(")
+	} else {
+		g.wln("recognize.Alts(")
+	}
+	g.in(func() {
+		if ok {
+			g.wln("[]operators.ByteSet{")
+			g.in(func() {
+				for _, bytes := range first {
+					g.wlnf("{%#x, %#x, %#x, %#x},", bytes[0], bytes[1], bytes[2], bytes[3])
+				}
+			})
+			g.wln("},")
+		}
+		for _, operator := range alt.subOperators {
+			operator.recognizer(g)
+			g.wln(",")
+		}
+	})
+	g.w(")")
+}
+
+func (concat ConcatenationOperator) recognizer(g *CodeGenerator) {
+	g.wln("recognize.Concat(")
+	g.in(func() {
+		for _, operator := range concat.subOperators {
+			operator.recognizer(g)
+			g.wln(",")
+		}
+	})
+	g.w(")")
+}
+
+func (rep RepetitionOperator) recognizer(g *CodeGenerator) {
+	switch {
+	case rep.min == rep.max:
+		g.wf("recognize.RepeatN(%d, ", rep.min)
+	case rep.min == 0 && rep.max == -1:
+		g.w("recognize.Repeat0Inf(")
+	case rep.min == 1 && rep.max == -1:
+		g.w("recognize.Repeat1Inf(")
+	default:
+		g.wf("recognize.Repeat(%d, %d, ", rep.min, rep.max)
+	}
+	rep.subOperator.recognizer(g)
+	g.w(")")
+}
+
+func (name RuleNameOperator) recognizer(g *CodeGenerator) {
+	external, ok := g.ExternalABNF[name.key]
+	switch {
+	case !ok:
+		g.w(g.recognizerName(name.key))
+	case external.Recognizers:
+		g.wf("%s.Recognize%s", external.PackageName, formatRuleName(name.key))
+	case external.IsOperator:
+		g.wf("recognize.Lift(%s.%s())", external.PackageName, name.key)
+	default:
+		g.wf("recognize.Lift(%s.%s)", external.PackageName, name.key)
+	}
+}
+
+func (opt OptionOperator) recognizer(g *CodeGenerator) {
+	g.w("recognize.Optional(")
+	opt.subOperator.recognizer(g)
+	g.w(")")
+}
+
+func (value CharacterValueOperator) recognizer(g *CodeGenerator) {
+	g.wf("recognize.String(%q)", value.value)
+}
+
+func (value NumericValueOperator) recognizer(g *CodeGenerator) {
+	low, high := value.bytes()
+	switch {
+	case value.hyphen:
+		g.wf("recognize.Range(%s, %s)", byteSlice(low), byteSlice(high))
+	case value.points:
+		g.wf("recognize.String(%q)", low)
+	default:
+		g.wf("recognize.Terminal(%s)", byteSlice(low))
+	}
+}
+
+// byteSlice returns the Go syntax of the given bytes, e.g. []byte{13, 10}.
+func byteSlice(bytes []byte) string {
+	values := make([]string, len(bytes))
+	for i, b := range bytes {
+		values[i] = strconv.Itoa(int(b))
 	}
+	return fmt.Sprintf("[]byte{%s}", strings.Join(values, ", "))
 }
@@ -2,7 +2,14 @@ package abnf
 
 import (
 	"bytes"
+	"go/ast"
+	goimporter "go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
 	"io/ioutil"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -15,42 +22,19 @@ func TestCodeGenerator_core(t *testing.T) {
 	}
 
 	g := CodeGenerator{
-		PackageName: "core",
-		RawABNF:     rawABNF,
+		PackageName:      "core",
+		RawABNF:          rawABNF,
+		Dispatch:         true,
+		Recognizers:      true,
+		RecursiveDescent: true,
+		Registry:         true,
 	}
 	b := &bytes.Buffer{}
 	g.writer = b
 	g.isOperator = true
 	g.generate()
 
-	coreABNF, err := ioutil.ReadFile("./core/core_abnf.go")
-	if err != nil {
-		t.Error(err)
-		return
-	}
-
-	var (
-		expected = strings.Split(string(coreABNF), "\n")
-		actual   = strings.Split(b.String(), "\n")
-	)
-
-	for row, expectedLine := range expected {
-		actualLine := []rune(actual[row])
-		if len(expectedLine) != len(actualLine) {
-			t.Errorf("Lines %d do not have an equal length", row+1)
-			continue
-		}
-		for column, expectedChar := range expectedLine {
-			actualChar := actualLine[column]
-			if expectedChar != actualChar {
-				t.Errorf(
-					"Characters do not match on %d-%d: %s != %s",
-					row+1, column+1, string(expectedChar), string(actualChar),
-				)
-				break
-			}
-		}
-	}
+	compareGeneratedCode(t, "./core/core_abnf.go", b.String())
 }
 
 func TestCodeGenerator_definition(t *testing.T) {
@@ -78,22 +62,87 @@ func TestCodeGenerator_definition(t *testing.T) {
 			"VCHAR":  corePkg,
 			"WSP":    corePkg,
 		},
+		Dispatch: true,
+		Registry: true,
+	}
+	b := &bytes.Buffer{}
+	g.writer = b
+	g.generate()
+
+	compareGeneratedCode(t, "./definition/abnf_definition.go", b.String())
+}
+
+func TestCodeGenerator_strictCore(t *testing.T) {
+	rawABNF, err := ioutil.ReadFile("./testdata/core_strict.abnf")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	g := CodeGenerator{
+		PackageName:      "strict",
+		RawABNF:          rawABNF,
+		Dispatch:         true,
+		Recognizers:      true,
+		RecursiveDescent: true,
+		Registry:         true,
 	}
 	b := &bytes.Buffer{}
 	g.writer = b
+	g.isOperator = true
 	g.generate()
 
-	coreABNF, err := ioutil.ReadFile("./definition/abnf_definition.go")
+	compareGeneratedCode(t, "./core/strict/core_abnf.go", b.String())
+}
+
+func TestCodeGenerator_actions(t *testing.T) {
+	for _, descent := range []bool{false, true} {
+		g := CodeGenerator{
+			PackageName:      "example",
+			RawABNF:          []byte("a = b \"-\" b\nb = 1*%x30-39\nparse-b = \"p\"\nactions = \"q\"\n"),
+			Actions:          true,
+			RecursiveDescent: descent,
+		}
+		expected := map[string]string{"a": "A", "actions": "Actions", "b": "B", "parse-b": "ParseB2"}
+		if names := g.Names(); !reflect.DeepEqual(names, expected) {
+			t.Errorf("expected %v, got %v", expected, names)
+		}
+		for _, generate := range []func(io.Writer){g.GenerateABNFAsAlternatives, g.GenerateABNFAsOperators} {
+			b := &bytes.Buffer{}
+			generate(b)
+			if !strings.Contains(b.String(), "func ParseB(s []byte, actions operators.Actions) (interface{}, error) {") {
+				t.Errorf("no parser generated for b in:\n%s", b)
+			}
+			fset := token.NewFileSet()
+			f, err := parser.ParseFile(fset, "example.go", b, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			config := types.Config{Importer: goimporter.ForCompiler(fset, "source", nil)}
+			if _, err := config.Check("example", fset, []*ast.File{f}, nil); err != nil {
+				t.Errorf("%v in:\n%s", err, b)
+			}
+		}
+	}
+}
+
+func compareGeneratedCode(t *testing.T, path string, generated string) {
+	raw, err := ioutil.ReadFile(path)
 	if err != nil {
 		t.Error(err)
 		return
 	}
 
 	var (
-		expected = strings.Split(string(coreABNF), "\n")
-		actual   = strings.Split(b.String(), "\n")
+		expected = strings.Split(string(raw), "\n")
+		actual   = strings.Split(generated, "\n")
 	)
 
+	if len(expected) != len(actual) {
+		t.Errorf("Files do not have an equal amount of lines: %d != %d", len(expected), len(actual))
+		return
+	}
+
 	for row, expectedLine := range expected {
 		actualLine := []rune(actual[row])
 		if len(expectedLine) != len(actualLine) {
@@ -112,3 +161,389 @@ func TestCodeGenerator_definition(t *testing.T) {
 		}
 	}
 }
+
+func TestCodeGenerator_PEG(t *testing.T) {
+	g := CodeGenerator{
+		PackageName:  "example",
+		RawABNF:      []byte("word = 1*ALPHA [\"-\"]\n"),
+		ExternalABNF: CoreExternalABNF(false),
+		PEG:          true,
+	}
+	b := &bytes.Buffer{}
+	g.GenerateABNFAsOperators(b)
+
+	expected := `// This file is generated - do not edit.
+
+package example
+
+import (
+	"github.com/elimity-com/abnf/core"
+
+	"github.com/elimity-com/abnf/operators/peg"
+)
+
+// word = 1*ALPHA ["-"]
+func Word() peg.Operator {
+	return operatorWord
+}
+
+var operatorWord peg.Operator
+
+func init() {
+	operatorWord = peg.Rule("word", peg.Concat(
+		"1*ALPHA [\"-\"]",
+		peg.WithElement("word", 1, peg.Repeat1Inf("1*ALPHA", peg.Lift(core.ALPHA()))),
+		peg.WithElement("word", 3, peg.Optional("[\"-\"]", peg.WithElement("word", 4, peg.String("-", "-")))),
+	))
+}
+`
+	if b.String() != expected {
+		t.Errorf("unexpected code:\n%s", b)
+	}
+
+	g.ExternalABNF = nil
+	b.Reset()
+	g.RawABNF = []byte("word = 2*3\"a\"\n")
+	g.GenerateABNFAsAlternatives(b)
+	for _, expected := range []string{
+		"import (\n\t\"github.com/elimity-com/abnf/operators\"\n\t\"github.com/elimity-com/abnf/operators/peg\"\n)\n",
+		"func Word(s []byte) *operators.Node {\n",
+		"peg.Repeat(\"2*3\\\"a\\\"\", 2, 3, peg.WithElement(\"word\", 1, peg.String(\"a\", \"a\")))",
+	} {
+		if !strings.Contains(b.String(), expected) {
+			t.Errorf("expected %q in:\n%s", expected, b)
+		}
+	}
+}
+
+func TestCodeGenerator_DFA(t *testing.T) {
+	g := CodeGenerator{
+		PackageName:  "example",
+		RawABNF:      []byte("hex = \"0x\" 1*HEXDIG\nlist = \"(\" [list] \")\"\n"),
+		ExternalABNF: CoreExternalABNF(false),
+		DFA:          true,
+	}
+	b := &bytes.Buffer{}
+	g.GenerateABNFAsAlternatives(b)
+
+	// HEXDIG gets inlined, so the core package is not imported
+	expected := `// This file is generated - do not edit.
+
+package example
+
+import "github.com/elimity-com/abnf/operators"
+
+// hex = "0x" 1*HEXDIG
+func Hex(s []byte) operators.Alternatives {
+	return operatorHex(s)
+}
+
+var operatorHex operators.Operator
+
+// list = "(" [list] ")"
+func List(s []byte) operators.Alternatives {
+	return operatorList(s)
+}
+
+var operatorList operators.Operator
+
+func init() {
+	operatorHex = (&operators.DFA{States: []operators.DFAState{
+		{Edges: []operators.DFAEdge{{Low: 48, High: 48, Target: 1}}},
+		{Edges: []operators.DFAEdge{{Low: 120, High: 120, Target: 2}}},
+		{Edges: []operators.DFAEdge{{Low: 48, High: 57, Target: 3}, {Low: 65, High: 70, Target: 3}, {Low: 97, High: 102, Target: 3}}},
+		{Accepting: true, Edges: []operators.DFAEdge{{Low: 48, High: 57, Target: 3}, {Low: 65, High: 70, Target: 3}, {Low: 97, High: 102, Target: 3}}},
+	}}).Operator("hex")
+	operatorList = operators.Rule("list", operators.Concat(
+		"\"(\" [list] \")\"",
+		operators.WithElement("list", 1, operators.String("(", "(")),
+		operators.WithElement("list", 2, operators.Optional("[list]", List)),
+		operators.WithElement("list", 4, operators.String(")", ")")),
+	))
+}
+`
+	if b.String() != expected {
+		t.Errorf("unexpected code:\n%s", b)
+	}
+}
+
+func TestCodeGenerator_recursiveDescent(t *testing.T) {
+	g := CodeGenerator{
+		PackageName:      "example",
+		RawABNF:          []byte("list = \"(\" [list] \")\" / 1*ALPHA\n"),
+		ExternalABNF:     CoreExternalABNF(false),
+		Dispatch:         true,
+		RecursiveDescent: true,
+	}
+	b := &bytes.Buffer{}
+	g.GenerateABNFAsOperators(b)
+	for _, expected := range []string{
+		"var coreALPHA = core.ALPHA()\n",
+		"func List() operators.Operator {\n\treturn parseList\n}\n",
+		"\tif len(s) == 0 || (0x41 <= s[0] && s[0] <= 0x5a) || (0x61 <= s[0] && s[0] <= 0x7a) {\n\t\tfor _, node := range parseList_3(s) {\n",
+		"\t\tfor _, n_1 := range parseList_5(s[l_0:]) {\n\t\t\tl_1 := l_0 + len(n_1.Value)\n",
+		"func repeatList_3(s []byte, i, l int) operators.Alternatives {\n",
+		"\tif i < 1 {\n\t\treturn nodes\n\t}\n",
+		"// [list]\nfunc parseList_5(s []byte) operators.Alternatives {\n\tvar nodes operators.Alternatives\n\tfor _, node := range parseList(s) {\n",
+		"\tif len(s) == 0 || s[0] != 0x29 {\n\t\treturn nil\n\t}\n",
+	} {
+		if !strings.Contains(b.String(), expected) {
+			t.Errorf("expected %q in:\n%s", expected, b)
+		}
+	}
+
+	// list is recursive, so it does not get compiled to a DFA
+	g.DFA = true
+	b.Reset()
+	g.GenerateABNFAsAlternatives(b)
+	for _, expected := range []string{
+		"func List(s []byte) operators.Alternatives {\n\tvar nodes operators.Alternatives\n",
+		"\tfor _, node := range List(s) {\n",
+	} {
+		if !strings.Contains(b.String(), expected) {
+			t.Errorf("expected %q in:\n%s", expected, b)
+		}
+	}
+	// the DFA is only compiled once
+	g.RawABNF = []byte("hex = \"0x\" 1*HEXDIG\n")
+	b.Reset()
+	g.GenerateABNFAsAlternatives(b)
+	if expected := "func Hex(s []byte) operators.Alternatives {\n\treturn dfaHex(s)\n}\n\nvar dfaHex = (&operators.DFA{"; !strings.Contains(b.String(), expected) {
+		t.Errorf("expected %q in:\n%s", expected, b)
+	}
+}
+
+func TestCodeGenerator_recursiveDescentCore(t *testing.T) {
+	// the core packages are generated as recursive descent functions, they build the same trees as the operators
+	for _, strict := range []bool{false, true} {
+		generated := CoreOperators(strict)
+		g := ParserGenerator{RawABNF: []byte(coreABNF), Dispatch: true}
+		if strict {
+			g.RawABNF = []byte(strictCoreABNF)
+		}
+		rules := g.GenerateABNFAsOperators()
+		inputs := []string{"", "\r\n", "\n \t\r\n x", "  \r\n\r\n", "0f", "Fa"}
+		for b := 0; b < 256; b++ {
+			inputs = append(inputs, string([]byte{byte(b)}))
+		}
+		for name, operator := range rules {
+			for _, input := range inputs {
+				if expected, actual := operator([]byte(input)), generated[name]([]byte(input)); !reflect.DeepEqual(expected, actual) {
+					t.Errorf("%s %q: expected %v, got %v", name, input, expected, actual)
+				}
+			}
+		}
+	}
+}
+
+func TestCodeGenerator_operators(t *testing.T) {
+	for _, peg := range []bool{false, true} {
+		g := CodeGenerator{
+			PackageName: "example",
+			RawABNF:     []byte("list = \"(\" [list] \")\"\n"),
+			PEG:         peg,
+		}
+		b := &bytes.Buffer{}
+		g.GenerateABNFAsOperators(b)
+		// recursive rules refer to the variables through a function, they are not initialized yet
+		for _, expected := range []string{
+			"func List() " + g.pkg() + ".Operator {\n\treturn operatorList\n}\n",
+			"func parseList(s []byte) " + g.result() + " {\n\treturn operatorList(s)\n}\n",
+			"var operatorList " + g.pkg() + ".Operator\n",
+			g.pkg() + ".WithElement(\"list\", 2, " + g.pkg() + ".Optional(\"[list]\", parseList)),\n",
+			"\"github.com/elimity-com/abnf/operators\"\n",
+		} {
+			if !strings.Contains(b.String(), expected) {
+				t.Errorf("expected %q in:\n%s", expected, b)
+			}
+		}
+	}
+}
+
+func TestCodeGenerator_registry(t *testing.T) {
+	g := CodeGenerator{
+		PackageName:  "example",
+		RawABNF:      []byte("list = \"(\" [list] \")\" / word ; nested\nword = 1*ALPHA\n"),
+		ExternalABNF: CoreExternalABNF(false),
+		Registry:     true,
+	}
+	b := &bytes.Buffer{}
+	g.GenerateABNFAsAlternatives(b)
+	for _, expected := range []string{
+		"type RuleName string\n",
+		"const (\n\tRuleList RuleName = \"list\"\n\tRuleWord RuleName = \"word\"\n)\n",
+		"var Rules = make(map[RuleName]operators.Operator)\n",
+		"\tRules[RuleList] = List\n",
+		"\tMetadata[RuleList] = RuleMetadata{ABNF: \"list = \\\"(\\\" [list] \\\")\\\" / word ; nested\", References: []RuleName{RuleList, RuleWord}}\n",
+		"\tMetadata[RuleWord] = RuleMetadata{ABNF: \"word = 1*ALPHA\", References: []RuleName{\"ALPHA\"}}\n",
+	} {
+		if !strings.Contains(b.String(), expected) {
+			t.Errorf("expected %q in:\n%s", expected, b)
+		}
+	}
+
+	// the operators are initialized before the registry
+	b.Reset()
+	g.GenerateABNFAsOperators(b)
+	if expected := "\tRules[RuleList] = List()\n"; !strings.Contains(b.String(), expected) ||
+		strings.Index(b.String(), expected) < strings.Index(b.String(), "\toperatorList = ") {
+		t.Errorf("expected %q after the operators in:\n%s", expected, b)
+	}
+}
+
+func TestCodeGenerator_names(t *testing.T) {
+	g := CodeGenerator{
+		PackageName: "example",
+		RawABNF: []byte(strings.Join([]string{
+			`foo-bar = "a"`,
+			`Foo-Bar = "b"`,
+			`a-bc = "c"`,
+			`ab-c = "d"`,
+			`recognize-x = "e"`,
+			`x = "f"`,
+			`y = "g"`,
+			`z = "h"`,
+		}, "\n") + "\n"),
+		Recognizers: true,
+		RuleNames:   map[string]string{"y": "Why", "z": "FooBar"},
+	}
+	expected := map[string]string{
+		"Foo-Bar": "FooBar",
+		"foo-bar": "FooBar2",
+		// the parts are title-cased, so these do not collide
+		"a-bc":        "ABc",
+		"ab-c":        "AbC",
+		"recognize-x": "RecognizeX",
+		// RecognizeX is the recognizer of x
+		"x": "X2",
+		"y": "Why",
+		// overrides can collide as well
+		"z": "FooBar3",
+	}
+	if names := g.Names(); !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected %v, got %v", expected, names)
+	}
+	b := &bytes.Buffer{}
+	g.GenerateABNFAsAlternatives(b)
+	for _, expected := range []string{
+		"// foo-bar = a\nfunc FooBar2(s []byte) operators.Alternatives {\n",
+		"// x = f\nfunc X2(s []byte) operators.Alternatives {\n",
+		"func RecognizeX2(s []byte, lengths []int) []int {\n",
+	} {
+		if !strings.Contains(b.String(), expected) {
+			t.Errorf("expected %q in:\n%s", expected, b)
+		}
+	}
+
+	// keywords, imported packages and the identifiers of the registry are taken
+	g = CodeGenerator{
+		PackageName: "example",
+		RawABNF:     []byte("type = \"a\"\noperators = \"b\"\nrule-name = \"c\"\nname = \"d\"\n"),
+		Registry:    true,
+		FormatRuleName: func(name string) string {
+			if name == "type" || name == "operators" {
+				return name
+			}
+			return formatRuleName(name)
+		},
+	}
+	expected = map[string]string{
+		"type":      "type2",
+		"operators": "operators2",
+		// RuleName2 is the constant of name
+		"rule-name": "RuleName3",
+		// its constant would be RuleName
+		"name": "Name2",
+	}
+	if names := g.Names(); !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected %v, got %v", expected, names)
+	}
+}
+
+func TestCodeGenerator_startRules(t *testing.T) {
+	g := CodeGenerator{
+		PackageName: "example",
+		RawABNF: []byte(strings.Join([]string{
+			`request = method SP uri SP HTTP-version`,
+			`method = "GET" / "POST"`,
+			`uri = 1*ALPHA`,
+			`HTTP-version = "HTTP/1.1"`,
+			`ALPHA = %x41-5A / %x61-7A`,
+			`SP = %x20`,
+			`type = "t"`,
+		}, "\n") + "\n"),
+		Recognizers:     true,
+		Registry:        true,
+		StartRules:      []string{"request"},
+		UnexportHelpers: true,
+	}
+	expected := map[string]string{
+		"request":      "Request",
+		"method":       "method",
+		"uri":          "uri",
+		"HTTP-version": "httpVersion",
+		"ALPHA":        "alpha",
+		"SP":           "sp",
+		// type is a keyword
+		"type": "type2",
+	}
+	if names := g.Names(); !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected %v, got %v", expected, names)
+	}
+	b := &bytes.Buffer{}
+	g.GenerateABNFAsOperators(b)
+	for _, expected := range []string{
+		"func Request() operators.Operator {\n",
+		"func method() operators.Operator {\n\treturn operatorMethod\n}\n\nfunc parseMethod(s []byte) operators.Alternatives {\n",
+		"func RecognizeRequest(s []byte, lengths []int) []int {\n\treturn recognizeRequest(s, lengths)\n}\n",
+		"func recognizeMethod(s []byte, lengths []int) []int {\n\treturn recognizerMethod(s, lengths)\n}\n",
+		"RuleRequest     RuleName = \"request\"\n",
+		"ruleMethod      RuleName = \"method\"\n",
+		"Rules[ruleMethod] = method()\n",
+		"References: []RuleName{ruleHTTPVersion, ruleSP, ruleMethod, ruleUri}}\n",
+	} {
+		if !strings.Contains(b.String(), expected) {
+			t.Errorf("expected %q in:\n%s", expected, b)
+		}
+	}
+
+	// only the rules that are reachable from the start rules are generated
+	g.Prune = true
+	delete(expected, "type")
+	if names := g.Names(); !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected %v, got %v", expected, names)
+	}
+	b = &bytes.Buffer{}
+	g.GenerateABNFAsOperators(b)
+	if strings.Contains(b.String(), "type2") {
+		t.Errorf("expected type to be pruned:\n%s", b)
+	}
+}
+
+func TestCodeGenerator_locals(t *testing.T) {
+	g := CodeGenerator{
+		PackageName:      "example",
+		RawABNF:          []byte("a = s nodes l1\ns = \"x\"\nnodes = \"y\"\nl1 = \"z\"\n"),
+		Recognizers:      true,
+		RecursiveDescent: true,
+		StartRules:       []string{"a"},
+		UnexportHelpers:  true,
+	}
+	expected := map[string]string{"a": "A", "s": "s2", "nodes": "nodes2", "l1": "l1"}
+	if names := g.Names(); !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected %v, got %v", expected, names)
+	}
+	for _, generate := range []func(io.Writer){g.GenerateABNFAsAlternatives, g.GenerateABNFAsOperators} {
+		b := &bytes.Buffer{}
+		generate(b)
+		fset := token.NewFileSet()
+		f, err := parser.ParseFile(fset, "example.go", b, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		config := types.Config{Importer: goimporter.ForCompiler(fset, "source", nil)}
+		if _, err := config.Check("example", fset, []*ast.File{f}, nil); err != nil {
+			t.Errorf("%v in:\n%s", err, b)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package abnf
+
+import (
+	"github.com/elimity-com/abnf/core"
+	corestrict "github.com/elimity-com/abnf/core/strict"
+	"github.com/elimity-com/abnf/operators"
+	"github.com/elimity-com/abnf/operators/recognize"
+)
+
+const (
+	corePkgPath       = "github.com/elimity-com/abnf/core"
+	strictCorePkgPath = "github.com/elimity-com/abnf/core/strict"
+)
+
+// coreABNF contains the (lenient) core rules, CRLF also accepts LF and HEXDIG also accepts lowercase letters.
+const coreABNF = `ALPHA  = %x41-5A / %x61-7A ; A-Z / a-z
+BIT    = "0" / "1"
+CHAR   = %x01-7F
+       ; any 7-bit US-ASCII character,
+       ; excluding NUL
+CR     =  %x0D ; carriage return
+CRLF   = CR LF / LF
+       ; Internet standard newline
+CTL    = %x00-1F / %x7F ; controls
+DIGIT  = %x30-39 ; 0-9
+DQUOTE = %x22
+       ; " (Double Quote)
+HEXDIG = DIGIT / "A" / "B" / "C" / "D" / "E" / "F"
+               / "a" / "b" / "c" / "d" / "e" / "f"
+HTAB   = %x09
+       ; horizontal tab
+LF     = %x0A
+       ; linefeed
+LWSP   = *(WSP / CRLF WSP)
+       ; Use of this linear-white-space rule
+       ; permits lines containing only white
+       ; space that are no longer legal in
+       ; mail headers and have caused
+       ; interoperability problems in other
+       ; contexts.
+       ; Do not use when defining mail
+       ; headers and use with caution in
+       ; other contexts.
+OCTET  = %x00-FF
+       ; 8 bits of data
+SP     = %x20
+VCHAR  = %x21-7E
+       ; visible (printing) characters
+WSP    = SP / HTAB
+       ; white space
+`
+
+// strictCoreABNF contains the core rules exactly as defined in RFC 5234 Appendix B.
+const strictCoreABNF = `ALPHA  = %x41-5A / %x61-7A ; A-Z / a-z
+BIT    = "0" / "1"
+CHAR   = %x01-7F
+       ; any 7-bit US-ASCII character,
+       ; excluding NUL
+CR     =  %x0D ; carriage return
+CRLF   = CR LF
+       ; Internet standard newline
+CTL    = %x00-1F / %x7F ; controls
+DIGIT  = %x30-39 ; 0-9
+DQUOTE = %x22
+       ; " (Double Quote)
+HEXDIG = DIGIT / "A" / "B" / "C" / "D" / "E" / "F"
+HTAB   = %x09
+       ; horizontal tab
+LF     = %x0A
+       ; linefeed
+LWSP   = *(WSP / CRLF WSP)
+       ; Use of this linear-white-space rule
+       ; permits lines containing only white
+       ; space that are no longer legal in
+       ; mail headers and have caused
+       ; interoperability problems in other
+       ; contexts.
+       ; Do not use when defining mail
+       ; headers and use with caution in
+       ; other contexts.
+OCTET  = %x00-FF
+       ; 8 bits of data
+SP     = %x20
+VCHAR  = %x21-7E
+       ; visible (printing) characters
+WSP    = SP / HTAB
+       ; white space
+`
+
+// CoreRules returns the core ABNF rules. If strict is false, CRLF also accepts LF and HEXDIG also accepts lowercase
+// letters. Otherwise the rules are exactly the ones defined in RFC 5234 Appendix B.
+func CoreRules(strict bool) RuleSet {
+	if strict {
+		return NewRuleSet([]byte(strictCoreABNF))
+	}
+	return NewRuleSet([]byte(coreABNF))
+}
+
+// CoreExternalABNF returns a reference to all the core rules, to be used as ExternalABNF of the CodeGenerator.
+// If strict is true, the rules refer to github.com/elimity-com/abnf/core/strict.
+func CoreExternalABNF(strict bool) map[string]ExternalABNF {
+	pkg := ExternalABNF{
+		IsOperator:  true,
+		PackagePath: corePkgPath,
+		PackageName: "core",
+		Recognizers: true,
+	}
+	if strict {
+		pkg.PackagePath = strictCorePkgPath
+		pkg.PackageName = "strict"
+	}
+	external := make(map[string]ExternalABNF)
+	for name := range CoreOperators(strict) {
+		external[name] = pkg
+	}
+	return external
+}
+
+// CoreOperators returns all the core rules as operators, to be used as ExternalABNF of the ParserGenerator.
+// If strict is true, the operators are taken from github.com/elimity-com/abnf/core/strict.
+func CoreOperators(strict bool) map[string]operators.Operator {
+	if strict {
+		return map[string]operators.Operator{
+			"ALPHA":  corestrict.ALPHA(),
+			"BIT":    corestrict.BIT(),
+			"CHAR":   corestrict.CHAR(),
+			"CR":     corestrict.CR(),
+			"CRLF":   corestrict.CRLF(),
+			"CTL":    corestrict.CTL(),
+			"DIGIT":  corestrict.DIGIT(),
+			"DQUOTE": corestrict.DQUOTE(),
+			"HEXDIG": corestrict.HEXDIG(),
+			"HTAB":   corestrict.HTAB(),
+			"LF":     corestrict.LF(),
+			"LWSP":   corestrict.LWSP(),
+			"OCTET":  corestrict.OCTET(),
+			"SP":     corestrict.SP(),
+			"VCHAR":  corestrict.VCHAR(),
+			"WSP":    corestrict.WSP(),
+		}
+	}
+	return map[string]operators.Operator{
+		"ALPHA":  core.ALPHA(),
+		"BIT":    core.BIT(),
+		"CHAR":   core.CHAR(),
+		"CR":     core.CR(),
+		"CRLF":   core.CRLF(),
+		"CTL":    core.CTL(),
+		"DIGIT":  core.DIGIT(),
+		"DQUOTE": core.DQUOTE(),
+		"HEXDIG": core.HEXDIG(),
+		"HTAB":   core.HTAB(),
+		"LF":     core.LF(),
+		"LWSP":   core.LWSP(),
+		"OCTET":  core.OCTET(),
+		"SP":     core.SP(),
+		"VCHAR":  core.VCHAR(),
+		"WSP":    core.WSP(),
+	}
+}
+
+// CoreRecognizers returns all the core rules as recognizers, to be used as ExternalRecognizers of the ParserGenerator.
+// If strict is true, the recognizers are taken from github.com/elimity-com/abnf/core/strict.
+func CoreRecognizers(strict bool) map[string]recognize.Operator {
+	if strict {
+		return map[string]recognize.Operator{
+			"ALPHA":  corestrict.RecognizeALPHA,
+			"BIT":    corestrict.RecognizeBIT,
+			"CHAR":   corestrict.RecognizeCHAR,
+			"CR":     corestrict.RecognizeCR,
+			"CRLF":   corestrict.RecognizeCRLF,
+			"CTL":    corestrict.RecognizeCTL,
+			"DIGIT":  corestrict.RecognizeDIGIT,
+			"DQUOTE": corestrict.RecognizeDQUOTE,
+			"HEXDIG": corestrict.RecognizeHEXDIG,
+			"HTAB":   corestrict.RecognizeHTAB,
+			"LF":     corestrict.RecognizeLF,
+			"LWSP":   corestrict.RecognizeLWSP,
+			"OCTET":  corestrict.RecognizeOCTET,
+			"SP":     corestrict.RecognizeSP,
+			"VCHAR":  corestrict.RecognizeVCHAR,
+			"WSP":    corestrict.RecognizeWSP,
+		}
+	}
+	return map[string]recognize.Operator{
+		"ALPHA":  core.RecognizeALPHA,
+		"BIT":    core.RecognizeBIT,
+		"CHAR":   core.RecognizeCHAR,
+		"CR":     core.RecognizeCR,
+		"CRLF":   core.RecognizeCRLF,
+		"CTL":    core.RecognizeCTL,
+		"DIGIT":  core.RecognizeDIGIT,
+		"DQUOTE": core.RecognizeDQUOTE,
+		"HEXDIG": core.RecognizeHEXDIG,
+		"HTAB":   core.RecognizeHTAB,
+		"LF":     core.RecognizeLF,
+		"LWSP":   core.RecognizeLWSP,
+		"OCTET":  core.RecognizeOCTET,
+		"SP":     core.RecognizeSP,
+		"VCHAR":  core.RecognizeVCHAR,
+		"WSP":    core.RecognizeWSP,
+	}
+}
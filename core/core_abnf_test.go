@@ -3,6 +3,7 @@ package core
 import (
 	"github.com/elimity-com/abnf/encoding"
 	"github.com/elimity-com/abnf/operators"
+	"github.com/elimity-com/abnf/operators/recognize"
 	"testing"
 
 	"github.com/di-wu/regen"
@@ -541,3 +542,63 @@ func TestNode(t *testing.T) {
 		})
 	}
 }
+
+func BenchmarkHEXDIG(b *testing.B) {
+	rule := HEXDIG()
+	for i := 0; i < b.N; i++ {
+		rule([]byte("f"))
+	}
+}
+
+func TestRecognizers(t *testing.T) {
+	inputs := []string{"", "\r\n", "\n \t\r\n x", "  \r\n\r\n", "0f", "Fa"}
+	for b := 0; b < 256; b++ {
+		inputs = append(inputs, string([]byte{byte(b)}))
+	}
+	for _, test := range []struct {
+		name       string
+		rule       operators.Operator
+		recognizer recognize.Operator
+	}{
+		{"ALPHA", ALPHA(), RecognizeALPHA},
+		{"BIT", BIT(), RecognizeBIT},
+		{"CHAR", CHAR(), RecognizeCHAR},
+		{"CR", CR(), RecognizeCR},
+		{"CRLF", CRLF(), RecognizeCRLF},
+		{"CTL", CTL(), RecognizeCTL},
+		{"DIGIT", DIGIT(), RecognizeDIGIT},
+		{"DQUOTE", DQUOTE(), RecognizeDQUOTE},
+		{"HEXDIG", HEXDIG(), RecognizeHEXDIG},
+		{"HTAB", HTAB(), RecognizeHTAB},
+		{"LF", LF(), RecognizeLF},
+		{"LWSP", LWSP(), RecognizeLWSP},
+		{"OCTET", OCTET(), RecognizeOCTET},
+		{"SP", SP(), RecognizeSP},
+		{"VCHAR", VCHAR(), RecognizeVCHAR},
+		{"WSP", WSP(), RecognizeWSP},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			for _, input := range inputs {
+				n, ok := recognize.Recognize(test.recognizer, []byte(input))
+				nodes := test.rule([]byte(input))
+				if ok != (len(nodes) != 0) || ok && n != len(nodes.Best().Value) {
+					t.Errorf("%q: expected %d nodes, got %d %t", input, len(nodes), n, ok)
+				}
+			}
+
+			input := []byte(" \t\r\n \t\r\nx")
+			if allocs := testing.AllocsPerRun(100, func() {
+				recognize.Recognize(test.recognizer, input)
+			}); allocs != 0 {
+				t.Errorf("expected no allocations, got %v", allocs)
+			}
+		})
+	}
+}
+
+func BenchmarkRecognizeHEXDIG(b *testing.B) {
+	input := []byte("f")
+	for i := 0; i < b.N; i++ {
+		recognize.Recognize(RecognizeHEXDIG, input)
+	}
+}
@@ -0,0 +1,868 @@
+// This file is generated - do not edit.
+
+package strict
+
+import (
+	"github.com/elimity-com/abnf/operators"
+	"github.com/elimity-com/abnf/operators/recognize"
+)
+
+// ALPHA = %x41-5A / %x61-7A
+func ALPHA() operators.Operator {
+	return parseALPHA
+}
+
+func parseALPHA(s []byte) operators.Alternatives {
+	var nodes operators.Alternatives
+	for _, node := range parseALPHA_1(s) {
+		children := node.Children
+		if node.IsRule() {
+			children = operators.Children{node}
+		}
+		nodes = append(nodes, &operators.Node{Key: "ALPHA", Kind: operators.KindRule, Element: operators.Element{Rule: "ALPHA"}, Value: node.Value, Children: children})
+	}
+	return nodes
+}
+
+// %x41-5A / %x61-7A
+func parseALPHA_1(s []byte) operators.Alternatives {
+	var nodes operators.Alternatives
+	if len(s) == 0 || (0x41 <= s[0] && s[0] <= 0x5a) {
+		for _, node := range parseALPHA_2(s) {
+			nodes = append(nodes, &operators.Node{Key: "%x41-5A / %x61-7A", Kind: operators.KindAlternation, Element: operators.Element{Rule: "ALPHA"}, Value: node.Value, Children: operators.Children{node}})
+		}
+	}
+	if len(s) == 0 || (0x61 <= s[0] && s[0] <= 0x7a) {
+		for _, node := range parseALPHA_3(s) {
+			nodes = append(nodes, &operators.Node{Key: "%x41-5A / %x61-7A", Kind: operators.KindAlternation, Element: operators.Element{Rule: "ALPHA"}, Value: node.Value, Children: operators.Children{node}})
+		}
+	}
+	return nodes
+}
+
+// %x41-5A
+func parseALPHA_2(s []byte) operators.Alternatives {
+	if len(s) == 0 || s[0] < 0x41 || 0x5a < s[0] {
+		return nil
+	}
+	return operators.Alternatives{{Key: "%x41-5A", Kind: operators.KindTerminal, Element: operators.Element{Rule: "ALPHA", Index: 1}, Value: s[:1]}}
+}
+
+// %x61-7A
+func parseALPHA_3(s []byte) operators.Alternatives {
+	if len(s) == 0 || s[0] < 0x61 || 0x7a < s[0] {
+		return nil
+	}
+	return operators.Alternatives{{Key: "%x61-7A", Kind: operators.KindTerminal, Element: operators.Element{Rule: "ALPHA", Index: 2}, Value: s[:1]}}
+}
+
+// BIT = "0" / "1"
+func BIT() operators.Operator {
+	return parseBIT
+}
+
+func parseBIT(s []byte) operators.Alternatives {
+	var nodes operators.Alternatives
+	for _, node := range parseBIT_1(s) {
+		children := node.Children
+		if node.IsRule() {
+			children = operators.Children{node}
+		}
+		nodes = append(nodes, &operators.Node{Key: "BIT", Kind: operators.KindRule, Element: operators.Element{Rule: "BIT"}, Value: node.Value, Children: children})
+	}
+	return nodes
+}
+
+// "0" / "1"
+func parseBIT_1(s []byte) operators.Alternatives {
+	var nodes operators.Alternatives
+	if len(s) == 0 || s[0] == 0x30 {
+		for _, node := range parseBIT_2(s) {
+			nodes = append(nodes, &operators.Node{Key: "\"0\" / \"1\"", Kind: operators.KindAlternation, Element: operators.Element{Rule: "BIT"}, Value: node.Value, Children: operators.Children{node}})
+		}
+	}
+	if len(s) == 0 || s[0] == 0x31 {
+		for _, node := range parseBIT_3(s) {
+			nodes = append(nodes, &operators.Node{Key: "\"0\" / \"1\"", Kind: operators.KindAlternation, Element: operators.Element{Rule: "BIT"}, Value: node.Value, Children: operators.Children{node}})
+		}
+	}
+	return nodes
+}
+
+// "0"
+func parseBIT_2(s []byte) operators.Alternatives {
+	if len(s) == 0 || s[0] != 0x30 {
+		return nil
+	}
+	return operators.Alternatives{{Key: "0", Kind: operators.KindTerminal, Element: operators.Element{Rule: "BIT", Index: 1}, Value: s[:1]}}
+}
+
+// "1"
+func parseBIT_3(s []byte) operators.Alternatives {
+	if len(s) == 0 || s[0] != 0x31 {
+		return nil
+	}
+	return operators.Alternatives{{Key: "1", Kind: operators.KindTerminal, Element: operators.Element{Rule: "BIT", Index: 2}, Value: s[:1]}}
+}
+
+// CHAR = %x01-7F
+func CHAR() operators.Operator {
+	return parseCHAR
+}
+
+func parseCHAR(s []byte) operators.Alternatives {
+	var nodes operators.Alternatives
+	for _, node := range parseCHAR_1(s) {
+		children := node.Children
+		if node.IsRule() {
+			children = operators.Children{node}
+		}
+		nodes = append(nodes, &operators.Node{Key: "CHAR", Kind: operators.KindRule, Element: operators.Element{Rule: "CHAR"}, Value: node.Value, Children: children})
+	}
+	return nodes
+}
+
+// %x01-7F
+func parseCHAR_1(s []byte) operators.Alternatives {
+	if len(s) == 0 || s[0] < 0x01 || 0x7f < s[0] {
+		return nil
+	}
+	return operators.Alternatives{{Key: "%x01-7F", Kind: operators.KindTerminal, Element: operators.Element{Rule: "CHAR"}, Value: s[:1]}}
+}
+
+// CR = %x0D
+func CR() operators.Operator {
+	return parseCR
+}
+
+func parseCR(s []byte) operators.Alternatives {
+	var nodes operators.Alternatives
+	for _, node := range parseCR_1(s) {
+		children := node.Children
+		if node.IsRule() {
+			children = operators.Children{node}
+		}
+		nodes = append(nodes, &operators.Node{Key: "CR", Kind: operators.KindRule, Element: operators.Element{Rule: "CR"}, Value: node.Value, Children: children})
+	}
+	return nodes
+}
+
+// %x0D
+func parseCR_1(s []byte) operators.Alternatives {
+	if len(s) == 0 || s[0] != 0x0d {
+		return nil
+	}
+	return operators.Alternatives{{Key: "%x0D", Kind: operators.KindTerminal, Element: operators.Element{Rule: "CR"}, Value: s[:1]}}
+}
+
+// CRLF = CR LF
+func CRLF() operators.Operator {
+	return parseCRLF
+}
+
+func parseCRLF(s []byte) operators.Alternatives {
+	var nodes operators.Alternatives
+	for _, node := range parseCRLF_1(s) {
+		children := node.Children
+		if node.IsRule() {
+			children = operators.Children{node}
+		}
+		nodes = append(nodes, &operators.Node{Key: "CRLF", Kind: operators.KindRule, Element: operators.Element{Rule: "CRLF"}, Value: node.Value, Children: children})
+	}
+	return nodes
+}
+
+// CR LF
+func parseCRLF_1(s []byte) operators.Alternatives {
+	var nodes operators.Alternatives
+	for _, n_0 := range parseCR(s) {
+		l_0 := len(n_0.Value)
+		for _, n_1 := range parseLF(s[l_0:]) {
+			l_1 := l_0 + len(n_1.Value)
+			nodes = append(nodes, &operators.Node{Key: "CR LF", Kind: operators.KindConcatenation, Element: operators.Element{Rule: "CRLF"}, Value: s[:l_1], Children: operators.Children{n_0, n_1}})
+		}
+	}
+	return nodes
+}
+
+// CTL = %x00-1F / %x7F
+func CTL() operators.Operator {
+	return parseCTL
+}
+
+func parseCTL(s []byte) operators.Alternatives {
+	var nodes operators.Alternatives
+	for _, node := range parseCTL_1(s) {
+		children := node.Children
+		if node.IsRule() {
+			children = operators.Children{node}
+		}
+		nodes = append(nodes, &operators.Node{Key: "CTL", Kind: operators.KindRule, Element: operators.Element{Rule: "CTL"}, Value: node.Value, Children: children})
+	}
+	return nodes
+}
+
+// %x00-1F / %x7F
+func parseCTL_1(s []byte) operators.Alternatives {
+	var nodes operators.Alternatives
+	if len(s) == 0 || s[0] <= 0x1f {
+		for _, node := range parseCTL_2(s) {
+			nodes = append(nodes, &operators.Node{Key: "%x00-1F / %x7F", Kind: operators.KindAlternation, Element: operators.Element{Rule: "CTL"}, Value: node.Value, Children: operators.Children{node}})
+		}
+	}
+	if len(s) == 0 || s[0] == 0x7f {
+		for _, node := range parseCTL_3(s) {
+			nodes = append(nodes, &operators.Node{Key: "%x00-1F / %x7F", Kind: operators.KindAlternation, Element: operators.Element{Rule: "CTL"}, Value: node.Value, Children: operators.Children{node}})
+		}
+	}
+	return nodes
+}
+
+// %x00-1F
+func parseCTL_2(s []byte) operators.Alternatives {
+	if len(s) == 0 || 0x1f < s[0] {
+		return nil
+	}
+	return operators.Alternatives{{Key: "%x00-1F", Kind: operators.KindTerminal, Element: operators.Element{Rule: "CTL", Index: 1}, Value: s[:1]}}
+}
+
+// %x7F
+func parseCTL_3(s []byte) operators.Alternatives {
+	if len(s) == 0 || s[0] != 0x7f {
+		return nil
+	}
+	return operators.Alternatives{{Key: "%x7F", Kind: operators.KindTerminal, Element: operators.Element{Rule: "CTL", Index: 2}, Value: s[:1]}}
+}
+
+// DIGIT = %x30-39
+func DIGIT() operators.Operator {
+	return parseDIGIT
+}
+
+func parseDIGIT(s []byte) operators.Alternatives {
+	var nodes operators.Alternatives
+	for _, node := range parseDIGIT_1(s) {
+		children := node.Children
+		if node.IsRule() {
+			children = operators.Children{node}
+		}
+		nodes = append(nodes, &operators.Node{Key: "DIGIT", Kind: operators.KindRule, Element: operators.Element{Rule: "DIGIT"}, Value: node.Value, Children: children})
+	}
+	return nodes
+}
+
+// %x30-39
+func parseDIGIT_1(s []byte) operators.Alternatives {
+	if len(s) == 0 || s[0] < 0x30 || 0x39 < s[0] {
+		return nil
+	}
+	return operators.Alternatives{{Key: "%x30-39", Kind: operators.KindTerminal, Element: operators.Element{Rule: "DIGIT"}, Value: s[:1]}}
+}
+
+// DQUOTE = %x22
+func DQUOTE() operators.Operator {
+	return parseDQUOTE
+}
+
+func parseDQUOTE(s []byte) operators.Alternatives {
+	var nodes operators.Alternatives
+	for _, node := range parseDQUOTE_1(s) {
+		children := node.Children
+		if node.IsRule() {
+			children = operators.Children{node}
+		}
+		nodes = append(nodes, &operators.Node{Key: "DQUOTE", Kind: operators.KindRule, Element: operators.Element{Rule: "DQUOTE"}, Value: node.Value, Children: children})
+	}
+	return nodes
+}
+
+// %x22
+func parseDQUOTE_1(s []byte) operators.Alternatives {
+	if len(s) == 0 || s[0] != 0x22 {
+		return nil
+	}
+	return operators.Alternatives{{Key: "%x22", Kind: operators.KindTerminal, Element: operators.Element{Rule: "DQUOTE"}, Value: s[:1]}}
+}
+
+// HEXDIG = DIGIT / "A" / "B" / "C" / "D" / "E" / "F"
+func HEXDIG() operators.Operator {
+	return parseHEXDIG
+}
+
+func parseHEXDIG(s []byte) operators.Alternatives {
+	var nodes operators.Alternatives
+	for _, node := range parseHEXDIG_1(s) {
+		children := node.Children
+		if node.IsRule() {
+			children = operators.Children{node}
+		}
+		nodes = append(nodes, &operators.Node{Key: "HEXDIG", Kind: operators.KindRule, Element: operators.Element{Rule: "HEXDIG"}, Value: node.Value, Children: children})
+	}
+	return nodes
+}
+
+// DIGIT / "A" / "B" / "C" / "D" / "E" / "F"
+func parseHEXDIG_1(s []byte) operators.Alternatives {
+	var nodes operators.Alternatives
+	if len(s) == 0 || (0x30 <= s[0] && s[0] <= 0x39) {
+		for _, node := range parseDIGIT(s) {
+			nodes = append(nodes, &operators.Node{Key: "DIGIT / \"A\" / \"B\" / \"C\" / \"D\" / \"E\" / \"F\"", Kind: operators.KindAlternation, Element: operators.Element{Rule: "HEXDIG"}, Value: node.Value, Children: operators.Children{node}})
+		}
+	}
+	if len(s) == 0 || s[0] == 0x41 {
+		for _, node := range parseHEXDIG_2(s) {
+			nodes = append(nodes, &operators.Node{Key: "DIGIT / \"A\" / \"B\" / \"C\" / \"D\" / \"E\" / \"F\"", Kind: operators.KindAlternation, Element: operators.Element{Rule: "HEXDIG"}, Value: node.Value, Children: operators.Children{node}})
+		}
+	}
+	if len(s) == 0 || s[0] == 0x42 {
+		for _, node := range parseHEXDIG_3(s) {
+			nodes = append(nodes, &operators.Node{Key: "DIGIT / \"A\" / \"B\" / \"C\" / \"D\" / \"E\" / \"F\"", Kind: operators.KindAlternation, Element: operators.Element{Rule: "HEXDIG"}, Value: node.Value, Children: operators.Children{node}})
+		}
+	}
+	if len(s) == 0 || s[0] == 0x43 {
+		for _, node := range parseHEXDIG_4(s) {
+			nodes = append(nodes, &operators.Node{Key: "DIGIT / \"A\" / \"B\" / \"C\" / \"D\" / \"E\" / \"F\"", Kind: operators.KindAlternation, Element: operators.Element{Rule: "HEXDIG"}, Value: node.Value, Children: operators.Children{node}})
+		}
+	}
+	if len(s) == 0 || s[0] == 0x44 {
+		for _, node := range parseHEXDIG_5(s) {
+			nodes = append(nodes, &operators.Node{Key: "DIGIT / \"A\" / \"B\" / \"C\" / \"D\" / \"E\" / \"F\"", Kind: operators.KindAlternation, Element: operators.Element{Rule: "HEXDIG"}, Value: node.Value, Children: operators.Children{node}})
+		}
+	}
+	if len(s) == 0 || s[0] == 0x45 {
+		for _, node := range parseHEXDIG_6(s) {
+			nodes = append(nodes, &operators.Node{Key: "DIGIT / \"A\" / \"B\" / \"C\" / \"D\" / \"E\" / \"F\"", Kind: operators.KindAlternation, Element: operators.Element{Rule: "HEXDIG"}, Value: node.Value, Children: operators.Children{node}})
+		}
+	}
+	if len(s) == 0 || s[0] == 0x46 {
+		for _, node := range parseHEXDIG_7(s) {
+			nodes = append(nodes, &operators.Node{Key: "DIGIT / \"A\" / \"B\" / \"C\" / \"D\" / \"E\" / \"F\"", Kind: operators.KindAlternation, Element: operators.Element{Rule: "HEXDIG"}, Value: node.Value, Children: operators.Children{node}})
+		}
+	}
+	return nodes
+}
+
+// "A"
+func parseHEXDIG_2(s []byte) operators.Alternatives {
+	if len(s) == 0 || s[0] != 0x41 {
+		return nil
+	}
+	return operators.Alternatives{{Key: "A", Kind: operators.KindTerminal, Element: operators.Element{Rule: "HEXDIG", Index: 2}, Value: s[:1]}}
+}
+
+// "B"
+func parseHEXDIG_3(s []byte) operators.Alternatives {
+	if len(s) == 0 || s[0] != 0x42 {
+		return nil
+	}
+	return operators.Alternatives{{Key: "B", Kind: operators.KindTerminal, Element: operators.Element{Rule: "HEXDIG", Index: 3}, Value: s[:1]}}
+}
+
+// "C"
+func parseHEXDIG_4(s []byte) operators.Alternatives {
+	if len(s) == 0 || s[0] != 0x43 {
+		return nil
+	}
+	return operators.Alternatives{{Key: "C", Kind: operators.KindTerminal, Element: operators.Element{Rule: "HEXDIG", Index: 4}, Value: s[:1]}}
+}
+
+// "D"
+func parseHEXDIG_5(s []byte) operators.Alternatives {
+	if len(s) == 0 || s[0] != 0x44 {
+		return nil
+	}
+	return operators.Alternatives{{Key: "D", Kind: operators.KindTerminal, Element: operators.Element{Rule: "HEXDIG", Index: 5}, Value: s[:1]}}
+}
+
+// "E"
+func parseHEXDIG_6(s []byte) operators.Alternatives {
+	if len(s) == 0 || s[0] != 0x45 {
+		return nil
+	}
+	return operators.Alternatives{{Key: "E", Kind: operators.KindTerminal, Element: operators.Element{Rule: "HEXDIG", Index: 6}, Value: s[:1]}}
+}
+
+// "F"
+func parseHEXDIG_7(s []byte) operators.Alternatives {
+	if len(s) == 0 || s[0] != 0x46 {
+		return nil
+	}
+	return operators.Alternatives{{Key: "F", Kind: operators.KindTerminal, Element: operators.Element{Rule: "HEXDIG", Index: 7}, Value: s[:1]}}
+}
+
+// HTAB = %x09
+func HTAB() operators.Operator {
+	return parseHTAB
+}
+
+func parseHTAB(s []byte) operators.Alternatives {
+	var nodes operators.Alternatives
+	for _, node := range parseHTAB_1(s) {
+		children := node.Children
+		if node.IsRule() {
+			children = operators.Children{node}
+		}
+		nodes = append(nodes, &operators.Node{Key: "HTAB", Kind: operators.KindRule, Element: operators.Element{Rule: "HTAB"}, Value: node.Value, Children: children})
+	}
+	return nodes
+}
+
+// %x09
+func parseHTAB_1(s []byte) operators.Alternatives {
+	if len(s) == 0 || s[0] != 0x09 {
+		return nil
+	}
+	return operators.Alternatives{{Key: "%x09", Kind: operators.KindTerminal, Element: operators.Element{Rule: "HTAB"}, Value: s[:1]}}
+}
+
+// LF = %x0A
+func LF() operators.Operator {
+	return parseLF
+}
+
+func parseLF(s []byte) operators.Alternatives {
+	var nodes operators.Alternatives
+	for _, node := range parseLF_1(s) {
+		children := node.Children
+		if node.IsRule() {
+			children = operators.Children{node}
+		}
+		nodes = append(nodes, &operators.Node{Key: "LF", Kind: operators.KindRule, Element: operators.Element{Rule: "LF"}, Value: node.Value, Children: children})
+	}
+	return nodes
+}
+
+// %x0A
+func parseLF_1(s []byte) operators.Alternatives {
+	if len(s) == 0 || s[0] != 0x0a {
+		return nil
+	}
+	return operators.Alternatives{{Key: "%x0A", Kind: operators.KindTerminal, Element: operators.Element{Rule: "LF"}, Value: s[:1]}}
+}
+
+// LWSP = *(WSP / CRLF WSP)
+func LWSP() operators.Operator {
+	return parseLWSP
+}
+
+func parseLWSP(s []byte) operators.Alternatives {
+	var nodes operators.Alternatives
+	for _, node := range parseLWSP_1(s) {
+		children := node.Children
+		if node.IsRule() {
+			children = operators.Children{node}
+		}
+		nodes = append(nodes, &operators.Node{Key: "LWSP", Kind: operators.KindRule, Element: operators.Element{Rule: "LWSP"}, Value: node.Value, Children: children})
+	}
+	return nodes
+}
+
+// *(WSP / CRLF WSP)
+func parseLWSP_1(s []byte) operators.Alternatives {
+	return repeatLWSP_1(s, 0, 0)
+}
+
+func repeatLWSP_1(s []byte, i, l int) operators.Alternatives {
+	var nodes operators.Alternatives
+	for _, node := range parseLWSP_2(s[l:]) {
+		for _, n := range repeatLWSP_1(s, i+1, l+len(node.Value)) {
+			n.Children = append(operators.Children{node}, n.Children...)
+			nodes = append(nodes, n)
+		}
+	}
+	return append(nodes, &operators.Node{Key: "*(WSP / CRLF WSP)", Kind: operators.KindRepetition, Element: operators.Element{Rule: "LWSP"}, Value: s[:l]})
+}
+
+// WSP / CRLF WSP
+func parseLWSP_2(s []byte) operators.Alternatives {
+	var nodes operators.Alternatives
+	if len(s) == 0 || s[0] == 0x09 || s[0] == 0x20 {
+		for _, node := range parseWSP(s) {
+			nodes = append(nodes, &operators.Node{Key: "WSP / CRLF WSP", Kind: operators.KindAlternation, Element: operators.Element{Rule: "LWSP", Index: 1}, Value: node.Value, Children: operators.Children{node}})
+		}
+	}
+	if len(s) == 0 || s[0] == 0x0d {
+		for _, node := range parseLWSP_3(s) {
+			nodes = append(nodes, &operators.Node{Key: "WSP / CRLF WSP", Kind: operators.KindAlternation, Element: operators.Element{Rule: "LWSP", Index: 1}, Value: node.Value, Children: operators.Children{node}})
+		}
+	}
+	return nodes
+}
+
+// CRLF WSP
+func parseLWSP_3(s []byte) operators.Alternatives {
+	var nodes operators.Alternatives
+	for _, n_0 := range parseCRLF(s) {
+		l_0 := len(n_0.Value)
+		for _, n_1 := range parseWSP(s[l_0:]) {
+			l_1 := l_0 + len(n_1.Value)
+			nodes = append(nodes, &operators.Node{Key: "CRLF WSP", Kind: operators.KindConcatenation, Element: operators.Element{Rule: "LWSP", Index: 3}, Value: s[:l_1], Children: operators.Children{n_0, n_1}})
+		}
+	}
+	return nodes
+}
+
+// OCTET = %x00-FF
+func OCTET() operators.Operator {
+	return parseOCTET
+}
+
+func parseOCTET(s []byte) operators.Alternatives {
+	var nodes operators.Alternatives
+	for _, node := range parseOCTET_1(s) {
+		children := node.Children
+		if node.IsRule() {
+			children = operators.Children{node}
+		}
+		nodes = append(nodes, &operators.Node{Key: "OCTET", Kind: operators.KindRule, Element: operators.Element{Rule: "OCTET"}, Value: node.Value, Children: children})
+	}
+	return nodes
+}
+
+// %x00-FF
+func parseOCTET_1(s []byte) operators.Alternatives {
+	if len(s) == 0 {
+		return nil
+	}
+	return operators.Alternatives{{Key: "%x00-FF", Kind: operators.KindTerminal, Element: operators.Element{Rule: "OCTET"}, Value: s[:1]}}
+}
+
+// SP = %x20
+func SP() operators.Operator {
+	return parseSP
+}
+
+func parseSP(s []byte) operators.Alternatives {
+	var nodes operators.Alternatives
+	for _, node := range parseSP_1(s) {
+		children := node.Children
+		if node.IsRule() {
+			children = operators.Children{node}
+		}
+		nodes = append(nodes, &operators.Node{Key: "SP", Kind: operators.KindRule, Element: operators.Element{Rule: "SP"}, Value: node.Value, Children: children})
+	}
+	return nodes
+}
+
+// %x20
+func parseSP_1(s []byte) operators.Alternatives {
+	if len(s) == 0 || s[0] != 0x20 {
+		return nil
+	}
+	return operators.Alternatives{{Key: "%x20", Kind: operators.KindTerminal, Element: operators.Element{Rule: "SP"}, Value: s[:1]}}
+}
+
+// VCHAR = %x21-7E
+func VCHAR() operators.Operator {
+	return parseVCHAR
+}
+
+func parseVCHAR(s []byte) operators.Alternatives {
+	var nodes operators.Alternatives
+	for _, node := range parseVCHAR_1(s) {
+		children := node.Children
+		if node.IsRule() {
+			children = operators.Children{node}
+		}
+		nodes = append(nodes, &operators.Node{Key: "VCHAR", Kind: operators.KindRule, Element: operators.Element{Rule: "VCHAR"}, Value: node.Value, Children: children})
+	}
+	return nodes
+}
+
+// %x21-7E
+func parseVCHAR_1(s []byte) operators.Alternatives {
+	if len(s) == 0 || s[0] < 0x21 || 0x7e < s[0] {
+		return nil
+	}
+	return operators.Alternatives{{Key: "%x21-7E", Kind: operators.KindTerminal, Element: operators.Element{Rule: "VCHAR"}, Value: s[:1]}}
+}
+
+// WSP = SP / HTAB
+func WSP() operators.Operator {
+	return parseWSP
+}
+
+func parseWSP(s []byte) operators.Alternatives {
+	var nodes operators.Alternatives
+	for _, node := range parseWSP_1(s) {
+		children := node.Children
+		if node.IsRule() {
+			children = operators.Children{node}
+		}
+		nodes = append(nodes, &operators.Node{Key: "WSP", Kind: operators.KindRule, Element: operators.Element{Rule: "WSP"}, Value: node.Value, Children: children})
+	}
+	return nodes
+}
+
+// SP / HTAB
+func parseWSP_1(s []byte) operators.Alternatives {
+	var nodes operators.Alternatives
+	if len(s) == 0 || s[0] == 0x20 {
+		for _, node := range parseSP(s) {
+			nodes = append(nodes, &operators.Node{Key: "SP / HTAB", Kind: operators.KindAlternation, Element: operators.Element{Rule: "WSP"}, Value: node.Value, Children: operators.Children{node}})
+		}
+	}
+	if len(s) == 0 || s[0] == 0x09 {
+		for _, node := range parseHTAB(s) {
+			nodes = append(nodes, &operators.Node{Key: "SP / HTAB", Kind: operators.KindAlternation, Element: operators.Element{Rule: "WSP"}, Value: node.Value, Children: operators.Children{node}})
+		}
+	}
+	return nodes
+}
+
+// ALPHA = %x41-5A / %x61-7A
+func RecognizeALPHA(s []byte, lengths []int) []int {
+	return recognizeALPHA(s, lengths)
+}
+
+var recognizeALPHA recognize.Operator
+
+// BIT = "0" / "1"
+func RecognizeBIT(s []byte, lengths []int) []int {
+	return recognizeBIT(s, lengths)
+}
+
+var recognizeBIT recognize.Operator
+
+// CHAR = %x01-7F
+func RecognizeCHAR(s []byte, lengths []int) []int {
+	return recognizeCHAR(s, lengths)
+}
+
+var recognizeCHAR recognize.Operator
+
+// CR = %x0D
+func RecognizeCR(s []byte, lengths []int) []int {
+	return recognizeCR(s, lengths)
+}
+
+var recognizeCR recognize.Operator
+
+// CRLF = CR LF
+func RecognizeCRLF(s []byte, lengths []int) []int {
+	return recognizeCRLF(s, lengths)
+}
+
+var recognizeCRLF recognize.Operator
+
+// CTL = %x00-1F / %x7F
+func RecognizeCTL(s []byte, lengths []int) []int {
+	return recognizeCTL(s, lengths)
+}
+
+var recognizeCTL recognize.Operator
+
+// DIGIT = %x30-39
+func RecognizeDIGIT(s []byte, lengths []int) []int {
+	return recognizeDIGIT(s, lengths)
+}
+
+var recognizeDIGIT recognize.Operator
+
+// DQUOTE = %x22
+func RecognizeDQUOTE(s []byte, lengths []int) []int {
+	return recognizeDQUOTE(s, lengths)
+}
+
+var recognizeDQUOTE recognize.Operator
+
+// HEXDIG = DIGIT / "A" / "B" / "C" / "D" / "E" / "F"
+func RecognizeHEXDIG(s []byte, lengths []int) []int {
+	return recognizeHEXDIG(s, lengths)
+}
+
+var recognizeHEXDIG recognize.Operator
+
+// HTAB = %x09
+func RecognizeHTAB(s []byte, lengths []int) []int {
+	return recognizeHTAB(s, lengths)
+}
+
+var recognizeHTAB recognize.Operator
+
+// LF = %x0A
+func RecognizeLF(s []byte, lengths []int) []int {
+	return recognizeLF(s, lengths)
+}
+
+var recognizeLF recognize.Operator
+
+// LWSP = *(WSP / CRLF WSP)
+func RecognizeLWSP(s []byte, lengths []int) []int {
+	return recognizeLWSP(s, lengths)
+}
+
+var recognizeLWSP recognize.Operator
+
+// OCTET = %x00-FF
+func RecognizeOCTET(s []byte, lengths []int) []int {
+	return recognizeOCTET(s, lengths)
+}
+
+var recognizeOCTET recognize.Operator
+
+// SP = %x20
+func RecognizeSP(s []byte, lengths []int) []int {
+	return recognizeSP(s, lengths)
+}
+
+var recognizeSP recognize.Operator
+
+// VCHAR = %x21-7E
+func RecognizeVCHAR(s []byte, lengths []int) []int {
+	return recognizeVCHAR(s, lengths)
+}
+
+var recognizeVCHAR recognize.Operator
+
+// WSP = SP / HTAB
+func RecognizeWSP(s []byte, lengths []int) []int {
+	return recognizeWSP(s, lengths)
+}
+
+var recognizeWSP recognize.Operator
+
+// RuleName is the name of a rule, convert it to a string to look up nodes (e.g. GetSubRule(string(RuleFoo))).
+type RuleName string
+
+const (
+	RuleALPHA  RuleName = "ALPHA"
+	RuleBIT    RuleName = "BIT"
+	RuleCHAR   RuleName = "CHAR"
+	RuleCR     RuleName = "CR"
+	RuleCRLF   RuleName = "CRLF"
+	RuleCTL    RuleName = "CTL"
+	RuleDIGIT  RuleName = "DIGIT"
+	RuleDQUOTE RuleName = "DQUOTE"
+	RuleHEXDIG RuleName = "HEXDIG"
+	RuleHTAB   RuleName = "HTAB"
+	RuleLF     RuleName = "LF"
+	RuleLWSP   RuleName = "LWSP"
+	RuleOCTET  RuleName = "OCTET"
+	RuleSP     RuleName = "SP"
+	RuleVCHAR  RuleName = "VCHAR"
+	RuleWSP    RuleName = "WSP"
+)
+
+// RuleMetadata describes a rule.
+type RuleMetadata struct {
+	// ABNF is the definition of the rule, as written in the source.
+	ABNF string
+	// References are the (sorted) names of the rules it refers to, including the external ones.
+	References []RuleName
+}
+
+// Rules contains the operators of all the rules, by name.
+var Rules = make(map[RuleName]operators.Operator)
+
+// Metadata contains the metadata of all the rules, by name.
+var Metadata = make(map[RuleName]RuleMetadata)
+
+func init() {
+	recognizeALPHA = recognize.AltsFirst(
+		[]operators.ByteSet{
+			{0x0, 0x7fffffe, 0x0, 0x0},
+			{0x0, 0x7fffffe00000000, 0x0, 0x0},
+		},
+		recognize.Range([]byte{65}, []byte{90}),
+		recognize.Range([]byte{97}, []byte{122}),
+	)
+	recognizeBIT = recognize.AltsFirst(
+		[]operators.ByteSet{
+			{0x1000000000000, 0x0, 0x0, 0x0},
+			{0x2000000000000, 0x0, 0x0, 0x0},
+		},
+		recognize.String("0"),
+		recognize.String("1"),
+	)
+	recognizeCHAR = recognize.Range([]byte{1}, []byte{127})
+	recognizeCR = recognize.Terminal([]byte{13})
+	recognizeCRLF = recognize.Concat(
+		RecognizeCR,
+		RecognizeLF,
+	)
+	recognizeCTL = recognize.AltsFirst(
+		[]operators.ByteSet{
+			{0xffffffff, 0x0, 0x0, 0x0},
+			{0x0, 0x8000000000000000, 0x0, 0x0},
+		},
+		recognize.Range([]byte{0}, []byte{31}),
+		recognize.Terminal([]byte{127}),
+	)
+	recognizeDIGIT = recognize.Range([]byte{48}, []byte{57})
+	recognizeDQUOTE = recognize.Terminal([]byte{34})
+	recognizeHEXDIG = recognize.AltsFirst(
+		[]operators.ByteSet{
+			{0x3ff000000000000, 0x0, 0x0, 0x0},
+			{0x0, 0x2, 0x0, 0x0},
+			{0x0, 0x4, 0x0, 0x0},
+			{0x0, 0x8, 0x0, 0x0},
+			{0x0, 0x10, 0x0, 0x0},
+			{0x0, 0x20, 0x0, 0x0},
+			{0x0, 0x40, 0x0, 0x0},
+		},
+		RecognizeDIGIT,
+		recognize.String("A"),
+		recognize.String("B"),
+		recognize.String("C"),
+		recognize.String("D"),
+		recognize.String("E"),
+		recognize.String("F"),
+	)
+	recognizeHTAB = recognize.Terminal([]byte{9})
+	recognizeLF = recognize.Terminal([]byte{10})
+	recognizeLWSP = recognize.Repeat0Inf(recognize.AltsFirst(
+		[]operators.ByteSet{
+			{0x100000200, 0x0, 0x0, 0x0},
+			{0x2000, 0x0, 0x0, 0x0},
+		},
+		RecognizeWSP,
+		recognize.Concat(
+			RecognizeCRLF,
+			RecognizeWSP,
+		),
+	))
+	recognizeOCTET = recognize.Range([]byte{0}, []byte{255})
+	recognizeSP = recognize.Terminal([]byte{32})
+	recognizeVCHAR = recognize.Range([]byte{33}, []byte{126})
+	recognizeWSP = recognize.AltsFirst(
+		[]operators.ByteSet{
+			{0x100000000, 0x0, 0x0, 0x0},
+			{0x200, 0x0, 0x0, 0x0},
+		},
+		RecognizeSP,
+		RecognizeHTAB,
+	)
+	Rules[RuleALPHA] = ALPHA()
+	Rules[RuleBIT] = BIT()
+	Rules[RuleCHAR] = CHAR()
+	Rules[RuleCR] = CR()
+	Rules[RuleCRLF] = CRLF()
+	Rules[RuleCTL] = CTL()
+	Rules[RuleDIGIT] = DIGIT()
+	Rules[RuleDQUOTE] = DQUOTE()
+	Rules[RuleHEXDIG] = HEXDIG()
+	Rules[RuleHTAB] = HTAB()
+	Rules[RuleLF] = LF()
+	Rules[RuleLWSP] = LWSP()
+	Rules[RuleOCTET] = OCTET()
+	Rules[RuleSP] = SP()
+	Rules[RuleVCHAR] = VCHAR()
+	Rules[RuleWSP] = WSP()
+	Metadata[RuleALPHA] = RuleMetadata{ABNF: "ALPHA  = %x41-5A / %x61-7A ; A-Z / a-z"}
+	Metadata[RuleBIT] = RuleMetadata{ABNF: "BIT    = \"0\" / \"1\""}
+	Metadata[RuleCHAR] = RuleMetadata{ABNF: "CHAR   = %x01-7F"}
+	Metadata[RuleCR] = RuleMetadata{ABNF: "CR     =  %x0D ; carriage return"}
+	Metadata[RuleCRLF] = RuleMetadata{ABNF: "CRLF   = CR LF", References: []RuleName{RuleCR, RuleLF}}
+	Metadata[RuleCTL] = RuleMetadata{ABNF: "CTL    = %x00-1F / %x7F ; controls"}
+	Metadata[RuleDIGIT] = RuleMetadata{ABNF: "DIGIT  = %x30-39 ; 0-9"}
+	Metadata[RuleDQUOTE] = RuleMetadata{ABNF: "DQUOTE = %x22"}
+	Metadata[RuleHEXDIG] = RuleMetadata{ABNF: "HEXDIG = DIGIT / \"A\" / \"B\" / \"C\" / \"D\" / \"E\" / \"F\"", References: []RuleName{RuleDIGIT}}
+	Metadata[RuleHTAB] = RuleMetadata{ABNF: "HTAB   = %x09"}
+	Metadata[RuleLF] = RuleMetadata{ABNF: "LF     = %x0A"}
+	Metadata[RuleLWSP] = RuleMetadata{ABNF: "LWSP   = *(WSP / CRLF WSP)", References: []RuleName{RuleCRLF, RuleWSP}}
+	Metadata[RuleOCTET] = RuleMetadata{ABNF: "OCTET  = %x00-FF"}
+	Metadata[RuleSP] = RuleMetadata{ABNF: "SP     = %x20"}
+	Metadata[RuleVCHAR] = RuleMetadata{ABNF: "VCHAR  = %x21-7E"}
+	Metadata[RuleWSP] = RuleMetadata{ABNF: "WSP    = SP / HTAB", References: []RuleName{RuleHTAB, RuleSP}}
+}
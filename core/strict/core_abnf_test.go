@@ -0,0 +1,29 @@
+package strict
+
+import (
+	"testing"
+
+	"github.com/elimity-com/abnf/operators"
+)
+
+func TestStrict(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		rule operators.Operator
+		str  string
+		ok   bool
+	}{
+		{"CRLF", CRLF(), "\r\n", true},
+		{"CRLF", CRLF(), "\n", false},
+		{"HEXDIG", HEXDIG(), "A", true},
+		{"HEXDIG", HEXDIG(), "9", true},
+		{"HEXDIG", HEXDIG(), "a", false},
+		{"LWSP", LWSP(), "\r\n\t", true},
+		{"LWSP", LWSP(), "\n\t", false},
+	} {
+		best := test.rule([]byte(test.str)).Best()
+		if (len(best.Value) == len(test.str)) != test.ok {
+			t.Errorf("%s %q: expected %t", test.name, test.str, test.ok)
+		}
+	}
+}
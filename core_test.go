@@ -0,0 +1,62 @@
+package abnf
+
+import (
+	"io/ioutil"
+	"testing"
+)
+
+func TestCoreRules(t *testing.T) {
+	for _, test := range []struct {
+		path   string
+		strict bool
+	}{
+		{"./testdata/core.abnf", false},
+		{"./testdata/core_strict.abnf", true},
+	} {
+		rawABNF, err := ioutil.ReadFile(test.path)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		expected, actual := NewRuleSet(rawABNF), CoreRules(test.strict)
+		if len(expected) != len(actual) {
+			t.Errorf("%s: not an equal amount of rules: %d, %d", test.path, len(expected), len(actual))
+		}
+		for name, rule := range expected {
+			if err := rule.Equals(actual[name]); err != nil {
+				t.Errorf("%s: %s", test.path, err)
+			}
+		}
+	}
+}
+
+func TestCoreOperators(t *testing.T) {
+	lenient, strict := CoreOperators(false), CoreOperators(true)
+	for _, test := range []struct {
+		rule, str string
+		lenient   bool
+		strict    bool
+	}{
+		{"CRLF", "\r\n", true, true},
+		{"CRLF", "\n", true, false},
+		{"HEXDIG", "F", true, true},
+		{"HEXDIG", "f", true, false},
+		{"LWSP", "\n ", true, false},
+	} {
+		if best := lenient[test.rule]([]byte(test.str)).Best(); (len(best.Value) == len(test.str)) != test.lenient {
+			t.Errorf("lenient %s %q: expected %t", test.rule, test.str, test.lenient)
+		}
+		if best := strict[test.rule]([]byte(test.str)).Best(); (len(best.Value) == len(test.str)) != test.strict {
+			t.Errorf("strict %s %q: expected %t", test.rule, test.str, test.strict)
+		}
+	}
+
+	external := CoreExternalABNF(true)
+	if len(external) != len(strict) {
+		t.Errorf("not an equal amount of rules: %d, %d", len(external), len(strict))
+	}
+	if pkg := external["ALPHA"]; pkg.PackagePath != strictCorePkgPath || pkg.PackageName != "strict" {
+		t.Errorf("invalid external package: %v", pkg)
+	}
+}
@@ -10,293 +10,504 @@ import (
 
 // alternation = concatenation *(*c-wsp "/" *c-wsp concatenation)
 func Alternation(s []byte) operators.Alternatives {
-	return operators.Concat(
-		"alternation",
-		Concatenation,
-		operators.Repeat0Inf("*(*c-wsp \"/\" *c-wsp concatenation)", operators.Concat(
-			"*c-wsp \"/\" *c-wsp concatenation",
-			operators.Repeat0Inf("*c-wsp", CWsp),
-			operators.String("/", "/"),
-			operators.Repeat0Inf("*c-wsp", CWsp),
-			Concatenation,
-		)),
-	)(s)
+	return operatorAlternation(s)
 }
 
+var operatorAlternation operators.Operator
+
 // bin-val = "b" 1*BIT [ 1*("." 1*BIT) / ("-" 1*BIT) ]
 func BinVal(s []byte) operators.Alternatives {
-	return operators.Concat(
-		"bin-val",
-		operators.String("b", "b"),
-		operators.Repeat1Inf("1*BIT", core.BIT()),
-		operators.Optional("[ 1*(\".\" 1*BIT) / (\"-\" 1*BIT) ]", operators.Alts(
-			"1*(\".\" 1*BIT) / (\"-\" 1*BIT)",
-			operators.Repeat1Inf("1*(\".\" 1*BIT)", operators.Concat(
-				"\".\" 1*BIT",
-				operators.String(".", "."),
-				operators.Repeat1Inf("1*BIT", core.BIT()),
-			)),
-			operators.Concat(
-				"\"-\" 1*BIT",
-				operators.String("-", "-"),
-				operators.Repeat1Inf("1*BIT", core.BIT()),
-			),
-		)),
-	)(s)
+	return operatorBinVal(s)
 }
 
+var operatorBinVal operators.Operator
+
 // c-nl = comment / CRLF
 func CNl(s []byte) operators.Alternatives {
-	return operators.Alts(
-		"c-nl",
-		Comment,
-		core.CRLF(),
-	)(s)
+	return operatorCNl(s)
 }
 
+var operatorCNl operators.Operator
+
 // c-wsp = WSP / (c-nl WSP)
 func CWsp(s []byte) operators.Alternatives {
-	return operators.Alts(
-		"c-wsp",
-		core.WSP(),
-		operators.Concat(
-			"c-nl WSP",
-			CNl,
-			core.WSP(),
-		),
-	)(s)
+	return operatorCWsp(s)
 }
 
+var operatorCWsp operators.Operator
+
 // char-val = DQUOTE *(%x20-21 / %x23-7E) DQUOTE
 func CharVal(s []byte) operators.Alternatives {
-	return operators.Concat(
-		"char-val",
-		core.DQUOTE(),
-		operators.Repeat0Inf("*(%x20-21 / %x23-7E)", operators.Alts(
-			"%x20-21 / %x23-7E",
-			operators.Range("%x20-21", []byte{32}, []byte{33}),
-			operators.Range("%x23-7E", []byte{35}, []byte{126}),
-		)),
-		core.DQUOTE(),
-	)(s)
+	return operatorCharVal(s)
 }
 
+var operatorCharVal operators.Operator
+
 // comment = ";" *(WSP / VCHAR) CRLF
 func Comment(s []byte) operators.Alternatives {
-	return operators.Concat(
-		"comment",
-		operators.String(";", ";"),
-		operators.Repeat0Inf("*(WSP / VCHAR)", operators.Alts(
-			"WSP / VCHAR",
-			core.WSP(),
-			core.VCHAR(),
-		)),
-		core.CRLF(),
-	)(s)
+	return operatorComment(s)
 }
 
+var operatorComment operators.Operator
+
 // concatenation = repetition *(1*c-wsp repetition)
 func Concatenation(s []byte) operators.Alternatives {
-	return operators.Concat(
-		"concatenation",
-		Repetition,
-		operators.Repeat0Inf("*(1*c-wsp repetition)", operators.Concat(
-			"1*c-wsp repetition",
-			operators.Repeat1Inf("1*c-wsp", CWsp),
-			Repetition,
-		)),
-	)(s)
+	return operatorConcatenation(s)
 }
 
+var operatorConcatenation operators.Operator
+
 // dec-val = "d" 1*DIGIT [ 1*("." 1*DIGIT) / ("-" 1*DIGIT) ]
 func DecVal(s []byte) operators.Alternatives {
-	return operators.Concat(
-		"dec-val",
-		operators.String("d", "d"),
-		operators.Repeat1Inf("1*DIGIT", core.DIGIT()),
-		operators.Optional("[ 1*(\".\" 1*DIGIT) / (\"-\" 1*DIGIT) ]", operators.Alts(
-			"1*(\".\" 1*DIGIT) / (\"-\" 1*DIGIT)",
-			operators.Repeat1Inf("1*(\".\" 1*DIGIT)", operators.Concat(
-				"\".\" 1*DIGIT",
-				operators.String(".", "."),
-				operators.Repeat1Inf("1*DIGIT", core.DIGIT()),
-			)),
-			operators.Concat(
-				"\"-\" 1*DIGIT",
-				operators.String("-", "-"),
-				operators.Repeat1Inf("1*DIGIT", core.DIGIT()),
-			),
-		)),
-	)(s)
+	return operatorDecVal(s)
 }
 
+var operatorDecVal operators.Operator
+
 // defined-as = *c-wsp ("=" / "=/") *c-wsp
 func DefinedAs(s []byte) operators.Alternatives {
-	return operators.Concat(
-		"defined-as",
-		operators.Repeat0Inf("*c-wsp", CWsp),
-		operators.Alts(
-			"\"=\" / \"=/\"",
-			operators.String("=", "="),
-			operators.String("=/", "=/"),
-		),
-		operators.Repeat0Inf("*c-wsp", CWsp),
-	)(s)
+	return operatorDefinedAs(s)
 }
 
+var operatorDefinedAs operators.Operator
+
 // element = rulename / group / option / char-val / num-val / prose-val
 func Element(s []byte) operators.Alternatives {
-	return operators.Alts(
-		"element",
-		Rulename,
-		Group,
-		Option,
-		CharVal,
-		NumVal,
-		ProseVal,
-	)(s)
+	return operatorElement(s)
 }
 
+var operatorElement operators.Operator
+
 // elements = alternation *WSP
 func Elements(s []byte) operators.Alternatives {
-	return operators.Concat(
-		"elements",
-		Alternation,
-		operators.Repeat0Inf("*WSP", core.WSP()),
-	)(s)
+	return operatorElements(s)
 }
 
+var operatorElements operators.Operator
+
 // group = "(" *c-wsp alternation *c-wsp ")"
 func Group(s []byte) operators.Alternatives {
-	return operators.Concat(
-		"group",
-		operators.String("(", "("),
-		operators.Repeat0Inf("*c-wsp", CWsp),
-		Alternation,
-		operators.Repeat0Inf("*c-wsp", CWsp),
-		operators.String(")", ")"),
-	)(s)
+	return operatorGroup(s)
 }
 
+var operatorGroup operators.Operator
+
 // hex-val = "x" 1*HEXDIG [ 1*("." 1*HEXDIG) / ("-" 1*HEXDIG) ]
 func HexVal(s []byte) operators.Alternatives {
-	return operators.Concat(
-		"hex-val",
-		operators.String("x", "x"),
-		operators.Repeat1Inf("1*HEXDIG", core.HEXDIG()),
-		operators.Optional("[ 1*(\".\" 1*HEXDIG) / (\"-\" 1*HEXDIG) ]", operators.Alts(
-			"1*(\".\" 1*HEXDIG) / (\"-\" 1*HEXDIG)",
-			operators.Repeat1Inf("1*(\".\" 1*HEXDIG)", operators.Concat(
-				"\".\" 1*HEXDIG",
-				operators.String(".", "."),
-				operators.Repeat1Inf("1*HEXDIG", core.HEXDIG()),
-			)),
-			operators.Concat(
-				"\"-\" 1*HEXDIG",
-				operators.String("-", "-"),
-				operators.Repeat1Inf("1*HEXDIG", core.HEXDIG()),
-			),
-		)),
-	)(s)
+	return operatorHexVal(s)
 }
 
+var operatorHexVal operators.Operator
+
 // num-val = "%" (bin-val / dec-val / hex-val)
 func NumVal(s []byte) operators.Alternatives {
-	return operators.Concat(
-		"num-val",
-		operators.String("%", "%"),
-		operators.Alts(
-			"bin-val / dec-val / hex-val",
-			BinVal,
-			DecVal,
-			HexVal,
-		),
-	)(s)
+	return operatorNumVal(s)
 }
 
+var operatorNumVal operators.Operator
+
 // option = "[" *c-wsp alternation *c-wsp "]"
 func Option(s []byte) operators.Alternatives {
-	return operators.Concat(
-		"option",
-		operators.String("[", "["),
-		operators.Repeat0Inf("*c-wsp", CWsp),
-		Alternation,
-		operators.Repeat0Inf("*c-wsp", CWsp),
-		operators.String("]", "]"),
-	)(s)
+	return operatorOption(s)
 }
 
+var operatorOption operators.Operator
+
 // prose-val = "<" *(%x20-3D / %x3F-7E) ">"
 func ProseVal(s []byte) operators.Alternatives {
-	return operators.Concat(
-		"prose-val",
-		operators.String("<", "<"),
-		operators.Repeat0Inf("*(%x20-3D / %x3F-7E)", operators.Alts(
-			"%x20-3D / %x3F-7E",
-			operators.Range("%x20-3D", []byte{32}, []byte{61}),
-			operators.Range("%x3F-7E", []byte{63}, []byte{126}),
-		)),
-		operators.String(">", ">"),
-	)(s)
+	return operatorProseVal(s)
 }
 
+var operatorProseVal operators.Operator
+
 // repeat = 1*DIGIT / (*DIGIT "*" *DIGIT)
 func Repeat(s []byte) operators.Alternatives {
-	return operators.Alts(
-		"repeat",
-		operators.Repeat1Inf("1*DIGIT", core.DIGIT()),
-		operators.Concat(
-			"*DIGIT \"*\" *DIGIT",
-			operators.Repeat0Inf("*DIGIT", core.DIGIT()),
-			operators.String("*", "*"),
-			operators.Repeat0Inf("*DIGIT", core.DIGIT()),
-		),
-	)(s)
+	return operatorRepeat(s)
 }
 
+var operatorRepeat operators.Operator
+
 // repetition = [repeat] element
 func Repetition(s []byte) operators.Alternatives {
-	return operators.Concat(
-		"repetition",
-		operators.Optional("[repeat]", Repeat),
-		Element,
-	)(s)
+	return operatorRepetition(s)
 }
 
+var operatorRepetition operators.Operator
+
 // rule = rulename defined-as elements c-nl
 func Rule(s []byte) operators.Alternatives {
-	return operators.Concat(
-		"rule",
-		Rulename,
-		DefinedAs,
-		Elements,
-		CNl,
-	)(s)
+	return operatorRule(s)
 }
 
+var operatorRule operators.Operator
+
 // rulelist = 1*( rule / (*WSP c-nl) )
 func Rulelist(s []byte) operators.Alternatives {
-	return operators.Repeat1Inf("rulelist", operators.Alts(
-		"rule / (*WSP c-nl)",
-		Rule,
-		operators.Concat(
-			"*WSP c-nl",
-			operators.Repeat0Inf("*WSP", core.WSP()),
-			CNl,
-		),
-	))(s)
+	return operatorRulelist(s)
 }
 
+var operatorRulelist operators.Operator
+
 // rulename = ALPHA *(ALPHA / DIGIT / "-")
 func Rulename(s []byte) operators.Alternatives {
-	return operators.Concat(
-		"rulename",
+	return operatorRulename(s)
+}
+
+var operatorRulename operators.Operator
+
+// RuleName is the name of a rule, convert it to a string to look up nodes (e.g. GetSubRule(string(RuleFoo))).
+type RuleName string
+
+const (
+	RuleAlternation   RuleName = "alternation"
+	RuleBinVal        RuleName = "bin-val"
+	RuleCNl           RuleName = "c-nl"
+	RuleCWsp          RuleName = "c-wsp"
+	RuleCharVal       RuleName = "char-val"
+	RuleComment       RuleName = "comment"
+	RuleConcatenation RuleName = "concatenation"
+	RuleDecVal        RuleName = "dec-val"
+	RuleDefinedAs     RuleName = "defined-as"
+	RuleElement       RuleName = "element"
+	RuleElements      RuleName = "elements"
+	RuleGroup         RuleName = "group"
+	RuleHexVal        RuleName = "hex-val"
+	RuleNumVal        RuleName = "num-val"
+	RuleOption        RuleName = "option"
+	RuleProseVal      RuleName = "prose-val"
+	RuleRepeat        RuleName = "repeat"
+	RuleRepetition    RuleName = "repetition"
+	RuleRule          RuleName = "rule"
+	RuleRulelist      RuleName = "rulelist"
+	RuleRulename      RuleName = "rulename"
+)
+
+// RuleMetadata describes a rule.
+type RuleMetadata struct {
+	// ABNF is the definition of the rule, as written in the source.
+	ABNF string
+	// References are the (sorted) names of the rules it refers to, including the external ones.
+	References []RuleName
+}
+
+// Rules contains the operators of all the rules, by name.
+var Rules = make(map[RuleName]operators.Operator)
+
+// Metadata contains the metadata of all the rules, by name.
+var Metadata = make(map[RuleName]RuleMetadata)
+
+func init() {
+	operatorAlternation = operators.Rule("alternation", operators.Concat(
+		"concatenation *(*c-wsp \"/\" *c-wsp concatenation)",
+		Concatenation,
+		operators.WithElement("alternation", 2, operators.Repeat0Inf("*(*c-wsp \"/\" *c-wsp concatenation)", operators.WithElement("alternation", 3, operators.Concat(
+			"*c-wsp \"/\" *c-wsp concatenation",
+			operators.WithElement("alternation", 4, operators.Repeat0Inf("*c-wsp", CWsp)),
+			operators.WithElement("alternation", 6, operators.String("/", "/")),
+			operators.WithElement("alternation", 7, operators.Repeat0Inf("*c-wsp", CWsp)),
+			Concatenation,
+		)))),
+	))
+	operatorBinVal = operators.Rule("bin-val", operators.Concat(
+		"\"b\" 1*BIT [ 1*(\".\" 1*BIT) / (\"-\" 1*BIT) ]",
+		operators.WithElement("bin-val", 1, operators.String("b", "b")),
+		operators.WithElement("bin-val", 2, operators.Repeat1Inf("1*BIT", core.BIT())),
+		operators.WithElement("bin-val", 4, operators.Optional("[ 1*(\".\" 1*BIT) / (\"-\" 1*BIT) ]", operators.WithElement("bin-val", 5, operators.AltsFirst(
+			"1*(\".\" 1*BIT) / (\"-\" 1*BIT)",
+			[]operators.ByteSet{
+				{0x400000000000, 0x0, 0x0, 0x0},
+				{0x200000000000, 0x0, 0x0, 0x0},
+			},
+			operators.WithElement("bin-val", 6, operators.Repeat1Inf("1*(\".\" 1*BIT)", operators.WithElement("bin-val", 7, operators.Concat(
+				"\".\" 1*BIT",
+				operators.WithElement("bin-val", 8, operators.String(".", ".")),
+				operators.WithElement("bin-val", 9, operators.Repeat1Inf("1*BIT", core.BIT())),
+			)))),
+			operators.WithElement("bin-val", 11, operators.Concat(
+				"\"-\" 1*BIT",
+				operators.WithElement("bin-val", 12, operators.String("-", "-")),
+				operators.WithElement("bin-val", 13, operators.Repeat1Inf("1*BIT", core.BIT())),
+			)),
+		)))),
+	))
+	operatorCNl = operators.Rule("c-nl", operators.AltsFirst(
+		"comment / CRLF",
+		[]operators.ByteSet{
+			{0x800000000000000, 0x0, 0x0, 0x0},
+			{0x2400, 0x0, 0x0, 0x0},
+		},
+		Comment,
+		core.CRLF(),
+	))
+	operatorCWsp = operators.Rule("c-wsp", operators.AltsFirst(
+		"WSP / (c-nl WSP)",
+		[]operators.ByteSet{
+			{0x100000200, 0x0, 0x0, 0x0},
+			{0x800000000002400, 0x0, 0x0, 0x0},
+		},
+		core.WSP(),
+		operators.WithElement("c-wsp", 2, operators.Concat(
+			"c-nl WSP",
+			CNl,
+			core.WSP(),
+		)),
+	))
+	operatorCharVal = operators.Rule("char-val", operators.Concat(
+		"DQUOTE *(%x20-21 / %x23-7E) DQUOTE",
+		core.DQUOTE(),
+		operators.WithElement("char-val", 2, operators.Repeat0Inf("*(%x20-21 / %x23-7E)", operators.WithElement("char-val", 3, operators.AltsFirst(
+			"%x20-21 / %x23-7E",
+			[]operators.ByteSet{
+				{0x300000000, 0x0, 0x0, 0x0},
+				{0xfffffff800000000, 0x7fffffffffffffff, 0x0, 0x0},
+			},
+			operators.WithElement("char-val", 4, operators.Range("%x20-21", []byte{32}, []byte{33})),
+			operators.WithElement("char-val", 5, operators.Range("%x23-7E", []byte{35}, []byte{126})),
+		)))),
+		core.DQUOTE(),
+	))
+	operatorComment = operators.Rule("comment", operators.Concat(
+		"\";\" *(WSP / VCHAR) CRLF",
+		operators.WithElement("comment", 1, operators.String(";", ";")),
+		operators.WithElement("comment", 2, operators.Repeat0Inf("*(WSP / VCHAR)", operators.WithElement("comment", 3, operators.AltsFirst(
+			"WSP / VCHAR",
+			[]operators.ByteSet{
+				{0x100000200, 0x0, 0x0, 0x0},
+				{0xfffffffe00000000, 0x7fffffffffffffff, 0x0, 0x0},
+			},
+			core.WSP(),
+			core.VCHAR(),
+		)))),
+		core.CRLF(),
+	))
+	operatorConcatenation = operators.Rule("concatenation", operators.Concat(
+		"repetition *(1*c-wsp repetition)",
+		Repetition,
+		operators.WithElement("concatenation", 2, operators.Repeat0Inf("*(1*c-wsp repetition)", operators.WithElement("concatenation", 3, operators.Concat(
+			"1*c-wsp repetition",
+			operators.WithElement("concatenation", 4, operators.Repeat1Inf("1*c-wsp", CWsp)),
+			Repetition,
+		)))),
+	))
+	operatorDecVal = operators.Rule("dec-val", operators.Concat(
+		"\"d\" 1*DIGIT [ 1*(\".\" 1*DIGIT) / (\"-\" 1*DIGIT) ]",
+		operators.WithElement("dec-val", 1, operators.String("d", "d")),
+		operators.WithElement("dec-val", 2, operators.Repeat1Inf("1*DIGIT", core.DIGIT())),
+		operators.WithElement("dec-val", 4, operators.Optional("[ 1*(\".\" 1*DIGIT) / (\"-\" 1*DIGIT) ]", operators.WithElement("dec-val", 5, operators.AltsFirst(
+			"1*(\".\" 1*DIGIT) / (\"-\" 1*DIGIT)",
+			[]operators.ByteSet{
+				{0x400000000000, 0x0, 0x0, 0x0},
+				{0x200000000000, 0x0, 0x0, 0x0},
+			},
+			operators.WithElement("dec-val", 6, operators.Repeat1Inf("1*(\".\" 1*DIGIT)", operators.WithElement("dec-val", 7, operators.Concat(
+				"\".\" 1*DIGIT",
+				operators.WithElement("dec-val", 8, operators.String(".", ".")),
+				operators.WithElement("dec-val", 9, operators.Repeat1Inf("1*DIGIT", core.DIGIT())),
+			)))),
+			operators.WithElement("dec-val", 11, operators.Concat(
+				"\"-\" 1*DIGIT",
+				operators.WithElement("dec-val", 12, operators.String("-", "-")),
+				operators.WithElement("dec-val", 13, operators.Repeat1Inf("1*DIGIT", core.DIGIT())),
+			)),
+		)))),
+	))
+	operatorDefinedAs = operators.Rule("defined-as", operators.Concat(
+		"*c-wsp (\"=\" / \"=/\") *c-wsp",
+		operators.WithElement("defined-as", 1, operators.Repeat0Inf("*c-wsp", CWsp)),
+		operators.WithElement("defined-as", 3, operators.AltsFirst(
+			"\"=\" / \"=/\"",
+			[]operators.ByteSet{
+				{0x2000000000000000, 0x0, 0x0, 0x0},
+				{0x2000000000000000, 0x0, 0x0, 0x0},
+			},
+			operators.WithElement("defined-as", 4, operators.String("=", "=")),
+			operators.WithElement("defined-as", 5, operators.String("=/", "=/")),
+		)),
+		operators.WithElement("defined-as", 6, operators.Repeat0Inf("*c-wsp", CWsp)),
+	))
+	operatorElement = operators.Rule("element", operators.AltsFirst(
+		"rulename / group / option / char-val / num-val / prose-val",
+		[]operators.ByteSet{
+			{0x0, 0x7fffffe07fffffe, 0x0, 0x0},
+			{0x10000000000, 0x0, 0x0, 0x0},
+			{0x0, 0x8000000, 0x0, 0x0},
+			{0x400000000, 0x0, 0x0, 0x0},
+			{0x2000000000, 0x0, 0x0, 0x0},
+			{0x1000000000000000, 0x0, 0x0, 0x0},
+		},
+		Rulename,
+		Group,
+		Option,
+		CharVal,
+		NumVal,
+		ProseVal,
+	))
+	operatorElements = operators.Rule("elements", operators.Concat(
+		"alternation *WSP",
+		Alternation,
+		operators.WithElement("elements", 2, operators.Repeat0Inf("*WSP", core.WSP())),
+	))
+	operatorGroup = operators.Rule("group", operators.Concat(
+		"\"(\" *c-wsp alternation *c-wsp \")\"",
+		operators.WithElement("group", 1, operators.String("(", "(")),
+		operators.WithElement("group", 2, operators.Repeat0Inf("*c-wsp", CWsp)),
+		Alternation,
+		operators.WithElement("group", 5, operators.Repeat0Inf("*c-wsp", CWsp)),
+		operators.WithElement("group", 7, operators.String(")", ")")),
+	))
+	operatorHexVal = operators.Rule("hex-val", operators.Concat(
+		"\"x\" 1*HEXDIG [ 1*(\".\" 1*HEXDIG) / (\"-\" 1*HEXDIG) ]",
+		operators.WithElement("hex-val", 1, operators.String("x", "x")),
+		operators.WithElement("hex-val", 2, operators.Repeat1Inf("1*HEXDIG", core.HEXDIG())),
+		operators.WithElement("hex-val", 4, operators.Optional("[ 1*(\".\" 1*HEXDIG) / (\"-\" 1*HEXDIG) ]", operators.WithElement("hex-val", 5, operators.AltsFirst(
+			"1*(\".\" 1*HEXDIG) / (\"-\" 1*HEXDIG)",
+			[]operators.ByteSet{
+				{0x400000000000, 0x0, 0x0, 0x0},
+				{0x200000000000, 0x0, 0x0, 0x0},
+			},
+			operators.WithElement("hex-val", 6, operators.Repeat1Inf("1*(\".\" 1*HEXDIG)", operators.WithElement("hex-val", 7, operators.Concat(
+				"\".\" 1*HEXDIG",
+				operators.WithElement("hex-val", 8, operators.String(".", ".")),
+				operators.WithElement("hex-val", 9, operators.Repeat1Inf("1*HEXDIG", core.HEXDIG())),
+			)))),
+			operators.WithElement("hex-val", 11, operators.Concat(
+				"\"-\" 1*HEXDIG",
+				operators.WithElement("hex-val", 12, operators.String("-", "-")),
+				operators.WithElement("hex-val", 13, operators.Repeat1Inf("1*HEXDIG", core.HEXDIG())),
+			)),
+		)))),
+	))
+	operatorNumVal = operators.Rule("num-val", operators.Concat(
+		"\"%\" (bin-val / dec-val / hex-val)",
+		operators.WithElement("num-val", 1, operators.String("%", "%")),
+		operators.WithElement("num-val", 2, operators.AltsFirst(
+			"bin-val / dec-val / hex-val",
+			[]operators.ByteSet{
+				{0x0, 0x400000000, 0x0, 0x0},
+				{0x0, 0x1000000000, 0x0, 0x0},
+				{0x0, 0x100000000000000, 0x0, 0x0},
+			},
+			BinVal,
+			DecVal,
+			HexVal,
+		)),
+	))
+	operatorOption = operators.Rule("option", operators.Concat(
+		"\"[\" *c-wsp alternation *c-wsp \"]\"",
+		operators.WithElement("option", 1, operators.String("[", "[")),
+		operators.WithElement("option", 2, operators.Repeat0Inf("*c-wsp", CWsp)),
+		Alternation,
+		operators.WithElement("option", 5, operators.Repeat0Inf("*c-wsp", CWsp)),
+		operators.WithElement("option", 7, operators.String("]", "]")),
+	))
+	operatorProseVal = operators.Rule("prose-val", operators.Concat(
+		"\"<\" *(%x20-3D / %x3F-7E) \">\"",
+		operators.WithElement("prose-val", 1, operators.String("<", "<")),
+		operators.WithElement("prose-val", 2, operators.Repeat0Inf("*(%x20-3D / %x3F-7E)", operators.WithElement("prose-val", 3, operators.AltsFirst(
+			"%x20-3D / %x3F-7E",
+			[]operators.ByteSet{
+				{0x3fffffff00000000, 0x0, 0x0, 0x0},
+				{0x8000000000000000, 0x7fffffffffffffff, 0x0, 0x0},
+			},
+			operators.WithElement("prose-val", 4, operators.Range("%x20-3D", []byte{32}, []byte{61})),
+			operators.WithElement("prose-val", 5, operators.Range("%x3F-7E", []byte{63}, []byte{126})),
+		)))),
+		operators.WithElement("prose-val", 6, operators.String(">", ">")),
+	))
+	operatorRepeat = operators.Rule("repeat", operators.AltsFirst(
+		"1*DIGIT / (*DIGIT \"*\" *DIGIT)",
+		[]operators.ByteSet{
+			{0x3ff000000000000, 0x0, 0x0, 0x0},
+			{0x3ff040000000000, 0x0, 0x0, 0x0},
+		},
+		operators.WithElement("repeat", 1, operators.Repeat1Inf("1*DIGIT", core.DIGIT())),
+		operators.WithElement("repeat", 3, operators.Concat(
+			"*DIGIT \"*\" *DIGIT",
+			operators.WithElement("repeat", 4, operators.Repeat0Inf("*DIGIT", core.DIGIT())),
+			operators.WithElement("repeat", 6, operators.String("*", "*")),
+			operators.WithElement("repeat", 7, operators.Repeat0Inf("*DIGIT", core.DIGIT())),
+		)),
+	))
+	operatorRepetition = operators.Rule("repetition", operators.Concat(
+		"[repeat] element",
+		operators.WithElement("repetition", 1, operators.Optional("[repeat]", Repeat)),
+		Element,
+	))
+	operatorRule = operators.Rule("rule", operators.Concat(
+		"rulename defined-as elements c-nl",
+		Rulename,
+		DefinedAs,
+		Elements,
+		CNl,
+	))
+	operatorRulelist = operators.Rule("rulelist", operators.Repeat1Inf("1*( rule / (*WSP c-nl) )", operators.WithElement("rulelist", 1, operators.AltsFirst(
+		"rule / (*WSP c-nl)",
+		[]operators.ByteSet{
+			{0x0, 0x7fffffe07fffffe, 0x0, 0x0},
+			{0x800000100002600, 0x0, 0x0, 0x0},
+		},
+		Rule,
+		operators.WithElement("rulelist", 3, operators.Concat(
+			"*WSP c-nl",
+			operators.WithElement("rulelist", 4, operators.Repeat0Inf("*WSP", core.WSP())),
+			CNl,
+		)),
+	))))
+	operatorRulename = operators.Rule("rulename", operators.Concat(
+		"ALPHA *(ALPHA / DIGIT / \"-\")",
 		core.ALPHA(),
-		operators.Repeat0Inf("*(ALPHA / DIGIT / \"-\")", operators.Alts(
+		operators.WithElement("rulename", 2, operators.Repeat0Inf("*(ALPHA / DIGIT / \"-\")", operators.WithElement("rulename", 3, operators.AltsFirst(
 			"ALPHA / DIGIT / \"-\"",
+			[]operators.ByteSet{
+				{0x0, 0x7fffffe07fffffe, 0x0, 0x0},
+				{0x3ff000000000000, 0x0, 0x0, 0x0},
+				{0x200000000000, 0x0, 0x0, 0x0},
+			},
 			core.ALPHA(),
 			core.DIGIT(),
-			operators.String("-", "-"),
-		)),
-	)(s)
+			operators.WithElement("rulename", 6, operators.String("-", "-")),
+		)))),
+	))
+	Rules[RuleAlternation] = Alternation
+	Rules[RuleBinVal] = BinVal
+	Rules[RuleCNl] = CNl
+	Rules[RuleCWsp] = CWsp
+	Rules[RuleCharVal] = CharVal
+	Rules[RuleComment] = Comment
+	Rules[RuleConcatenation] = Concatenation
+	Rules[RuleDecVal] = DecVal
+	Rules[RuleDefinedAs] = DefinedAs
+	Rules[RuleElement] = Element
+	Rules[RuleElements] = Elements
+	Rules[RuleGroup] = Group
+	Rules[RuleHexVal] = HexVal
+	Rules[RuleNumVal] = NumVal
+	Rules[RuleOption] = Option
+	Rules[RuleProseVal] = ProseVal
+	Rules[RuleRepeat] = Repeat
+	Rules[RuleRepetition] = Repetition
+	Rules[RuleRule] = Rule
+	Rules[RuleRulelist] = Rulelist
+	Rules[RuleRulename] = Rulename
+	Metadata[RuleAlternation] = RuleMetadata{ABNF: "alternation    =  concatenation\n                  *(*c-wsp \"/\" *c-wsp concatenation)", References: []RuleName{RuleCWsp, RuleConcatenation}}
+	Metadata[RuleBinVal] = RuleMetadata{ABNF: "bin-val        =  \"b\" 1*BIT\n                  [ 1*(\".\" 1*BIT) / (\"-\" 1*BIT) ]", References: []RuleName{"BIT"}}
+	Metadata[RuleCNl] = RuleMetadata{ABNF: "c-nl           =  comment / CRLF", References: []RuleName{"CRLF", RuleComment}}
+	Metadata[RuleCWsp] = RuleMetadata{ABNF: "c-wsp          =  WSP / (c-nl WSP)", References: []RuleName{"WSP", RuleCNl}}
+	Metadata[RuleCharVal] = RuleMetadata{ABNF: "char-val       =  DQUOTE *(%x20-21 / %x23-7E) DQUOTE", References: []RuleName{"DQUOTE"}}
+	Metadata[RuleComment] = RuleMetadata{ABNF: "comment        =  \";\" *(WSP / VCHAR) CRLF", References: []RuleName{"CRLF", "VCHAR", "WSP"}}
+	Metadata[RuleConcatenation] = RuleMetadata{ABNF: "concatenation  =  repetition *(1*c-wsp repetition)", References: []RuleName{RuleCWsp, RuleRepetition}}
+	Metadata[RuleDecVal] = RuleMetadata{ABNF: "dec-val        =  \"d\" 1*DIGIT\n                  [ 1*(\".\" 1*DIGIT) / (\"-\" 1*DIGIT) ]", References: []RuleName{"DIGIT"}}
+	Metadata[RuleDefinedAs] = RuleMetadata{ABNF: "defined-as     =  *c-wsp (\"=\" / \"=/\") *c-wsp", References: []RuleName{RuleCWsp}}
+	Metadata[RuleElement] = RuleMetadata{ABNF: "element        =  rulename / group / option /\n                  char-val / num-val / prose-val", References: []RuleName{RuleCharVal, RuleGroup, RuleNumVal, RuleOption, RuleProseVal, RuleRulename}}
+	Metadata[RuleElements] = RuleMetadata{ABNF: "elements       =  alternation *WSP", References: []RuleName{"WSP", RuleAlternation}}
+	Metadata[RuleGroup] = RuleMetadata{ABNF: "group          =  \"(\" *c-wsp alternation *c-wsp \")\"", References: []RuleName{RuleAlternation, RuleCWsp}}
+	Metadata[RuleHexVal] = RuleMetadata{ABNF: "hex-val        =  \"x\" 1*HEXDIG\n                  [ 1*(\".\" 1*HEXDIG) / (\"-\" 1*HEXDIG) ]", References: []RuleName{"HEXDIG"}}
+	Metadata[RuleNumVal] = RuleMetadata{ABNF: "num-val        =  \"%\" (bin-val / dec-val / hex-val)", References: []RuleName{RuleBinVal, RuleDecVal, RuleHexVal}}
+	Metadata[RuleOption] = RuleMetadata{ABNF: "option         =  \"[\" *c-wsp alternation *c-wsp \"]\"", References: []RuleName{RuleAlternation, RuleCWsp}}
+	Metadata[RuleProseVal] = RuleMetadata{ABNF: "prose-val      =  \"<\" *(%x20-3D / %x3F-7E) \">\""}
+	Metadata[RuleRepeat] = RuleMetadata{ABNF: "repeat         =  1*DIGIT / (*DIGIT \"*\" *DIGIT)", References: []RuleName{"DIGIT"}}
+	Metadata[RuleRepetition] = RuleMetadata{ABNF: "repetition     =  [repeat] element", References: []RuleName{RuleElement, RuleRepeat}}
+	Metadata[RuleRule] = RuleMetadata{ABNF: "rule           =  rulename defined-as elements c-nl", References: []RuleName{RuleCNl, RuleDefinedAs, RuleElements, RuleRulename}}
+	Metadata[RuleRulelist] = RuleMetadata{ABNF: "rulelist       =  1*( rule / (*WSP c-nl) )", References: []RuleName{"WSP", RuleCNl, RuleRule}}
+	Metadata[RuleRulename] = RuleMetadata{ABNF: "rulename       =  ALPHA *(ALPHA / DIGIT / \"-\")", References: []RuleName{"ALPHA", "DIGIT"}}
 }
@@ -160,3 +160,40 @@ func compareRunes(a, b string) bool {
 	}
 	return true
 }
+
+func BenchmarkRulelist(b *testing.B) {
+	rawABNF, err := ioutil.ReadFile("../testdata/core.abnf")
+	if err != nil {
+		b.Fatal(err)
+	}
+	for i := 0; i < b.N; i++ {
+		Rulelist(rawABNF)
+	}
+}
+
+func BenchmarkRulename(b *testing.B) {
+	input := []byte("rule-name")
+	for i := 0; i < b.N; i++ {
+		Rulename(input)
+	}
+}
+
+func TestRules(t *testing.T) {
+	if len(Rules) != len(Metadata) {
+		t.Fatalf("expected metadata for all %d rules, got %d", len(Rules), len(Metadata))
+	}
+	for name, rule := range Rules {
+		metadata, ok := Metadata[name]
+		if !ok || rule == nil {
+			t.Errorf("%s: missing operator or metadata", name)
+			continue
+		}
+		// the rules describe themselves
+		if nodes := Rules[RuleRule]([]byte(metadata.ABNF + "\r\n")); len(nodes) == 0 || nodes.Best().GetSubRule(string(RuleRulename)).String() != string(name) {
+			t.Errorf("%s: could not parse %q", name, metadata.ABNF)
+		}
+	}
+	if references := Metadata[RuleElement].References; len(references) != 6 || references[0] != RuleCharVal {
+		t.Errorf("unexpected references: %v", references)
+	}
+}
@@ -0,0 +1,379 @@
+package abnf
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/elimity-com/abnf/operators"
+)
+
+// descentFunction is an operator that still needs to be written as a function.
+type descentFunction struct {
+	name     string
+	operator Operator
+	element  operators.Element
+}
+
+// descent writes every rule as a plain function that builds its parse trees, the (anonymous) operators of the rule get
+// a function of their own, e.g. parseCRLF_1. Nothing gets constructed at call time, external operators are stored in
+// variables (e.g. coreALPHA) and DFAs are only compiled once.
+func (g *CodeGenerator) descent(ruleSet RuleSet, dfas map[string]*operators.DFA) {
+	g.descentDFAs = dfas
+	g.descentExternals(ruleSet, dfas)
+
+	for _, name := range ruleSet.names() {
+		rule := ruleSet[name]
+		g.ln()
+		g.c("%s = %s", rule.name, rule.operator.Key())
+		if dfa, ok := dfas[name]; ok {
+			g.descentDFA(rule.name, dfa)
+			continue
+		}
+
+		function := g.descentName(rule.name)
+		if g.isOperator {
+			g.wlnf("func %s() operators.Operator {", g.name(rule.name))
+			g.in(func() {
+				g.wlnf("return %s", function)
+			})
+			g.wln("}")
+			g.ln()
+		}
+		g.descentRule = "parse" + g.base(rule.name)
+		g.descentFunctions = nil
+		g.wlnf("func %s(s []byte) operators.Alternatives {", function)
+		g.in(func() {
+			g.wln("var nodes operators.Alternatives")
+			g.wlnf("for _, node := range %s(s) {", g.descentReference(rule.operator, operators.Element{Rule: rule.name}))
+			g.in(func() {
+				g.wln("children := node.Children")
+				g.wln("if node.IsRule() {")
+				g.in(func() {
+					g.wln("children = operators.Children{node}")
+				})
+				g.wln("}")
+				g.wlnf("nodes = append(nodes, &operators.Node{Key: %q, Kind: operators.KindRule, Element: %s, Value: node.Value, Children: children})", rule.name, elementLiteral(operators.Element{Rule: rule.name}))
+			})
+			g.wln("}")
+			g.wln("return nodes")
+		})
+		g.wln("}")
+
+		// writing a function can add new ones
+		for i := 0; i < len(g.descentFunctions); i++ {
+			function := g.descentFunctions[i]
+			g.ln()
+			g.c("%s", operatorText(function.operator))
+			function.operator.descent(g, function.name, function.element)
+		}
+	}
+}
+
+// descentExternals writes a variable for every external operator that is used by the given rules, so they only get
+// constructed once.
+func (g *CodeGenerator) descentExternals(ruleSet RuleSet, dfas map[string]*operators.DFA) {
+	externals := make(map[string]string)
+	for _, name := range ruleSet.names() {
+		if _, ok := dfas[name]; ok {
+			continue
+		}
+		_ = walkOperators(ruleSet[name].operator, func(operator Operator) error {
+			reference, ok := operator.(RuleNameOperator)
+			if !ok {
+				return nil
+			}
+			if external, ok := g.ExternalABNF[reference.key]; ok && external.IsOperator {
+				externals[g.descentExternal(reference.key)] = fmt.Sprintf("%s.%s()", external.PackageName, reference.key)
+			}
+			return nil
+		})
+	}
+	var names []string
+	for name := range externals {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if len(names) != 0 {
+		g.ln()
+	}
+	for _, name := range names {
+		g.wlnf("var %s = %s", name, externals[name])
+	}
+}
+
+// descentDFA writes a rule that is compiled to the given DFA.
+func (g *CodeGenerator) descentDFA(name string, dfa *operators.DFA) {
+	variable := "dfa" + g.base(name)
+	if g.isOperator {
+		g.wlnf("func %s() operators.Operator {", g.name(name))
+		g.in(func() {
+			g.wlnf("return %s", variable)
+		})
+	} else {
+		g.wlnf("func %s(s []byte) operators.Alternatives {", g.name(name))
+		g.in(func() {
+			g.wlnf("return %s(s)", variable)
+		})
+	}
+	g.wln("}")
+	g.ln()
+	g.wf("var %s = (", variable)
+	g.dfa(dfa)
+	g.wlnf(").Operator(%q)", name)
+}
+
+// descentName returns the name of the function of the rule with the given name.
+func (g *CodeGenerator) descentName(name string) string {
+	if g.isOperator {
+		return "parse" + g.base(name)
+	}
+	return g.name(name)
+}
+
+// descentExternal returns the name of the variable of the external operator with the given name.
+func (g *CodeGenerator) descentExternal(name string) string {
+	return g.ExternalABNF[name].PackageName + formatRuleName(name)
+}
+
+// descentReference returns the name of the function of the given operator, which is the given element. It gets written
+// after the current rule if it is not a reference to a rule.
+func (g *CodeGenerator) descentReference(operator Operator, element operators.Element) string {
+	if reference, ok := operator.(RuleNameOperator); ok {
+		external, ok := g.ExternalABNF[reference.key]
+		switch {
+		case !ok:
+			if _, isDFA := g.descentDFAs[reference.key]; isDFA && g.isOperator {
+				return "dfa" + g.base(reference.key)
+			}
+			return g.descentName(reference.key)
+		case external.IsOperator:
+			return g.descentExternal(reference.key)
+		default:
+			return fmt.Sprintf("%s.%s", external.PackageName, reference.key)
+		}
+	}
+	name := fmt.Sprintf("%s_%d", g.descentRule, len(g.descentFunctions)+1)
+	g.descentFunctions = append(g.descentFunctions, descentFunction{name: name, operator: operator, element: element})
+	return name
+}
+
+// elementLiteral returns the Go expression of the given element.
+func elementLiteral(element operators.Element) string {
+	if element.Index == 0 {
+		return fmt.Sprintf("operators.Element{Rule: %q}", element.Rule)
+	}
+	return fmt.Sprintf("operators.Element{Rule: %q, Index: %d}", element.Rule, element.Index)
+}
+
+// byteCondition returns a Go expression that checks whether the next byte of the input (s[0]) is part of the given set.
+func byteCondition(set operators.ByteSet) string {
+	var conditions []string
+	for low := 0; low < 256; low++ {
+		if !set.Contains(byte(low)) {
+			continue
+		}
+		high := low
+		for high < 255 && set.Contains(byte(high+1)) {
+			high++
+		}
+		switch {
+		case low == high:
+			conditions = append(conditions, fmt.Sprintf("s[0] == 0x%02x", low))
+		case low == 0:
+			conditions = append(conditions, fmt.Sprintf("s[0] <= 0x%02x", high))
+		case high == 255:
+			conditions = append(conditions, fmt.Sprintf("0x%02x <= s[0]", low))
+		default:
+			conditions = append(conditions, fmt.Sprintf("(0x%02x <= s[0] && s[0] <= 0x%02x)", low, high))
+		}
+		low = high
+	}
+	return strings.Join(conditions, " || ")
+}
+
+func (alt AlternationOperator) descent(g *CodeGenerator, name string, element operators.Element) {
+	first, ok := dispatch(g.analysis, alt)
+	elements := subElements(alt, element)
+	var all operators.ByteSet
+	all.AddRange(0, 255)
+
+	g.wlnf("func %s(s []byte) operators.Alternatives {", name)
+	g.in(func() {
+		g.wln("var nodes operators.Alternatives")
+		for i, operator := range alt.subOperators {
+			loop := func() {
+				g.wlnf("for _, node := range %s(s) {", g.descentReference(operator, elements[i]))
+				g.in(func() {
+					g.wlnf("nodes = append(nodes, &operators.Node{Key: %q, Kind: operators.KindAlternation, Element: %s, Value: node.Value, Children: operators.Children{node}})", alt.key, elementLiteral(element))
+				})
+				g.wln("}")
+			}
+			if !ok || first[i] == all {
+				loop()
+				continue
+			}
+			if first[i].IsEmpty() {
+				g.wln("if len(s) == 0 {")
+			} else {
+				g.wlnf("if len(s) == 0 || %s {", byteCondition(first[i]))
+			}
+			g.in(loop)
+			g.wln("}")
+		}
+		g.wln("return nodes")
+	})
+	g.wln("}")
+}
+
+func (concat ConcatenationOperator) descent(g *CodeGenerator, name string, element operators.Element) {
+	elements := subElements(concat, element)
+	g.wlnf("func %s(s []byte) operators.Alternatives {", name)
+	g.in(func() {
+		g.wln("var nodes operators.Alternatives")
+		var loop func(i int)
+		loop = func(i int) {
+			if i == len(concat.subOperators) {
+				children := make([]string, i)
+				for j := range children {
+					children[j] = fmt.Sprintf("n_%d", j)
+				}
+				g.wlnf("nodes = append(nodes, &operators.Node{Key: %q, Kind: operators.KindConcatenation, Element: %s, Value: s[:l_%d], Children: operators.Children{%s}})", concat.key, elementLiteral(element), i-1, strings.Join(children, ", "))
+				return
+			}
+			reference := g.descentReference(concat.subOperators[i], elements[i])
+			if i == 0 {
+				g.wlnf("for _, n_0 := range %s(s) {", reference)
+			} else {
+				g.wlnf("for _, n_%d := range %s(s[l_%d:]) {", i, reference, i-1)
+			}
+			g.in(func() {
+				if i == 0 {
+					g.wln("l_0 := len(n_0.Value)")
+				} else {
+					g.wlnf("l_%d := l_%d + len(n_%d.Value)", i, i-1, i)
+				}
+				loop(i + 1)
+			})
+			g.wln("}")
+		}
+		loop(0)
+		g.wln("return nodes")
+	})
+	g.wln("}")
+}
+
+func (rep RepetitionOperator) descent(g *CodeGenerator, name string, element operators.Element) {
+	repeat := "repeat" + strings.TrimPrefix(name, "parse")
+	g.wlnf("func %s(s []byte) operators.Alternatives {", name)
+	g.in(func() {
+		g.wlnf("return %s(s, 0, 0)", repeat)
+	})
+	g.wln("}")
+	g.ln()
+	g.wlnf("func %s(s []byte, i, l int) operators.Alternatives {", repeat)
+	g.in(func() {
+		g.wln("var nodes operators.Alternatives")
+		loop := func() {
+			g.wlnf("for _, node := range %s(s[l:]) {", g.descentReference(rep.subOperator, subElements(rep, element)[0]))
+			g.in(func() {
+				g.wlnf("for _, n := range %s(s, i+1, l+len(node.Value)) {", repeat)
+				g.in(func() {
+					g.wln("n.Children = append(operators.Children{node}, n.Children...)")
+					g.wln("nodes = append(nodes, n)")
+				})
+				g.wln("}")
+			})
+			g.wln("}")
+		}
+		if rep.max < 0 {
+			loop()
+		} else {
+			g.wlnf("if i < %d {", rep.max)
+			g.in(loop)
+			g.wln("}")
+		}
+		if 0 < rep.min {
+			g.wlnf("if i < %d {", rep.min)
+			g.in(func() {
+				g.wln("return nodes")
+			})
+			g.wln("}")
+		}
+		g.wlnf("return append(nodes, &operators.Node{Key: %q, Kind: operators.KindRepetition, Element: %s, Value: s[:l]})", rep.key, elementLiteral(element))
+	})
+	g.wln("}")
+}
+
+func (name RuleNameOperator) descent(*CodeGenerator, string, operators.Element) {
+	// references do not get a function of their own
+}
+
+func (opt OptionOperator) descent(g *CodeGenerator, name string, element operators.Element) {
+	g.wlnf("func %s(s []byte) operators.Alternatives {", name)
+	g.in(func() {
+		g.wln("var nodes operators.Alternatives")
+		g.wlnf("for _, node := range %s(s) {", g.descentReference(opt.subOperator, subElements(opt, element)[0]))
+		g.in(func() {
+			g.wlnf("nodes = append(nodes, &operators.Node{Key: %q, Kind: operators.KindOption, Element: %s, Value: node.Value, Children: operators.Children{node}})", opt.key, elementLiteral(element))
+		})
+		g.wln("}")
+		g.wlnf("return append(nodes, &operators.Node{Key: %q, Kind: operators.KindOption, Element: %s, Value: s[:0]})", opt.key, elementLiteral(element))
+	})
+	g.wln("}")
+}
+
+func (value CharacterValueOperator) descent(g *CodeGenerator, name string, element operators.Element) {
+	descentTerminal(g, name, value.value, []byte(value.value), element)
+}
+
+func (value NumericValueOperator) descent(g *CodeGenerator, name string, element operators.Element) {
+	low, high := value.bytes()
+	switch {
+	case !value.hyphen:
+		descentTerminal(g, name, value.key, low, element)
+	case len(low) == 1 && len(high) == 1:
+		g.wlnf("func %s(s []byte) operators.Alternatives {", name)
+		g.in(func() {
+			var conditions []string
+			if low[0] != 0 {
+				conditions = append(conditions, fmt.Sprintf("s[0] < 0x%02x", low[0]))
+			}
+			if high[0] != 255 {
+				conditions = append(conditions, fmt.Sprintf("0x%02x < s[0]", high[0]))
+			}
+			g.wlnf("if %s {", strings.Join(append([]string{"len(s) == 0"}, conditions...), " || "))
+			g.in(func() {
+				g.wln("return nil")
+			})
+			g.wln("}")
+			g.wlnf("return operators.Alternatives{{Key: %q, Kind: operators.KindTerminal, Element: %s, Value: s[:1]}}", value.key, elementLiteral(element))
+		})
+		g.wln("}")
+	default:
+		// ranges of multiple bytes are matched byte by byte, see operators.Range
+		g.wlnf("var %s = operators.WithElement(%q, %d, operators.Range(%q, %s, %s))", name, element.Rule, element.Index, value.key, byteSlice(low), byteSlice(high))
+	}
+}
+
+// descentTerminal writes a function that matches the given bytes.
+func descentTerminal(g *CodeGenerator, name, key string, value []byte, element operators.Element) {
+	g.wlnf("func %s(s []byte) operators.Alternatives {", name)
+	g.in(func() {
+		if len(value) == 0 {
+			g.wlnf("return operators.Alternatives{{Key: %q, Kind: operators.KindTerminal, Element: %s, Value: s[:0]}}", key, elementLiteral(element))
+			return
+		}
+		if len(value) == 1 {
+			g.wlnf("if len(s) == 0 || s[0] != 0x%02x {", value[0])
+		} else {
+			g.wlnf("if len(s) < %d || string(s[:%d]) != %q {", len(value), len(value), value)
+		}
+		g.in(func() {
+			g.wln("return nil")
+		})
+		g.wln("}")
+		g.wlnf("return operators.Alternatives{{Key: %q, Kind: operators.KindTerminal, Element: %s, Value: s[:%d]}}", key, elementLiteral(element), len(value))
+	})
+	g.wln("}")
+}
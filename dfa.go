@@ -0,0 +1,422 @@
+package abnf
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/elimity-com/abnf/operators"
+)
+
+// maxDFAStates is the maximum amount of states of a compiled DFA.
+const maxDFAStates = 10000
+
+// NonRecursive returns the (sorted) names of the rules that do not refer to themselves, directly or indirectly, and of
+// which all the referenced rules are part of the set. The languages of these rules are regular.
+func (set RuleSet) NonRecursive() []string {
+	const (
+		unvisited = iota
+		visiting
+		regular
+		irregular
+	)
+	state := make(map[string]int)
+	var visit func(name string) bool
+	visit = func(name string) bool {
+		switch state[name] {
+		case visiting, irregular:
+			return false
+		case regular:
+			return true
+		}
+		rule, ok := set[name]
+		if !ok {
+			return false
+		}
+		state[name] = visiting
+		ok = walkOperators(rule.operator, func(operator Operator) error {
+			if name, isName := operator.(RuleNameOperator); isName && !visit(name.key) {
+				return fmt.Errorf("irregular")
+			}
+			return nil
+		}) == nil
+		state[name] = irregular
+		if ok {
+			state[name] = regular
+		}
+		return ok
+	}
+
+	var names []string
+	for _, name := range set.names() {
+		if visit(name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// DFA compiles the rule with the given name to a (minimal) deterministic finite automaton. Referenced rules get inlined,
+// so they need to be part of the set (e.g. add the CoreRules). Recursive rules and numeric values of more than one byte
+// are not supported.
+func (set RuleSet) DFA(rule string) (*operators.DFA, error) {
+	n := new(nfa)
+	start, end, err := n.rule(set, rule, make(map[string]bool))
+	if err != nil {
+		return nil, err
+	}
+	n.end = end
+	dfa, err := n.dfa(start)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", rule, err)
+	}
+	return minimize(dfa), nil
+}
+
+// nfa is a nondeterministic finite automaton with epsilon transitions.
+type nfa struct {
+	states []nfaState
+	end    int
+}
+
+type nfaState struct {
+	epsilon []int
+	// the target of the bytes, if there are any
+	bytes  operators.ByteSet
+	target int
+}
+
+func (n *nfa) state() int {
+	n.states = append(n.states, nfaState{target: -1})
+	return len(n.states) - 1
+}
+
+func (n *nfa) epsilon(from, to int) {
+	n.states[from].epsilon = append(n.states[from].epsilon, to)
+}
+
+// rule adds the states of the rule with the given name, and returns its start and end state.
+func (n *nfa) rule(set RuleSet, name string, visiting map[string]bool) (int, int, error) {
+	rule, ok := set[name]
+	if !ok {
+		return 0, 0, fmt.Errorf("unknown rule: %s", name)
+	}
+	if visiting[name] {
+		return 0, 0, fmt.Errorf("recursive rule: %s", name)
+	}
+	visiting[name] = true
+	defer delete(visiting, name)
+	return n.operator(set, rule.operator, visiting)
+}
+
+// operator adds the states of the given operator, and returns its start and end state.
+func (n *nfa) operator(set RuleSet, operator Operator, visiting map[string]bool) (int, int, error) {
+	switch operator := operator.(type) {
+	case AlternationOperator:
+		start, end := n.state(), n.state()
+		for _, subOperator := range operator.subOperators {
+			subStart, subEnd, err := n.operator(set, subOperator, visiting)
+			if err != nil {
+				return 0, 0, err
+			}
+			n.epsilon(start, subStart)
+			n.epsilon(subEnd, end)
+		}
+		return start, end, nil
+	case ConcatenationOperator:
+		start := n.state()
+		end := start
+		for _, subOperator := range operator.subOperators {
+			subStart, subEnd, err := n.operator(set, subOperator, visiting)
+			if err != nil {
+				return 0, 0, err
+			}
+			n.epsilon(end, subStart)
+			end = subEnd
+		}
+		return start, end, nil
+	case RepetitionOperator:
+		start := n.state()
+		end := start
+		if 0 <= operator.max && operator.max < operator.min {
+			// matches nothing
+			return start, n.state(), nil
+		}
+		for i := 0; i < operator.min; i++ {
+			subStart, subEnd, err := n.operator(set, operator.subOperator, visiting)
+			if err != nil {
+				return 0, 0, err
+			}
+			n.epsilon(end, subStart)
+			end = subEnd
+		}
+		if operator.max < 0 {
+			subStart, subEnd, err := n.operator(set, operator.subOperator, visiting)
+			if err != nil {
+				return 0, 0, err
+			}
+			n.epsilon(end, subStart)
+			n.epsilon(subEnd, end)
+			return start, end, nil
+		}
+		optionalEnd := n.state()
+		for i := operator.min; i < operator.max; i++ {
+			subStart, subEnd, err := n.operator(set, operator.subOperator, visiting)
+			if err != nil {
+				return 0, 0, err
+			}
+			n.epsilon(end, subStart)
+			n.epsilon(end, optionalEnd)
+			end = subEnd
+		}
+		n.epsilon(end, optionalEnd)
+		return start, optionalEnd, nil
+	case RuleNameOperator:
+		return n.rule(set, operator.key, visiting)
+	case OptionOperator:
+		subStart, subEnd, err := n.operator(set, operator.subOperator, visiting)
+		if err != nil {
+			return 0, 0, err
+		}
+		n.epsilon(subStart, subEnd)
+		return subStart, subEnd, nil
+	case CharacterValueOperator, NumericValueOperator:
+		sets, err := terminalBytes(operator)
+		if err != nil {
+			return 0, 0, err
+		}
+		start := n.state()
+		end := start
+		for _, bytes := range sets {
+			next := n.state()
+			n.states[end].bytes = bytes
+			n.states[end].target = next
+			end = next
+		}
+		return start, end, nil
+	default:
+		return 0, 0, fmt.Errorf("unsupported operator: %s", operator.Key())
+	}
+}
+
+// terminalBytes returns the bytes that get accepted by the given terminal, position by position.
+func terminalBytes(operator Operator) ([]operators.ByteSet, error) {
+	var value []byte
+	switch operator := operator.(type) {
+	case CharacterValueOperator:
+		value = []byte(operator.value)
+	case NumericValueOperator:
+		values := operator.toIntegers()
+		for _, v := range values {
+			if len(v) != 1 {
+				return nil, fmt.Errorf("multi-byte values are not supported: %s", operator.key)
+			}
+		}
+		if !operator.points {
+			// single values get encoded and ranges depend on the input, so the operator itself gets consulted
+			var bytes operators.ByteSet
+			leaf := operator.toFunc(nil, operators.Element{})
+			for b := 0; b < 256; b++ {
+				if nodes := leaf([]byte{byte(b)}); len(nodes) != 0 && len(nodes[0].Value) == 1 {
+					bytes.Add(byte(b))
+				}
+			}
+			return []operators.ByteSet{bytes}, nil
+		}
+		for _, v := range values {
+			value = append(value, byte(v[0]))
+		}
+	}
+	sets := make([]operators.ByteSet, len(value))
+	for i, b := range value {
+		sets[i].Add(b)
+	}
+	return sets, nil
+}
+
+// closure adds all the states that can be reached by epsilon transitions to the given (sorted) states.
+func (n *nfa) closure(states []int) []int {
+	seen := make(map[int]bool)
+	stack := append([]int{}, states...)
+	for len(stack) != 0 {
+		state := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if seen[state] {
+			continue
+		}
+		seen[state] = true
+		stack = append(stack, n.states[state].epsilon...)
+	}
+	closure := make([]int, 0, len(seen))
+	for state := range seen {
+		closure = append(closure, state)
+	}
+	sort.Ints(closure)
+	return closure
+}
+
+// dfa converts the automaton to a deterministic one with the subset construction.
+func (n *nfa) dfa(start int) (*operators.DFA, error) {
+	key := func(states []int) string {
+		return strings.Trim(fmt.Sprint(states), "[]")
+	}
+	dfa := new(operators.DFA)
+	ids := make(map[string]int)
+	var subsets [][]int
+	add := func(states []int) int {
+		k := key(states)
+		if id, ok := ids[k]; ok {
+			return id
+		}
+		ids[k] = len(subsets)
+		subsets = append(subsets, states)
+		dfa.States = append(dfa.States, operators.DFAState{})
+		return ids[k]
+	}
+
+	add(n.closure([]int{start}))
+	for i := 0; i < len(subsets); i++ {
+		if maxDFAStates < len(subsets) {
+			return nil, fmt.Errorf("too many states")
+		}
+		var edges []operators.DFAEdge
+		for b := 0; b < 256; b++ {
+			var targets []int
+			for _, state := range subsets[i] {
+				if n.states[state].target >= 0 && n.states[state].bytes.Contains(byte(b)) {
+					targets = append(targets, n.states[state].target)
+				}
+			}
+			if len(targets) == 0 {
+				continue
+			}
+			edges = addEdge(edges, byte(b), add(n.closure(targets)))
+		}
+		for _, state := range subsets[i] {
+			if state == n.end {
+				dfa.States[i].Accepting = true
+			}
+		}
+		dfa.States[i].Edges = edges
+	}
+	return dfa, nil
+}
+
+// addEdge adds an edge for the given byte, it extends the last edge if possible.
+func addEdge(edges []operators.DFAEdge, b byte, target int) []operators.DFAEdge {
+	if l := len(edges); l != 0 && edges[l-1].Target == target && edges[l-1].High == b-1 {
+		edges[l-1].High = b
+		return edges
+	}
+	return append(edges, operators.DFAEdge{Low: b, High: b, Target: target})
+}
+
+// minimize merges all the equivalent states of the given DFA (Moore's algorithm).
+func minimize(dfa *operators.DFA) *operators.DFA {
+	blocks := make([]int, len(dfa.States))
+	for i, state := range dfa.States {
+		if state.Accepting {
+			blocks[i] = 1
+		}
+	}
+	signature := func(i int) string {
+		var edges []operators.DFAEdge
+		for _, edge := range dfa.States[i].Edges {
+			for b := int(edge.Low); b <= int(edge.High); b++ {
+				edges = addEdge(edges, byte(b), blocks[edge.Target])
+			}
+		}
+		return fmt.Sprint(blocks[i], edges)
+	}
+
+	for count := 0; ; {
+		ids := make(map[string]int)
+		next := make([]int, len(blocks))
+		// the start state is always part of the first block
+		for i := range dfa.States {
+			s := signature(i)
+			if _, ok := ids[s]; !ok {
+				ids[s] = len(ids)
+			}
+			next[i] = ids[s]
+		}
+		blocks = next
+		if len(ids) == count {
+			break
+		}
+		count = len(ids)
+	}
+
+	minimal := new(operators.DFA)
+	for i := range dfa.States {
+		if blocks[i] < len(minimal.States) {
+			continue
+		}
+		var edges []operators.DFAEdge
+		for _, edge := range dfa.States[i].Edges {
+			for b := int(edge.Low); b <= int(edge.High); b++ {
+				edges = addEdge(edges, byte(b), blocks[edge.Target])
+			}
+		}
+		minimal.States = append(minimal.States, operators.DFAState{
+			Accepting: dfa.States[i].Accepting,
+			Edges:     edges,
+		})
+	}
+	return minimal
+}
+
+// dfaNext returns the state after reading the given byte, or -1 if there is no such state.
+func dfaNext(dfa *operators.DFA, state int, b byte) int {
+	if state < 0 || len(dfa.States) <= state {
+		return -1
+	}
+	for _, edge := range dfa.States[state].Edges {
+		if edge.Low <= b && b <= edge.High {
+			return edge.Target
+		}
+	}
+	return -1
+}
+
+// difference returns the shortest input that is accepted by the first DFA, but not by the second one. The search is
+// breadth first on the product of both automata, -1 represents the (rejecting) state without any edges.
+func difference(a, b *operators.DFA) ([]byte, bool) {
+	if len(a.States) == 0 {
+		return nil, false
+	}
+	type pair struct{ a, b int }
+	type step struct {
+		previous pair
+		b        byte
+	}
+	start := pair{0, 0}
+	if len(b.States) == 0 {
+		start.b = -1
+	}
+	steps := map[pair]step{start: {}}
+	queue := []pair{start}
+	for len(queue) != 0 {
+		p := queue[0]
+		queue = queue[1:]
+		if a.States[p.a].Accepting && (p.b < 0 || !b.States[p.b].Accepting) {
+			var s []byte
+			for p != start {
+				s = append([]byte{steps[p].b}, s...)
+				p = steps[p].previous
+			}
+			return s, true
+		}
+		for _, edge := range a.States[p.a].Edges {
+			for c := int(edge.Low); c <= int(edge.High); c++ {
+				next := pair{edge.Target, dfaNext(b, p.b, byte(c))}
+				if _, ok := steps[next]; ok {
+					continue
+				}
+				steps[next] = step{previous: p, b: byte(c)}
+				queue = append(queue, next)
+			}
+		}
+	}
+	return nil, false
+}
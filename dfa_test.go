@@ -0,0 +1,100 @@
+package abnf
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRuleSetNonRecursive(t *testing.T) {
+	set := NewRuleSet([]byte(strings.Join([]string{
+		"a = b c",
+		"b = \"b\"",
+		"c = *\"c\"",
+		"d = \"d\" [d]",
+		"e = f",
+		"f = \"f\" / e",
+		"g = d",
+		"h = ALPHA",
+	}, "\n") + "\n"))
+	if names := set.NonRecursive(); !reflect.DeepEqual(names, []string{"a", "b", "c"}) {
+		t.Errorf("invalid rules: %v", names)
+	}
+}
+
+// TestRuleSetDFA compares the lengths of the matches of the DFAs with the ones of the operators.
+func TestRuleSetDFA(t *testing.T) {
+	set := CoreRules(false)
+	for name, rule := range NewRuleSet([]byte(strings.Join([]string{
+		"word = 2*3(\"a\" / \"ab\") [DIGIT]",
+		"hex = \"0x\" 1*HEXDIG",
+		"points = %x61.62 / %d97",
+		"empty = 0\"a\"",
+	}, "\n") + "\n")) {
+		set[name] = rule
+	}
+	functions := new(ParserGenerator).generate(set)
+
+	bytes := []byte{0x00, 0x09, 0x0A, 0x0D, 0x20, '0', '9', 'A', 'F', 'G', 'a', 'b', 'f', 'x', 0x7E, 0x7F, 0x80, 0xFF}
+	inputs := []string{"", "0x1f", "abab1", "aaab"}
+	for _, a := range bytes {
+		inputs = append(inputs, string([]byte{a}))
+		for _, b := range bytes {
+			inputs = append(inputs, string([]byte{a, b}))
+		}
+	}
+
+	for _, name := range set.names() {
+		dfa, err := set.DFA(name)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		for _, input := range inputs {
+			var expected []int
+			for _, node := range functions[name]([]byte(input)) {
+				expected = append(expected, len(node.Value))
+			}
+			expected = uniqueDescending(expected)
+			if actual := dfa.Match([]byte(input)); !reflect.DeepEqual(expected, actual) {
+				t.Errorf("%s %q: expected %v, got %v", name, input, expected, actual)
+			}
+		}
+	}
+
+	for _, test := range []struct {
+		abnf, rule string
+	}{
+		{"a = \"a\" [a]\n", "a"},
+		{"a = b\n", "a"},
+		{"a = %x100\n", "a"},
+	} {
+		if _, err := NewRuleSet([]byte(test.abnf)).DFA(test.rule); err == nil {
+			t.Errorf("expected an error for %s", test.abnf)
+		}
+	}
+}
+
+func TestParserGeneratorDFA(t *testing.T) {
+	g := ParserGenerator{
+		RawABNF: []byte("list = item *(\",\" item)\nitem = 1*%x61-7A\n"),
+		DFA:     true,
+	}
+	nodes := g.GenerateABNFAsOperators()["list"]([]byte("ab,c"))
+	if best := nodes.Best(); best.String() != "ab,c" || best.Children != nil {
+		t.Errorf("expected a leaf node, got %v", best)
+	}
+}
+
+func uniqueDescending(lengths []int) []int {
+	var unique []int
+	for l := 64; 0 <= l; l-- {
+		for _, length := range lengths {
+			if length == l {
+				unique = append(unique, l)
+				break
+			}
+		}
+	}
+	return unique
+}
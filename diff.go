@@ -0,0 +1,399 @@
+package abnf
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Change is the kind of change of a rule.
+type Change int
+
+const (
+	ChangeAdded Change = iota
+	ChangeRemoved
+	ChangeModified
+)
+
+var changeNames = [...]string{
+	ChangeAdded:    "added",
+	ChangeRemoved:  "removed",
+	ChangeModified: "modified",
+}
+
+func (c Change) String() string {
+	if c < 0 || int(c) >= len(changeNames) {
+		return fmt.Sprintf("change(%d)", int(c))
+	}
+	return changeNames[c]
+}
+
+// Effect is the effect of a change on the language that is accepted by a rule.
+type Effect int
+
+const (
+	// EffectUnknown means that the effect could not be decided.
+	EffectUnknown Effect = iota
+	// EffectEquivalent means that the rule still accepts the same language, e.g. alternatives were reordered.
+	EffectEquivalent
+	// EffectWidens means that the rule accepts all the inputs it accepted before, and more.
+	EffectWidens
+	// EffectNarrows means that the rule only accepts inputs it accepted before, but not all of them.
+	EffectNarrows
+	// EffectAlters means that the rule accepts inputs it did not accept before and the other way around.
+	EffectAlters
+)
+
+var effectNames = [...]string{
+	EffectUnknown:    "unknown",
+	EffectEquivalent: "equivalent",
+	EffectWidens:     "widens",
+	EffectNarrows:    "narrows",
+	EffectAlters:     "alters",
+}
+
+func (e Effect) String() string {
+	if e < 0 || int(e) >= len(effectNames) {
+		return fmt.Sprintf("effect(%d)", int(e))
+	}
+	return effectNames[e]
+}
+
+// RuleDiff is the difference between two versions of a rule.
+type RuleDiff struct {
+	Name   string
+	Change Change
+	// Effect of a modification on the accepted language.
+	Effect Effect
+	// Operators that differ between both versions of a modified rule.
+	Operators []OperatorDiff
+}
+
+// OperatorDiff is the difference between two versions of an operator, their ABNF text is empty if the operator was
+// added or removed (e.g. an alternative).
+type OperatorDiff struct {
+	Old, New string
+	Effect   Effect
+}
+
+// Diff returns the (sorted by name) differences between the rules of both sets. Differences in formatting (white space
+// and the notation of numeric values) are ignored.
+//
+// The effect of a modification is decided with DFAs if the rule is not recursive in both sets (see NonRecursive), so
+// changes of the referenced rules are taken into account. Otherwise it is derived from the operators if none of the
+// referenced rules changed, e.g. adding an alternative widens the language. Removing an alternative does not prove that
+// the language narrows (another alternative may cover it), so its effect is unknown.
+func Diff(old, updated RuleSet) []RuleDiff {
+	analysis := diffAnalysis{old: old.Analyze(), updated: updated.Analyze()}
+	var diffs []RuleDiff
+	for _, name := range old.names() {
+		if _, ok := updated[name]; !ok {
+			diffs = append(diffs, RuleDiff{Name: name, Change: ChangeRemoved})
+		}
+	}
+	for _, name := range updated.names() {
+		if _, ok := old[name]; !ok {
+			diffs = append(diffs, RuleDiff{Name: name, Change: ChangeAdded})
+			continue
+		}
+		if operators := analysis.diffOperators(old[name].operator, updated[name].operator); len(operators) != 0 {
+			diffs = append(diffs, RuleDiff{Name: name, Change: ChangeModified, Operators: operators})
+		}
+	}
+
+	modified := make(map[string]bool)
+	for _, diff := range diffs {
+		modified[diff.Name] = true
+	}
+	oldRegular, newRegular := make(map[string]bool), make(map[string]bool)
+	for _, name := range old.NonRecursive() {
+		oldRegular[name] = true
+	}
+	for _, name := range updated.NonRecursive() {
+		newRegular[name] = true
+	}
+	for i, diff := range diffs {
+		if diff.Change != ChangeModified {
+			continue
+		}
+		if oldRegular[diff.Name] && newRegular[diff.Name] {
+			if effect, ok := dfaEffect(old, updated, diff.Name); ok {
+				diffs[i].Effect = effect
+				continue
+			}
+		}
+		if !referencesModified(updated, diff.Name, modified) {
+			diffs[i].Effect = combineEffects(diff.Operators)
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].Name < diffs[j].Name
+	})
+	return diffs
+}
+
+// dfaEffect decides the effect of a modification by comparing the languages of both versions of the rule.
+func dfaEffect(old, updated RuleSet, name string) (Effect, bool) {
+	oldDFA, err := old.DFA(name)
+	if err != nil {
+		return EffectUnknown, false
+	}
+	updatedDFA, err := updated.DFA(name)
+	if err != nil {
+		return EffectUnknown, false
+	}
+	_, removed := difference(oldDFA, updatedDFA)
+	_, added := difference(updatedDFA, oldDFA)
+	switch {
+	case removed && added:
+		return EffectAlters, true
+	case removed:
+		return EffectNarrows, true
+	case added:
+		return EffectWidens, true
+	default:
+		return EffectEquivalent, true
+	}
+}
+
+// referencesModified returns whether the rule with the given name refers to one of the (other) modified rules, directly
+// or indirectly. References to the rule itself do not matter, the language of the rule is monotone in its definition.
+func referencesModified(set RuleSet, name string, modified map[string]bool) bool {
+	visited := make(map[string]bool)
+	var visit func(name string) bool
+	visit = func(reference string) bool {
+		if visited[reference] {
+			return false
+		}
+		visited[reference] = true
+		rule, ok := set[reference]
+		if !ok {
+			return false
+		}
+		return walkOperators(rule.operator, func(operator Operator) error {
+			if reference, ok := operator.(RuleNameOperator); ok && reference.key != name &&
+				(modified[reference.key] || visit(reference.key)) {
+				return fmt.Errorf("modified")
+			}
+			return nil
+		}) != nil
+	}
+	return visit(name)
+}
+
+// combineEffects returns the effect of all the given differences together. All the operators are monotone, so if all
+// of them widen (or narrow) the language, so does the rule.
+func combineEffects(diffs []OperatorDiff) Effect {
+	effect := EffectEquivalent
+	for _, diff := range diffs {
+		switch {
+		case diff.Effect == EffectEquivalent:
+		case effect == EffectEquivalent:
+			effect = diff.Effect
+		case diff.Effect != effect:
+			return EffectUnknown
+		}
+	}
+	if effect == EffectAlters {
+		// can not be decided based on the operators
+		return EffectUnknown
+	}
+	return effect
+}
+
+// diffAnalysis contains the analyses of both versions of a rule set.
+type diffAnalysis struct {
+	old, updated *Analysis
+}
+
+// nullable returns whether the given operator matches the empty input in both versions of the rule set. It is not ok if
+// the versions do not agree or if the operator (indirectly) refers to an unknown rule, which is assumed to be nullable.
+func (a diffAnalysis) nullable(operator Operator) (nullable, ok bool) {
+	old, updated := a.old.NullableOperator(operator), a.updated.NullableOperator(operator)
+	return old && updated, old == updated && knownReferences(a.old.set, operator) && knownReferences(a.updated.set, operator)
+}
+
+// knownReferences returns whether all the rules the given operator (indirectly) refers to are part of the set.
+func knownReferences(set RuleSet, operator Operator) bool {
+	visited := make(map[string]bool)
+	var visit func(operator Operator) bool
+	visit = func(operator Operator) bool {
+		return walkOperators(operator, func(operator Operator) error {
+			reference, ok := operator.(RuleNameOperator)
+			if !ok || visited[reference.key] {
+				return nil
+			}
+			visited[reference.key] = true
+			if rule, ok := set[reference.key]; !ok || !visit(rule.operator) {
+				return fmt.Errorf("unknown rule: %s", reference.key)
+			}
+			return nil
+		}) == nil
+	}
+	return visit(operator)
+}
+
+// optionEffect returns the effect of adding an option around the given operator, or removing it if the effect is
+// inverted: the option does not change anything if the operator already matches the empty input.
+func (a diffAnalysis) optionEffect(operator Operator, effect Effect) Effect {
+	switch nullable, ok := a.nullable(operator); {
+	case !ok:
+		return EffectUnknown
+	case nullable:
+		return EffectEquivalent
+	default:
+		return effect
+	}
+}
+
+// diffOperators returns the differences between both operators.
+func (a diffAnalysis) diffOperators(old, updated Operator) []OperatorDiff {
+	if sameOperator(old, updated) {
+		return nil
+	}
+	switch old := old.(type) {
+	case AlternationOperator:
+		if updated, ok := updated.(AlternationOperator); ok {
+			return diffAlternatives(old, updated)
+		}
+	case ConcatenationOperator:
+		if updated, ok := updated.(ConcatenationOperator); ok && len(old.subOperators) == len(updated.subOperators) {
+			var diffs []OperatorDiff
+			for i, subOperator := range old.subOperators {
+				diffs = append(diffs, a.diffOperators(subOperator, updated.subOperators[i])...)
+			}
+			return diffs
+		}
+	case RepetitionOperator:
+		if updated, ok := updated.(RepetitionOperator); ok {
+			if sameOperator(old.subOperator, updated.subOperator) {
+				return []OperatorDiff{{Old: old.key, New: updated.key, Effect: a.repetitionEffect(old, updated)}}
+			}
+			if old.min == updated.min && old.max == updated.max {
+				return a.diffOperators(old.subOperator, updated.subOperator)
+			}
+		}
+	case OptionOperator:
+		if updated, ok := updated.(OptionOperator); ok {
+			return a.diffOperators(old.subOperator, updated.subOperator)
+		}
+		if sameOperator(old.subOperator, updated) {
+			return []OperatorDiff{{Old: old.key, New: operatorText(updated), Effect: a.optionEffect(updated, EffectNarrows)}}
+		}
+	}
+	if option, ok := updated.(OptionOperator); ok && sameOperator(old, option.subOperator) {
+		return []OperatorDiff{{Old: operatorText(old), New: option.key, Effect: a.optionEffect(old, EffectWidens)}}
+	}
+	return []OperatorDiff{{Old: operatorText(old), New: operatorText(updated)}}
+}
+
+// operatorText returns the ABNF text of the given operator, the key of a character value does not contain the quotes.
+func operatorText(operator Operator) string {
+	if value, ok := operator.(CharacterValueOperator); ok {
+		return `"` + value.value + `"`
+	}
+	return operator.Key()
+}
+
+// diffAlternatives returns the alternatives that were removed and added. Removed alternatives are not compared to the
+// added ones, their order does not tell which ones correspond.
+func diffAlternatives(old, updated AlternationOperator) []OperatorDiff {
+	var removed, added []Operator
+	for _, alternative := range old.subOperators {
+		if !containsOperator(updated.subOperators, alternative) {
+			removed = append(removed, alternative)
+		}
+	}
+	for _, alternative := range updated.subOperators {
+		if !containsOperator(old.subOperators, alternative) {
+			added = append(added, alternative)
+		}
+	}
+	if len(removed) == 0 && len(added) == 0 {
+		// reordered (or duplicated) alternatives
+		return []OperatorDiff{{Old: old.key, New: updated.key, Effect: EffectEquivalent}}
+	}
+	var diffs []OperatorDiff
+	for _, alternative := range removed {
+		diffs = append(diffs, OperatorDiff{Old: operatorText(alternative), Effect: EffectUnknown})
+	}
+	for _, alternative := range added {
+		diffs = append(diffs, OperatorDiff{New: operatorText(alternative), Effect: EffectWidens})
+	}
+	return diffs
+}
+
+// repetitionEffect returns the effect of changing the bounds of a repetition.
+func (a diffAnalysis) repetitionEffect(old, updated RepetitionOperator) Effect {
+	switch nullable, ok := a.nullable(old.subOperator); {
+	case !ok:
+		return EffectUnknown
+	case nullable:
+		// empty matches make up for the minimum, e.g. *("a" / "") and 1*("a" / "") are the same
+		old.min, updated.min = 0, 0
+	}
+	// -1 is infinite
+	includes := func(a, b RepetitionOperator) bool {
+		return a.min <= b.min && (a.max < 0 || 0 <= b.max && b.max <= a.max)
+	}
+	switch {
+	case includes(updated, old) && includes(old, updated):
+		return EffectEquivalent
+	case includes(updated, old):
+		return EffectWidens
+	case includes(old, updated):
+		return EffectNarrows
+	default:
+		return EffectUnknown
+	}
+}
+
+func containsOperator(operators []Operator, operator Operator) bool {
+	for _, o := range operators {
+		if sameOperator(o, operator) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameOperator returns whether both operators are the same, ignoring the formatting of their ABNF text.
+func sameOperator(a, b Operator) bool {
+	switch a := a.(type) {
+	case AlternationOperator:
+		b, ok := b.(AlternationOperator)
+		return ok && sameOperators(a.subOperators, b.subOperators)
+	case ConcatenationOperator:
+		b, ok := b.(ConcatenationOperator)
+		return ok && sameOperators(a.subOperators, b.subOperators)
+	case RepetitionOperator:
+		b, ok := b.(RepetitionOperator)
+		return ok && a.min == b.min && a.max == b.max && sameOperator(a.subOperator, b.subOperator)
+	case RuleNameOperator:
+		b, ok := b.(RuleNameOperator)
+		return ok && a.key == b.key
+	case OptionOperator:
+		b, ok := b.(OptionOperator)
+		return ok && sameOperator(a.subOperator, b.subOperator)
+	case CharacterValueOperator:
+		b, ok := b.(CharacterValueOperator)
+		return ok && a.value == b.value
+	case NumericValueOperator:
+		b, ok := b.(NumericValueOperator)
+		return ok && a.hyphen == b.hyphen && a.points == b.points && reflect.DeepEqual(a.toIntegers(), b.toIntegers())
+	default:
+		return false
+	}
+}
+
+func sameOperators(a, b []Operator) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !sameOperator(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
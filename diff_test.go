@@ -0,0 +1,117 @@
+package abnf
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	old := NewRuleSet([]byte(strings.Join([]string{
+		`removed = "x"`,
+		`format = "a"/%x42`,
+		`reorder = "a" / "b"`,
+		`alternatives = "a" / "b" / "c"`,
+		`bounds = 1*3"a"`,
+		`regular = "a" ["b"]`,
+		`altered = "a" / "b"`,
+		`option = "a" "b"`,
+		`list = "x" / "x" "," list`,
+		`list-element = "x"`,
+		`item = "x"`,
+		`uses-item = item "y" / uses-item "z"`,
+		`shrink = "a" / "b" / "c" shrink`,
+		`swap = "a" / "b" / "c" swap`,
+		`nullable-option = "a" [*"b"] [nullable-option]`,
+		`nullable-repetition = "a" *("b" / "") [nullable-repetition]`,
+		`unknown-option = "a" [unknown] [unknown-option]`,
+	}, "\n") + "\n"))
+	updated := NewRuleSet([]byte(strings.Join([]string{
+		`added = "x"`,
+		`format = "a" / %d66`,
+		`reorder = "b" / "a"`,
+		`alternatives = "a" / "b"`,
+		`bounds = *"a"`,
+		`regular = "a" / "a" "b" / "c"`,
+		`altered = "a" / "c"`,
+		`option = "a" ["b"]`,
+		`list = "x" / "x" "," list / ""`,
+		`list-element = "x" / "y"`,
+		`item = "x" / "z"`,
+		`uses-item = item "y" / uses-item "z" / "w"`,
+		`shrink = "a" / "c" shrink`,
+		`swap = "a" / "d" / "c" swap`,
+		`nullable-option = "a" *"b" [nullable-option]`,
+		`nullable-repetition = "a" 1*("b" / "") [nullable-repetition]`,
+		`unknown-option = "a" unknown [unknown-option]`,
+	}, "\n") + "\n"))
+
+	expected := []RuleDiff{
+		{Name: "added", Change: ChangeAdded},
+		{Name: "altered", Change: ChangeModified, Effect: EffectAlters, Operators: []OperatorDiff{
+			{Old: `"b"`, Effect: EffectUnknown},
+			{New: `"c"`, Effect: EffectWidens},
+		}},
+		{Name: "alternatives", Change: ChangeModified, Effect: EffectNarrows, Operators: []OperatorDiff{
+			{Old: `"c"`, Effect: EffectUnknown},
+		}},
+		{Name: "bounds", Change: ChangeModified, Effect: EffectWidens, Operators: []OperatorDiff{
+			{Old: `1*3"a"`, New: `*"a"`, Effect: EffectWidens},
+		}},
+		{Name: "item", Change: ChangeModified, Effect: EffectWidens, Operators: []OperatorDiff{
+			{Old: `"x"`, New: `"x" / "z"`},
+		}},
+		{Name: "list", Change: ChangeModified, Effect: EffectWidens, Operators: []OperatorDiff{
+			{New: `""`, Effect: EffectWidens},
+		}},
+		{Name: "list-element", Change: ChangeModified, Effect: EffectWidens, Operators: []OperatorDiff{
+			{Old: `"x"`, New: `"x" / "y"`},
+		}},
+		// the options and the minimum do not matter if the operator can already match the empty input
+		{Name: "nullable-option", Change: ChangeModified, Effect: EffectEquivalent, Operators: []OperatorDiff{
+			{Old: `[*"b"]`, New: `*"b"`, Effect: EffectEquivalent},
+		}},
+		{Name: "nullable-repetition", Change: ChangeModified, Effect: EffectEquivalent, Operators: []OperatorDiff{
+			{Old: `*("b" / "")`, New: `1*("b" / "")`, Effect: EffectEquivalent},
+		}},
+		{Name: "option", Change: ChangeModified, Effect: EffectWidens, Operators: []OperatorDiff{
+			{Old: `"b"`, New: `["b"]`, Effect: EffectWidens},
+		}},
+		{Name: "regular", Change: ChangeModified, Effect: EffectWidens, Operators: []OperatorDiff{
+			{Old: `"a" ["b"]`, New: `"a" / "a" "b" / "c"`},
+		}},
+		{Name: "removed", Change: ChangeRemoved},
+		{Name: "reorder", Change: ChangeModified, Effect: EffectEquivalent, Operators: []OperatorDiff{
+			{Old: `"a" / "b"`, New: `"b" / "a"`, Effect: EffectEquivalent},
+		}},
+		// "b" might be covered by another alternative
+		{Name: "shrink", Change: ChangeModified, Effect: EffectUnknown, Operators: []OperatorDiff{
+			{Old: `"b"`, Effect: EffectUnknown},
+		}},
+		{Name: "swap", Change: ChangeModified, Effect: EffectUnknown, Operators: []OperatorDiff{
+			{Old: `"b"`, Effect: EffectUnknown},
+			{New: `"d"`, Effect: EffectWidens},
+		}},
+		// the referenced item rule changed, so the effect can not be derived from the operators
+		// unknown rules might match the empty input
+		{Name: "unknown-option", Change: ChangeModified, Effect: EffectUnknown, Operators: []OperatorDiff{
+			{Old: `[unknown]`, New: `unknown`, Effect: EffectUnknown},
+		}},
+		{Name: "uses-item", Change: ChangeModified, Effect: EffectUnknown, Operators: []OperatorDiff{
+			{New: `"w"`, Effect: EffectWidens},
+		}},
+	}
+	diffs := Diff(old, updated)
+	if len(diffs) != len(expected) {
+		t.Fatalf("expected %d differences, got %d: %v", len(expected), len(diffs), diffs)
+	}
+	for i, diff := range diffs {
+		if !reflect.DeepEqual(diff, expected[i]) {
+			t.Errorf("expected %v, got %v", expected[i], diff)
+		}
+	}
+
+	if diffs := Diff(old, old); len(diffs) != 0 {
+		t.Errorf("expected no differences, got %v", diffs)
+	}
+}
@@ -0,0 +1,566 @@
+package abnf
+
+import (
+	"fmt"
+
+	"github.com/elimity-com/abnf/operators"
+)
+
+// EarleyParser parses the rules of a rule set with the Earley algorithm. Unlike the operators, it supports all ABNF
+// (including left recursive and ambiguous rules) and it runs in polynomial time. The result is a shared packed parse
+// forest, which can be converted to the parse trees of the operators package.
+type EarleyParser struct {
+	symbols     []earleySymbol
+	productions []earleyProduction
+	nullable    []bool
+	rules       map[string]int
+}
+
+// earleySymbol is either a rule, an (anonymous) operator, an intermediate symbol of a repetition or a terminal.
+type earleySymbol struct {
+	key     string
+	kind    operators.Kind
+	element operators.Element
+	// intermediate symbols only exist to express repetitions, their children get flattened into the repetition
+	intermediate bool
+	// leaf rules (e.g. "a = %x61") do not have any children, like the nodes of operators.Rule
+	leaf bool
+	// terminal matches the input directly, terminals do not have any productions
+	terminal    operators.Operator
+	productions []int
+}
+
+type earleyProduction struct {
+	symbol  int
+	symbols []int
+}
+
+// NewEarleyParser creates an Earley parser for the given rule set. All referenced rules need to be part of the set
+// (e.g. add the CoreRules).
+func NewEarleyParser(set RuleSet) (*EarleyParser, error) {
+	p := &EarleyParser{
+		rules: make(map[string]int),
+	}
+	for _, name := range set.names() {
+		if _, err := p.rule(set, name); err != nil {
+			return nil, fmt.Errorf("%s: %s", name, err)
+		}
+	}
+	p.initNullable()
+	return p, nil
+}
+
+// rule returns the symbol of the rule with the given name.
+func (p *EarleyParser) rule(set RuleSet, name string) (int, error) {
+	if symbol, ok := p.rules[name]; ok {
+		return symbol, nil
+	}
+	rule, ok := set[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown rule: %s", name)
+	}
+	// the symbol gets registered before its productions, so (mutually) recursive rules refer to the same symbol
+	element := operators.Element{Rule: name}
+	symbol := p.symbol(earleySymbol{
+		key:     name,
+		kind:    operators.KindRule,
+		element: element,
+	})
+	p.rules[name] = symbol
+	return symbol, p.define(set, symbol, rule.operator, element)
+}
+
+// operator returns a (new) symbol for the given operator, which is the given element.
+func (p *EarleyParser) operator(set RuleSet, operator Operator, element operators.Element) (int, error) {
+	switch operator := operator.(type) {
+	case RuleNameOperator:
+		return p.rule(set, operator.key)
+	case CharacterValueOperator, NumericValueOperator:
+		return p.symbol(earleySymbol{
+			key:      operator.Key(),
+			kind:     operators.KindTerminal,
+			element:  element,
+			terminal: operator.toFunc(nil, element),
+		}), nil
+	}
+	symbol := p.symbol(earleySymbol{
+		key:     operator.Key(),
+		kind:    operatorKind(operator),
+		element: element,
+	})
+	return symbol, p.define(set, symbol, operator, element)
+}
+
+// define adds the productions of the given operator, which is the given element, to the given symbol.
+func (p *EarleyParser) define(set RuleSet, symbol int, operator Operator, element operators.Element) error {
+	elements := subElements(operator, element)
+	switch operator := operator.(type) {
+	case AlternationOperator:
+		for i, subOperator := range operator.subOperators {
+			sub, err := p.operator(set, subOperator, elements[i])
+			if err != nil {
+				return err
+			}
+			p.production(symbol, sub)
+		}
+	case ConcatenationOperator:
+		var symbols []int
+		for i, subOperator := range operator.subOperators {
+			sub, err := p.operator(set, subOperator, elements[i])
+			if err != nil {
+				return err
+			}
+			symbols = append(symbols, sub)
+		}
+		p.production(symbol, symbols...)
+	case RepetitionOperator:
+		if 0 <= operator.max && operator.max < operator.min {
+			// matches nothing
+			return nil
+		}
+		sub, err := p.operator(set, operator.subOperator, elements[0])
+		if err != nil {
+			return err
+		}
+		var symbols []int
+		for i := 0; i < operator.min; i++ {
+			symbols = append(symbols, sub)
+		}
+		if operator.max < 0 {
+			// left recursive, which is the most efficient for an Earley parser
+			rest := p.intermediate(operator.key)
+			p.production(rest)
+			p.production(rest, rest, sub)
+			symbols = append(symbols, rest)
+		} else if operator.min < operator.max {
+			var rest int
+			for i := operator.min; i < operator.max; i++ {
+				next := p.intermediate(operator.key)
+				p.production(next)
+				if i == operator.min {
+					p.production(next, sub)
+				} else {
+					p.production(next, sub, rest)
+				}
+				rest = next
+			}
+			symbols = append(symbols, rest)
+		}
+		p.production(symbol, symbols...)
+	case OptionOperator:
+		sub, err := p.operator(set, operator.subOperator, elements[0])
+		if err != nil {
+			return err
+		}
+		p.production(symbol)
+		p.production(symbol, sub)
+	case RuleNameOperator:
+		sub, err := p.rule(set, operator.key)
+		if err != nil {
+			return err
+		}
+		p.production(symbol, sub)
+	case CharacterValueOperator, NumericValueOperator:
+		// the terminal is left out of the tree, see leaf
+		sub, err := p.operator(set, operator, element)
+		if err != nil {
+			return err
+		}
+		p.symbols[symbol].leaf = true
+		p.production(symbol, sub)
+	default:
+		return fmt.Errorf("unsupported operator: %s", operator.Key())
+	}
+	return nil
+}
+
+func (p *EarleyParser) symbol(symbol earleySymbol) int {
+	p.symbols = append(p.symbols, symbol)
+	return len(p.symbols) - 1
+}
+
+func (p *EarleyParser) intermediate(key string) int {
+	return p.symbol(earleySymbol{
+		key:          key,
+		kind:         operators.KindRepetition,
+		intermediate: true,
+	})
+}
+
+func (p *EarleyParser) production(symbol int, symbols ...int) {
+	p.productions = append(p.productions, earleyProduction{
+		symbol:  symbol,
+		symbols: symbols,
+	})
+	p.symbols[symbol].productions = append(p.symbols[symbol].productions, len(p.productions)-1)
+}
+
+// initNullable determines which symbols can match the empty input.
+func (p *EarleyParser) initNullable() {
+	p.nullable = make([]bool, len(p.symbols))
+	for i, symbol := range p.symbols {
+		if symbol.terminal != nil {
+			p.nullable[i] = len(symbol.terminal(nil)) != 0
+		}
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, production := range p.productions {
+			if p.nullable[production.symbol] {
+				continue
+			}
+			nullable := true
+			for _, symbol := range production.symbols {
+				nullable = nullable && p.nullable[symbol]
+			}
+			if nullable {
+				p.nullable[production.symbol] = true
+				changed = true
+			}
+		}
+	}
+}
+
+// Parse parses the whole input with the given rule and returns the root of the parse forest.
+func (p *EarleyParser) Parse(rule string, s []byte) (*ForestNode, error) {
+	symbol, ok := p.rules[rule]
+	if !ok {
+		return nil, fmt.Errorf("unknown rule: %s", rule)
+	}
+	c := p.parse(symbol, s)
+	if !c.spans(symbol, 0, len(s)) {
+		return nil, fmt.Errorf("no match for %s, unexpected input at offset %d", rule, c.furthest())
+	}
+	return c.node(symbol, 0, len(s)), nil
+}
+
+// Operator returns an operator for the given rule that uses the Earley parser. Like the other operators, it returns the
+// parse trees of all the prefixes of the input that match the rule, longest first.
+func (p *EarleyParser) Operator(rule string) (operators.Operator, error) {
+	symbol, ok := p.rules[rule]
+	if !ok {
+		return nil, fmt.Errorf("unknown rule: %s", rule)
+	}
+	return func(s []byte) operators.Alternatives {
+		c := p.parse(symbol, s)
+		var nodes operators.Alternatives
+		for end := len(s); 0 <= end; end-- {
+			if c.spans(symbol, 0, end) {
+				nodes = append(nodes, c.node(symbol, 0, end).Trees()...)
+			}
+		}
+		return nodes
+	}, nil
+}
+
+type earleyItem struct {
+	production, dot, origin int
+}
+
+type earleySet struct {
+	items []earleyItem
+	index map[earleyItem]struct{}
+	// waiting contains the items that expect the (non terminal) symbol
+	waiting map[int][]earleyItem
+}
+
+func (set *earleySet) contains(item earleyItem) bool {
+	_, ok := set.index[item]
+	return ok
+}
+
+// earleyChart contains the Earley sets of an input, it is used to build the parse forest.
+type earleyChart struct {
+	parser *EarleyParser
+	input  []byte
+	sets   []*earleySet
+	// leaves contains the length of the matches of the terminals by symbol and offset, -1 if there is no match
+	leaves map[[2]int]int
+	nodes  map[[3]int]*ForestNode
+	splits map[[4]int][][]*ForestNode
+}
+
+func (p *EarleyParser) parse(symbol int, s []byte) *earleyChart {
+	c := &earleyChart{
+		parser: p,
+		input:  s,
+		sets:   make([]*earleySet, len(s)+1),
+		leaves: make(map[[2]int]int),
+		nodes:  make(map[[3]int]*ForestNode),
+		splits: make(map[[4]int][][]*ForestNode),
+	}
+	for i := range c.sets {
+		c.sets[i] = &earleySet{
+			index:   make(map[earleyItem]struct{}),
+			waiting: make(map[int][]earleyItem),
+		}
+	}
+	for _, production := range p.symbols[symbol].productions {
+		c.add(0, earleyItem{production: production})
+	}
+
+	for i, set := range c.sets {
+		// new items get added while iterating
+		for j := 0; j < len(set.items); j++ {
+			item := set.items[j]
+			production := p.productions[item.production]
+			next := item
+			next.dot++
+
+			// completion
+			if item.dot == len(production.symbols) {
+				for _, waiting := range c.sets[item.origin].waiting[production.symbol] {
+					waiting.dot++
+					c.add(i, waiting)
+				}
+				continue
+			}
+
+			symbol := production.symbols[item.dot]
+			// scanning
+			if p.symbols[symbol].terminal != nil {
+				if l := c.leaf(symbol, i); 0 <= l {
+					c.add(i+l, next)
+				}
+				continue
+			}
+			// prediction, nullable symbols get completed immediately (Aycock and Horspool)
+			for _, production := range p.symbols[symbol].productions {
+				c.add(i, earleyItem{production: production, origin: i})
+			}
+			if p.nullable[symbol] {
+				c.add(i, next)
+			}
+		}
+	}
+	return c
+}
+
+func (c *earleyChart) add(i int, item earleyItem) {
+	set := c.sets[i]
+	if set.contains(item) {
+		return
+	}
+	set.index[item] = struct{}{}
+	set.items = append(set.items, item)
+	symbols := c.parser.productions[item.production].symbols
+	if item.dot < len(symbols) {
+		set.waiting[symbols[item.dot]] = append(set.waiting[symbols[item.dot]], item)
+	}
+}
+
+// leaf returns the length of the match of the given terminal at the given offset, -1 if it does not match.
+func (c *earleyChart) leaf(symbol, offset int) int {
+	key := [2]int{symbol, offset}
+	if l, ok := c.leaves[key]; ok {
+		return l
+	}
+	l := -1
+	if nodes := c.parser.symbols[symbol].terminal(c.input[offset:]); len(nodes) != 0 {
+		l = len(nodes[0].Value)
+	}
+	c.leaves[key] = l
+	return l
+}
+
+// furthest returns the offset of the last set that contains items.
+func (c *earleyChart) furthest() int {
+	for i := len(c.sets) - 1; 0 < i; i-- {
+		if len(c.sets[i].items) != 0 {
+			return i
+		}
+	}
+	return 0
+}
+
+// spans returns whether the given symbol matches the input from start to end.
+func (c *earleyChart) spans(symbol, start, end int) bool {
+	s := c.parser.symbols[symbol]
+	if s.terminal != nil {
+		return c.leaf(symbol, start) == end-start
+	}
+	for _, production := range s.productions {
+		item := earleyItem{
+			production: production,
+			dot:        len(c.parser.productions[production].symbols),
+			origin:     start,
+		}
+		if c.sets[end].contains(item) {
+			return true
+		}
+	}
+	return false
+}
+
+// node returns the (shared) forest node of the given symbol from start to end.
+func (c *earleyChart) node(symbol, start, end int) *ForestNode {
+	key := [3]int{symbol, start, end}
+	if n, ok := c.nodes[key]; ok {
+		return n
+	}
+	s := c.parser.symbols[symbol]
+	n := &ForestNode{
+		Key:          s.key,
+		Kind:         s.kind,
+		Start:        start,
+		End:          end,
+		Intermediate: s.intermediate,
+		chart:        c,
+		symbol:       symbol,
+	}
+	c.nodes[key] = n
+	return n
+}
+
+// split returns all the ways the first symbols (up to dot) of the given production can match the input from start to
+// end, as sequences of forest nodes.
+func (c *earleyChart) split(production, dot, start, end int) [][]*ForestNode {
+	if dot == 0 {
+		if start == end {
+			return [][]*ForestNode{{}}
+		}
+		return nil
+	}
+	key := [4]int{production, dot, start, end}
+	if splits, ok := c.splits[key]; ok {
+		return splits
+	}
+	var splits [][]*ForestNode
+	symbol := c.parser.productions[production].symbols[dot-1]
+	for mid := start; mid <= end; mid++ {
+		if !c.sets[mid].contains(earleyItem{production: production, dot: dot - 1, origin: start}) ||
+			!c.spans(symbol, mid, end) {
+			continue
+		}
+		node := c.node(symbol, mid, end)
+		for _, prefix := range c.split(production, dot-1, start, mid) {
+			splits = append(splits, append(append([]*ForestNode{}, prefix...), node))
+		}
+	}
+	c.splits[key] = splits
+	return splits
+}
+
+// ForestNode is a node of a shared packed parse forest. Nodes with the same key, kind, start and end are shared between
+// all the trees of the forest.
+type ForestNode struct {
+	Key  string
+	Kind operators.Kind
+	// Start and End are the offsets of the match within the input.
+	Start, End int
+	// Intermediate nodes are part of a repetition, their children get flattened into the repetition node.
+	Intermediate bool
+
+	chart        *earleyChart
+	symbol       int
+	alternatives [][]*ForestNode
+	expanded     bool
+}
+
+// Value returns the part of the input matched by the node.
+func (n *ForestNode) Value() []byte {
+	return n.chart.input[n.Start:n.End]
+}
+
+// Alternatives returns the packed alternatives of the node, each alternative is a sequence of children that matches
+// the value of the node. Terminal nodes do not have any alternatives.
+func (n *ForestNode) Alternatives() [][]*ForestNode {
+	if n.expanded {
+		return n.alternatives
+	}
+	n.expanded = true
+	for _, production := range n.chart.parser.symbols[n.symbol].productions {
+		dot := len(n.chart.parser.productions[production].symbols)
+		if !n.chart.sets[n.End].contains(earleyItem{production: production, dot: dot, origin: n.Start}) {
+			continue
+		}
+		n.alternatives = append(n.alternatives, n.chart.split(production, dot, n.Start, n.End)...)
+	}
+	return n.alternatives
+}
+
+// Ambiguous returns whether the node has more than one alternative.
+func (n *ForestNode) Ambiguous() bool {
+	return 1 < len(n.Alternatives())
+}
+
+// Tree returns the first parse tree of the forest, as created by the operators.
+func (n *ForestNode) Tree() *operators.Node {
+	sequences := n.sequences(make(map[*ForestNode]bool), 1)
+	if len(sequences) == 0 || len(sequences[0]) != 1 {
+		return nil
+	}
+	return sequences[0][0]
+}
+
+// Trees returns all the parse trees of the forest, as created by the operators. The amount of trees can grow
+// exponentially with the length of the input. Cyclic derivations (e.g. "a = a / "x"") are left out.
+func (n *ForestNode) Trees() operators.Alternatives {
+	var nodes operators.Alternatives
+	for _, sequence := range n.sequences(make(map[*ForestNode]bool), -1) {
+		nodes = append(nodes, sequence...)
+	}
+	return nodes
+}
+
+// sequences returns the sequences of nodes that the forest node adds to the children of its parent: one node per tree,
+// or the flattened children if the node is intermediate. At most limit sequences are returned, unless it is negative.
+func (n *ForestNode) sequences(visiting map[*ForestNode]bool, limit int) [][]*operators.Node {
+	s := n.chart.parser.symbols[n.symbol]
+	if s.terminal != nil {
+		return [][]*operators.Node{{n.tree(nil)}}
+	}
+	if visiting[n] {
+		return nil
+	}
+	visiting[n] = true
+	defer delete(visiting, n)
+
+	var sequences [][]*operators.Node
+	for _, alternative := range n.Alternatives() {
+		if 0 <= limit && limit <= len(sequences) {
+			break
+		}
+		childrenList := [][]*operators.Node{{}}
+		for _, child := range alternative {
+			childSequences := child.sequences(visiting, limit)
+			var next [][]*operators.Node
+			for _, children := range childrenList {
+				for _, sequence := range childSequences {
+					if 0 <= limit && limit <= len(next) {
+						break
+					}
+					next = append(next, append(append([]*operators.Node{}, children...), sequence...))
+				}
+			}
+			childrenList = next
+		}
+		for _, children := range childrenList {
+			if 0 <= limit && limit <= len(sequences) {
+				break
+			}
+			if n.Intermediate {
+				sequences = append(sequences, children)
+				continue
+			}
+			if s.leaf {
+				children = nil
+			}
+			sequences = append(sequences, []*operators.Node{n.tree(children)})
+		}
+	}
+	return sequences
+}
+
+func (n *ForestNode) tree(children []*operators.Node) *operators.Node {
+	if len(children) == 0 {
+		children = nil
+	}
+	return &operators.Node{
+		Key:      n.Key,
+		Kind:     n.Kind,
+		Element:  n.chart.parser.symbols[n.symbol].element,
+		Value:    n.Value(),
+		Children: children,
+	}
+}
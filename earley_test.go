@@ -0,0 +1,165 @@
+package abnf
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/elimity-com/abnf/operators"
+)
+
+func TestEarleyParser(t *testing.T) {
+	set := CoreRules(false)
+	for name, rule := range NewRuleSet([]byte("expr = expr \"+\" term / term\nterm = 1*DIGIT\n")) {
+		set[name] = rule
+	}
+	p, err := NewEarleyParser(set)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	root, err := p.Parse("expr", []byte("1+23+4"))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	trees := root.Trees()
+	if len(trees) != 1 {
+		t.Errorf("expected one tree, got %d", len(trees))
+		return
+	}
+	// left recursive, so the last term is the outer one
+	if expr := trees[0].GetSubRule("expr"); expr == nil || expr.String() != "1+23" {
+		t.Errorf("invalid sub expression: %v", expr)
+	}
+	if terms := trees[0].GetSubRules("term"); len(terms) != 3 {
+		t.Errorf("expected three terms, got %d", len(terms))
+	}
+
+	if _, err := p.Parse("expr", []byte("1+2+")); err == nil || !strings.Contains(err.Error(), "offset 4") {
+		t.Errorf("expected an error at offset 4, got %v", err)
+	}
+	if _, err := p.Parse("unknown", nil); err == nil {
+		t.Error("expected an error for an unknown rule")
+	}
+	if _, err := NewEarleyParser(NewRuleSet([]byte("a = b\n"))); err == nil {
+		t.Error("expected an error for an unknown rule reference")
+	}
+}
+
+func TestEarleyParserAmbiguous(t *testing.T) {
+	p, err := NewEarleyParser(NewRuleSet([]byte("e = e \"+\" e / \"1\"\n")))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	root, err := p.Parse("e", []byte("1+1+1"))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	// the root is the rule node, its only child is the concatenation "e "+" e"
+	concat := root.Alternatives()[0][0]
+	if !concat.Ambiguous() {
+		t.Error("expected an ambiguous concatenation")
+	}
+	if l := len(root.Trees()); l != 2 {
+		t.Errorf("expected two trees, got %d", l)
+	}
+
+	// the amount of trees is a Catalan number, but the forest stays polynomial
+	input := "1" + strings.Repeat("+1", 40)
+	if root, err = p.Parse("e", []byte(input)); err != nil {
+		t.Error(err)
+		return
+	}
+	if l := len(root.Alternatives()[0][0].Alternatives()); l != 40 {
+		t.Errorf("expected forty alternatives, got %d", l)
+	}
+	if tree := root.Tree(); tree == nil || tree.String() != input {
+		t.Errorf("invalid tree: %v", tree)
+	}
+}
+
+func TestEarleyParserCyclic(t *testing.T) {
+	p, err := NewEarleyParser(NewRuleSet([]byte("a = a / \"x\"\nb = *[\"y\"]\n")))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for _, test := range []struct {
+		rule, input string
+	}{
+		{"a", "x"},
+		{"b", "yy"},
+	} {
+		root, err := p.Parse(test.rule, []byte(test.input))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if trees := root.Trees(); len(trees) == 0 {
+			t.Errorf("expected trees for %s", test.rule)
+		}
+	}
+}
+
+// TestEarleyParserOperators compares the trees of the Earley parser with the ones of the parser generator.
+func TestEarleyParserOperators(t *testing.T) {
+	set := CoreRules(false)
+	for name, rule := range NewRuleSet([]byte(strings.Join([]string{
+		"list = *item",
+		"item = \"a\" / \"aa\" / %x62-7A",
+		"word = 1*ALPHA [\"-\" 2*3DIGIT] ; comment",
+		"alias = word",
+		"leaf = %x61.62",
+		"opt = [\"a\"] [\"a\"] \"a\"",
+	}, "\n") + "\n")) {
+		set[name] = rule
+	}
+	p, err := NewEarleyParser(set)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	functions := new(ParserGenerator).generate(set)
+
+	for _, test := range []struct {
+		rule, input string
+	}{
+		{"list", "aaba"},
+		{"list", ""},
+		{"word", "abc-123"},
+		{"word", "abc-1"},
+		{"alias", "ab-12"},
+		{"leaf", "abc"},
+		{"opt", "aa"},
+		{"HEXDIG", "f"},
+		{"LWSP", " \r\n\t"},
+	} {
+		operator, err := p.Operator(test.rule)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		expected := encodeTrees(functions[test.rule]([]byte(test.input)))
+		actual := encodeTrees(operator([]byte(test.input)))
+		if expected != actual {
+			t.Errorf("%s %q: expected\n%s\ngot\n%s", test.rule, test.input, expected, actual)
+		}
+	}
+}
+
+// encodeTrees returns the (sorted) JSON encodings of the given trees.
+func encodeTrees(nodes operators.Alternatives) string {
+	var trees []string
+	for _, node := range nodes {
+		raw, _ := json.Marshal(node)
+		trees = append(trees, string(raw))
+	}
+	sort.Strings(trees)
+	return strings.Join(trees, "\n")
+}
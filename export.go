@@ -0,0 +1,391 @@
+package abnf
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Notation of a grammar.
+type Notation int
+
+const (
+	// ISOEBNF is the Extended BNF of ISO/IEC 14977.
+	ISOEBNF Notation = iota
+	// W3CEBNF is the EBNF notation used by the W3C, e.g. in the XML specification.
+	W3CEBNF
+	// ANTLR is an ANTLR 4 lexer grammar.
+	ANTLR
+	// PEG is the notation of parsing expression grammars (Ford).
+	PEG
+)
+
+var notationNames = [...]string{
+	ISOEBNF: "ISO EBNF",
+	W3CEBNF: "W3C EBNF",
+	ANTLR:   "ANTLR",
+	PEG:     "PEG",
+}
+
+func (n Notation) String() string {
+	if n < 0 || int(n) >= len(notationNames) {
+		return fmt.Sprintf("notation(%d)", int(n))
+	}
+	return notationNames[n]
+}
+
+// Exporter converts rule sets to other grammar notations.
+type Exporter struct {
+	Notation Notation
+	// Name of the grammar, required by ANTLR.
+	Name string
+	// CaseInsensitive exports character values case insensitively, as defined by RFC 5234. By default they are exported
+	// case sensitively, like they are matched by the operators.
+	CaseInsensitive bool
+}
+
+// Export writes the rules of the given set in the notation of the exporter. It returns warnings for the constructs that
+// can not be expressed faithfully in that notation.
+func (e Exporter) Export(w io.Writer, set RuleSet) ([]string, error) {
+	x := &exporter{
+		Exporter: e,
+		set:      set,
+		names:    make(map[string]string),
+		seen:     make(map[string]bool),
+	}
+	if e.Notation < ISOEBNF || PEG < e.Notation {
+		return nil, fmt.Errorf("unknown notation: %s", e.Notation)
+	}
+	if e.Notation == ANTLR {
+		if e.Name == "" {
+			return nil, fmt.Errorf("ANTLR grammars need a name")
+		}
+		x.wf("lexer grammar %s;\n\n", e.Name)
+	}
+
+	// names get converted before the rules, so collisions are reported once
+	converted := make(map[string]string)
+	for _, name := range set.names() {
+		n := x.name(name)
+		if other, ok := converted[n]; ok {
+			x.warn("rules %s and %s are both exported as %s", other, name, n)
+		}
+		converted[n] = name
+	}
+
+	for _, name := range set.names() {
+		expression, _ := x.operator(set[name].operator)
+		switch e.Notation {
+		case ISOEBNF:
+			x.wf("%s = %s ;\n", x.name(name), expression)
+		case W3CEBNF:
+			x.wf("%s ::= %s\n", x.name(name), expression)
+		case ANTLR:
+			x.wf("%s : %s ;\n", x.name(name), expression)
+		case PEG:
+			x.wf("%s <- %s\n", x.name(name), expression)
+		}
+	}
+	if e.Notation == PEG && x.choices {
+		x.warn("choices are ordered and repetitions are greedy in PEG, which may reject inputs that match the ABNF rules")
+	}
+
+	if _, err := io.WriteString(w, x.b.String()); err != nil {
+		return nil, err
+	}
+	return x.warnings, nil
+}
+
+// exporter contains the state of a single export.
+type exporter struct {
+	Exporter
+	set      RuleSet
+	b        strings.Builder
+	names    map[string]string
+	warnings []string
+	seen     map[string]bool
+	// choices is true if the grammar contains alternations or repetitions
+	choices bool
+}
+
+func (x *exporter) wf(format string, args ...interface{}) {
+	fmt.Fprintf(&x.b, format, args...)
+}
+
+// warn adds a warning, if it was not reported before.
+func (x *exporter) warn(format string, args ...interface{}) {
+	warning := fmt.Sprintf(format, args...)
+	if x.seen[warning] {
+		return
+	}
+	x.seen[warning] = true
+	x.warnings = append(x.warnings, warning)
+}
+
+// name converts the name of a rule to an identifier of the notation.
+func (x *exporter) name(name string) string {
+	if converted, ok := x.names[name]; ok {
+		return converted
+	}
+	converted := name
+	switch x.Notation {
+	case ISOEBNF, PEG:
+		converted = strings.Replace(name, "-", "_", -1)
+	case ANTLR:
+		// lexer rules start with an uppercase letter
+		converted = strings.ToUpper(strings.Replace(name, "-", "_", -1))
+	}
+	x.names[name] = converted
+	return converted
+}
+
+// operator returns the expression of the given operator, and its precedence (same as the regular expressions).
+func (x *exporter) operator(operator Operator) (string, int) {
+	switch operator := operator.(type) {
+	case AlternationOperator:
+		x.choices = true
+		var alternatives []string
+		for _, subOperator := range operator.subOperators {
+			alternative, _ := x.operator(subOperator)
+			alternatives = append(alternatives, alternative)
+		}
+		separator := " | "
+		if x.Notation == PEG {
+			separator = " / "
+		}
+		return strings.Join(alternatives, separator), regexpAlternation
+	case ConcatenationOperator:
+		var elements []string
+		for _, subOperator := range operator.subOperators {
+			elements = append(elements, x.group(subOperator, regexpConcatenation))
+		}
+		return x.concat(elements), regexpConcatenation
+	case RepetitionOperator:
+		x.choices = true
+		return x.repetition(operator.subOperator, operator.min, operator.max)
+	case RuleNameOperator:
+		if _, ok := x.set[operator.key]; !ok {
+			x.warn("undefined rule: %s", operator.key)
+		}
+		return x.name(operator.key), regexpAtom
+	case OptionOperator:
+		x.choices = true
+		if x.Notation == ISOEBNF {
+			expression, _ := x.operator(operator.subOperator)
+			return "[" + expression + "]", regexpAtom
+		}
+		return x.group(operator.subOperator, regexpAtom) + "?", regexpAtom
+	case CharacterValueOperator:
+		return x.characterValue(operator.value)
+	case NumericValueOperator:
+		return x.numericValue(operator)
+	default:
+		x.warn("unsupported operator: %s", operator.Key())
+		return "", regexpAtom
+	}
+}
+
+// group returns the expression of the given operator, grouped if its precedence is lower than the given one.
+func (x *exporter) group(operator Operator, precedence int) string {
+	expression, p := x.operator(operator)
+	if p < precedence {
+		return "(" + expression + ")"
+	}
+	return expression
+}
+
+func (x *exporter) concat(elements []string) string {
+	if x.Notation == ISOEBNF {
+		return strings.Join(elements, ", ")
+	}
+	return strings.Join(elements, " ")
+}
+
+// repetition returns the expression of a repetition. Bounds that can not be expressed get expanded.
+func (x *exporter) repetition(operator Operator, min, max int) (string, int) {
+	if x.Notation == ISOEBNF {
+		expression, _ := x.operator(operator)
+		var elements []string
+		if 0 < min {
+			elements = append(elements, fmt.Sprintf("%d * %s", min, x.group(operator, regexpAtom)))
+		}
+		switch {
+		case max < 0:
+			elements = append(elements, "{"+expression+"}")
+		case min < max:
+			elements = append(elements, fmt.Sprintf("%d * [%s]", max-min, expression))
+		}
+		if len(elements) == 0 {
+			// matches the empty string
+			return "[]", regexpAtom
+		}
+		if len(elements) == 1 && max < 0 {
+			return elements[0], regexpAtom
+		}
+		return x.concat(elements), regexpConcatenation
+	}
+
+	element := x.group(operator, regexpAtom)
+	switch {
+	case min == 0 && max < 0:
+		return element + "*", regexpAtom
+	case min == 1 && max < 0:
+		return element + "+", regexpAtom
+	case min == 0 && max == 1:
+		return element + "?", regexpAtom
+	}
+	var elements []string
+	for i := 0; i < min; i++ {
+		elements = append(elements, element)
+	}
+	if max < 0 {
+		elements = append(elements, element+"*")
+	} else if min < max {
+		// e.g. (a (a)?)? for up to two more
+		optional := element + "?"
+		for i := min + 1; i < max; i++ {
+			optional = "(" + element + " " + optional + ")?"
+		}
+		elements = append(elements, optional)
+	}
+	switch len(elements) {
+	case 0:
+		x.warn("%s can not express empty repetitions, they are exported as empty groups", x.Notation)
+		return "()", regexpAtom
+	case 1:
+		return elements[0], regexpAtom
+	}
+	return x.concat(elements), regexpConcatenation
+}
+
+// characterValue returns the expression of a quoted string.
+func (x *exporter) characterValue(value string) (string, int) {
+	if !x.CaseInsensitive || strings.ToLower(value) == strings.ToUpper(value) {
+		return x.literal(value), regexpAtom
+	}
+	var elements []string
+	for _, r := range value {
+		lower, upper := unicode.ToLower(r), unicode.ToUpper(r)
+		if lower == upper {
+			elements = append(elements, x.literal(string(r)))
+			continue
+		}
+		switch x.Notation {
+		case ISOEBNF:
+			elements = append(elements, fmt.Sprintf("(%s | %s)", x.literal(string(lower)), x.literal(string(upper))))
+		default:
+			elements = append(elements, fmt.Sprintf("[%c%c]", lower, upper))
+		}
+	}
+	if len(elements) == 1 {
+		return elements[0], regexpAtom
+	}
+	return x.concat(elements), regexpConcatenation
+}
+
+// literal returns the given (printable) string as a terminal.
+func (x *exporter) literal(value string) string {
+	switch x.Notation {
+	case ANTLR:
+		return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+	case PEG:
+		return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+	case ISOEBNF:
+		if strings.Contains(value, `"`) {
+			return "'" + value + "'"
+		}
+	}
+	// W3C strings can not contain both quotes, but a char-val can not contain a double quote
+	return `"` + value + `"`
+}
+
+// numericValue returns the expression of a numeric value.
+func (x *exporter) numericValue(operator NumericValueOperator) (string, int) {
+	var values []int
+	for _, part := range operator.value {
+		v, _ := parseNumeric(part, operator.numericType)
+		values = append(values, v)
+	}
+
+	if operator.hyphen {
+		low, high := values[0], values[1]
+		switch x.Notation {
+		case ISOEBNF:
+			if printable(low, high) {
+				var alternatives []string
+				for v := low; v <= high; v++ {
+					alternatives = append(alternatives, x.literal(string(rune(v))))
+				}
+				return strings.Join(alternatives, " | "), regexpAlternation
+			}
+			x.warn("ISO EBNF can not express the range %s, it is exported as a special sequence", operator.key)
+			return fmt.Sprintf("? %s ?", operator.key), regexpAtom
+		case W3CEBNF:
+			return fmt.Sprintf("[#x%X-#x%X]", low, high), regexpAtom
+		default:
+			return fmt.Sprintf("[%s-%s]", x.escape(operator.key, low), x.escape(operator.key, high)), regexpAtom
+		}
+	}
+
+	var elements []string
+	for _, v := range values {
+		switch x.Notation {
+		case ISOEBNF:
+			if !printable(v, v) {
+				x.warn("ISO EBNF can not express the value %s, it is exported as a special sequence", operator.key)
+				return fmt.Sprintf("? %s ?", operator.key), regexpAtom
+			}
+			elements = append(elements, x.literal(string(rune(v))))
+		case W3CEBNF:
+			elements = append(elements, fmt.Sprintf("#x%X", v))
+		case ANTLR:
+			elements = append(elements, "'"+x.escape(operator.key, v)+"'")
+		default:
+			elements = append(elements, `"`+x.escape(operator.key, v)+`"`)
+		}
+	}
+	if len(elements) == 1 {
+		return elements[0], regexpAtom
+	}
+	return x.concat(elements), regexpConcatenation
+}
+
+// escape returns the escape sequence of the given value, within a string or a character class. Letters and digits are
+// not escaped.
+func (x *exporter) escape(key string, v int) string {
+	switch {
+	case v <= 0x7F && regexpByte(byte(v)) == string(rune(v)):
+		return string(rune(v))
+	case x.Notation == ANTLR && 0xFFFF < v:
+		return fmt.Sprintf(`\u{%X}`, v)
+	case x.Notation == ANTLR:
+		return fmt.Sprintf(`\u%04X`, v)
+	case v <= 0xFF:
+		return fmt.Sprintf(`\x%02X`, v)
+	case v <= 0xFFFF:
+		return fmt.Sprintf(`\u%04X`, v)
+	default:
+		x.warn("PEG can not express the value %s, it is exported as the closest escape", key)
+		return fmt.Sprintf(`\u%04X`, v&0xFFFF)
+	}
+}
+
+// printable returns whether all values in the range are printable ASCII characters.
+func printable(low, high int) bool {
+	return 0x20 <= low && high <= 0x7E
+}
+
+// parseNumeric parses a numeric value of the given type, unlike toIntegers it does not split values in bytes.
+func parseNumeric(value string, numericType numericType) (int, error) {
+	base := 16
+	switch numericType {
+	case binary:
+		base = 2
+	case decimal:
+		base = 10
+	}
+	v, err := strconv.ParseInt(value, base, 32)
+	return int(v), err
+}
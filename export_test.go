@@ -0,0 +1,92 @@
+package abnf
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExporter(t *testing.T) {
+	set := NewRuleSet([]byte(strings.Join([]string{
+		"rule-name = 2*3(\"ab\" / %x41.42) [\"-\" 1*DIGIT] *3\"x\"",
+		"DIGIT = %x30-39",
+		"ctl = %x00-1F / %x7F / other",
+	}, "\n") + "\n"))
+
+	for _, test := range []struct {
+		exporter Exporter
+		expected string
+		warnings []string
+	}{
+		{
+			exporter: Exporter{Notation: ISOEBNF},
+			expected: `DIGIT = "0" | "1" | "2" | "3" | "4" | "5" | "6" | "7" | "8" | "9" ;
+ctl = ? %x00-1F ? | ? %x7F ? | other ;
+rule_name = 2 * ("ab" | "A", "B"), 1 * ["ab" | "A", "B"], ["-", 1 * DIGIT, {DIGIT}], 3 * ["x"] ;
+`,
+			warnings: []string{
+				"ISO EBNF can not express the range %x00-1F, it is exported as a special sequence",
+				"ISO EBNF can not express the value %x7F, it is exported as a special sequence",
+				"undefined rule: other",
+			},
+		},
+		{
+			exporter: Exporter{Notation: W3CEBNF, CaseInsensitive: true},
+			expected: `DIGIT ::= [#x30-#x39]
+ctl ::= [#x0-#x1F] | #x7F | other
+rule-name ::= ([aA] [bB] | #x41 #x42) ([aA] [bB] | #x41 #x42) ([aA] [bB] | #x41 #x42)? ("-" DIGIT+)? ([xX] ([xX] [xX]?)?)?
+`,
+			warnings: []string{"undefined rule: other"},
+		},
+		{
+			exporter: Exporter{Notation: ANTLR, Name: "Example"},
+			expected: `lexer grammar Example;
+
+DIGIT : [0-9] ;
+CTL : [\u0000-\u001F] | '\u007F' | OTHER ;
+RULE_NAME : ('ab' | 'A' 'B') ('ab' | 'A' 'B') ('ab' | 'A' 'B')? ('-' DIGIT+)? ('x' ('x' 'x'?)?)? ;
+`,
+			warnings: []string{"undefined rule: other"},
+		},
+		{
+			exporter: Exporter{Notation: PEG},
+			expected: `DIGIT <- [0-9]
+ctl <- [\x00-\x1F] / "\x7F" / other
+rule_name <- ("ab" / "A" "B") ("ab" / "A" "B") ("ab" / "A" "B")? ("-" DIGIT+)? ("x" ("x" "x"?)?)?
+`,
+			warnings: []string{
+				"undefined rule: other",
+				"choices are ordered and repetitions are greedy in PEG, which may reject inputs that match the ABNF rules",
+			},
+		},
+	} {
+		b := &bytes.Buffer{}
+		warnings, err := test.exporter.Export(b, set)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if b.String() != test.expected {
+			t.Errorf("%s: expected\n%s\ngot\n%s", test.exporter.Notation, test.expected, b)
+		}
+		if !reflect.DeepEqual(warnings, test.warnings) {
+			t.Errorf("%s: invalid warnings: %q", test.exporter.Notation, warnings)
+		}
+	}
+
+	t.Run("Collisions", func(t *testing.T) {
+		set := NewRuleSet([]byte("a-b = \"x\"\nA-B = \"y\"\n"))
+		warnings, _ := Exporter{Notation: ANTLR, Name: "Collisions"}.Export(&bytes.Buffer{}, set)
+		if len(warnings) != 1 || warnings[0] != "rules A-B and a-b are both exported as A_B" {
+			t.Errorf("invalid warnings: %q", warnings)
+		}
+	})
+
+	if _, err := (Exporter{Notation: ANTLR}).Export(&bytes.Buffer{}, set); err == nil {
+		t.Error("expected an error for an ANTLR grammar without a name")
+	}
+	if _, err := (Exporter{Notation: PEG + 1}).Export(&bytes.Buffer{}, set); err == nil {
+		t.Error("expected an error for an unknown notation")
+	}
+}
@@ -1,6 +1,10 @@
 package abnf
 
-import "strings"
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
 
 func formatRuleName(name string) string {
 	var formatted string
@@ -10,3 +14,227 @@ func formatRuleName(name string) string {
 	}
 	return formatted
 }
+
+// reservedNames are the Go keywords and predeclared identifiers, the generated code can not use them as names.
+var reservedNames = map[string]bool{
+	"break": true, "case": true, "chan": true, "const": true, "continue": true, "default": true, "defer": true,
+	"else": true, "fallthrough": true, "for": true, "func": true, "go": true, "goto": true, "if": true, "import": true,
+	"interface": true, "map": true, "package": true, "range": true, "return": true, "select": true, "struct": true,
+	"switch": true, "type": true, "var": true,
+
+	"append": true, "bool": true, "byte": true, "cap": true, "close": true, "complex": true, "complex64": true,
+	"complex128": true, "copy": true, "delete": true, "error": true, "false": true, "float32": true, "float64": true,
+	"imag": true, "int": true, "int8": true, "int16": true, "int32": true, "int64": true, "iota": true, "len": true,
+	"make": true, "new": true, "nil": true, "panic": true, "print": true, "println": true, "real": true,
+	"recover": true, "rune": true, "string": true, "true": true, "uint": true, "uint8": true, "uint16": true,
+	"uint32": true, "uint64": true, "uintptr": true,
+}
+
+// locals are the parameters and local variables of the generated functions, rules can not be named after them either
+// (e.g. a rule s would get called as s(s)). The numbered variables of the recursive descent code (e.g. n_0) contain an
+// underscore, so they do not collide.
+var locals = map[string]bool{
+	"actions": true, "children": true, "i": true, "l": true, "lengths": true, "n": true, "node": true, "nodes": true,
+	"s": true,
+}
+
+// Names returns the Go names of the rules of the RawABNF, e.g. "foo-bar" is generated as the function FooBar (or
+// parseFooBar, RecognizeFooBar, etc.).
+//
+// The names are taken from RuleNames, or else from FormatRuleName. Only letters and digits are kept. Names that collide
+// with each other, with Go keywords, with the imported packages, with the local variables of the generated functions or
+// with other identifiers of the generated code (e.g. Recognize + the name of another rule) get the first free numeric
+// suffix, starting from 2. The rules keep their name in the order of their names, e.g. "Foo-Bar" is generated as FooBar
+// and "foo-bar" as FooBar2.
+//
+// Helpers (see UnexportHelpers) start with a lower case letter, e.g. "foo-bar" is generated as fooBar (or parseFooBar,
+// recognizeFooBar, etc.). Rules that get pruned (see Prune) are not included.
+func (g *CodeGenerator) Names() map[string]string {
+	names, _ := g.ruleNames(g.ruleSet())
+	return names
+}
+
+// name returns the Go name of the function of the rule with the given name.
+func (g *CodeGenerator) name(name string) string {
+	if goName, ok := g.names[name]; ok {
+		return goName
+	}
+	return formatRuleName(name)
+}
+
+// base returns the Go name of the rule with the given name as it is used in the names of the other identifiers of the
+// rule, e.g. FooBar for parseFooBar, also if the function itself is unexported.
+func (g *CodeGenerator) base(name string) string {
+	if base, ok := g.bases[name]; ok {
+		return base
+	}
+	return formatRuleName(name)
+}
+
+// exported returns whether the rule with the given name gets exported, see UnexportHelpers.
+func (g *CodeGenerator) exported(name string) bool {
+	if !g.UnexportHelpers {
+		return true
+	}
+	for _, start := range g.StartRules {
+		if start == name {
+			return true
+		}
+	}
+	return false
+}
+
+// recognizerName returns the name of the recognizer function of the rule with the given name.
+func (g *CodeGenerator) recognizerName(name string) string {
+	if !g.exported(name) {
+		return "recognize" + g.base(name)
+	}
+	return "Recognize" + g.base(name)
+}
+
+// parserName returns the name of the function that parses the whole input as the rule with the given name, see Actions.
+func (g *CodeGenerator) parserName(name string) string {
+	return "Parse" + g.base(name)
+}
+
+// recognizerVariable returns the name of the variable that contains the recognizer of the rule with the given name.
+func (g *CodeGenerator) recognizerVariable(name string) string {
+	if !g.exported(name) {
+		return "recognizer" + g.base(name)
+	}
+	return "recognize" + g.base(name)
+}
+
+// unexport returns the given name with a lower case start, a leading abbreviation is lower-cased as a whole, e.g.
+// "FooBar" as "fooBar", "ALPHA" as "alpha" and "HTTPVersion" as "httpVersion".
+func unexport(name string) string {
+	runes := []rune(name)
+	var upper int
+	for upper < len(runes) && unicode.IsUpper(runes[upper]) {
+		upper++
+	}
+	if 1 < upper && upper < len(runes) && unicode.IsLower(runes[upper]) {
+		// the last upper case letter starts the next word
+		upper--
+	}
+	for i := 0; i < upper; i++ {
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
+
+// ruleNames returns the unique Go names of the functions of the rules of the given set (see Names), together with
+// their bases (see base).
+func (g *CodeGenerator) ruleNames(ruleSet RuleSet) (map[string]string, map[string]string) {
+	taken := make(map[string]bool)
+	for name := range reservedNames {
+		taken[name] = true
+	}
+	for _, name := range []string{"init", "operators", "peg", "recognize"} {
+		taken[name] = true
+	}
+	for key, external := range g.ExternalABNF {
+		taken[external.PackageName] = true
+		if g.RecursiveDescent {
+			taken[g.descentExternal(key)] = true
+		}
+	}
+	if g.Registry {
+		for _, name := range []string{"Metadata", "RuleMetadata", "RuleName", "Rules"} {
+			taken[name] = true
+		}
+	}
+
+	// claim takes all the identifiers of the given base, if none of them are taken yet
+	claim := func(base string, exported bool) bool {
+		identifiers := g.identifiers(base, exported)
+		for _, identifier := range identifiers {
+			if taken[identifier] || locals[identifier] {
+				return false
+			}
+		}
+		for _, identifier := range identifiers {
+			taken[identifier] = true
+		}
+		return true
+	}
+	names, bases := make(map[string]string), make(map[string]string)
+	var collisions []string
+	for _, name := range ruleSet.names() {
+		if base := g.candidateName(name); claim(base, g.exported(name)) {
+			bases[name] = base
+		} else {
+			collisions = append(collisions, name)
+		}
+	}
+	for _, name := range collisions {
+		base := g.candidateName(name)
+		for i := 2; ; i++ {
+			if claim(base+strconv.Itoa(i), g.exported(name)) {
+				bases[name] = base + strconv.Itoa(i)
+				break
+			}
+		}
+	}
+	for name, base := range bases {
+		names[name] = base
+		if !g.exported(name) {
+			names[name] = unexport(base)
+		}
+	}
+	return names, bases
+}
+
+// candidateName returns the Go name of the rule with the given name, before resolving collisions.
+func (g *CodeGenerator) candidateName(name string) string {
+	goName, ok := g.RuleNames[name]
+	if !ok && g.FormatRuleName != nil {
+		goName = g.FormatRuleName(name)
+	} else if !ok {
+		goName = formatRuleName(name)
+	}
+	goName = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, goName)
+	if goName == "" || unicode.IsDigit([]rune(goName)[0]) {
+		goName = "R" + goName
+	}
+	return goName
+}
+
+// identifiers returns the identifiers the generated code declares for a rule with the given base, exported or not. The
+// functions of the operators of the recursive descent code are not included, they contain an underscore (e.g.
+// parseFooBar_1).
+func (g *CodeGenerator) identifiers(base string, exported bool) []string {
+	identifiers := []string{base}
+	if !exported {
+		identifiers[0] = unexport(base)
+	}
+	switch {
+	case g.PEG || !g.RecursiveDescent:
+		identifiers = append(identifiers, "operator"+base, "parse"+base)
+	default:
+		identifiers = append(identifiers, "parse"+base, "repeat"+base, "dfa"+base)
+	}
+	if g.Recognizers && !g.PEG {
+		if exported {
+			identifiers = append(identifiers, "Recognize"+base, "recognize"+base)
+		} else {
+			identifiers = append(identifiers, "recognize"+base, "recognizer"+base)
+		}
+	}
+	if g.Actions && !g.PEG && exported {
+		identifiers = append(identifiers, "Parse"+base)
+	}
+	if g.Registry {
+		if exported {
+			identifiers = append(identifiers, "Rule"+base)
+		} else {
+			identifiers = append(identifiers, "rule"+base)
+		}
+	}
+	return identifiers
+}
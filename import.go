@@ -0,0 +1,941 @@
+package abnf
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxCodePoint is the highest Unicode code point, used to complement character classes.
+const maxCodePoint = 0x10FFFF
+
+// Importer converts grammars in other notations to ABNF. Only ISO EBNF and W3C EBNF are supported.
+type Importer struct {
+	Notation Notation
+}
+
+// Import converts the given grammar to a rule set.
+func (i Importer) Import(raw []byte) (RuleSet, error) {
+	rawABNF, err := i.ABNF(raw)
+	if err != nil {
+		return nil, err
+	}
+	return NewRuleSet(rawABNF), nil
+}
+
+// ABNF converts the given grammar to ABNF, which can be used as the RawABNF of both generators. Rule names get converted
+// to valid ABNF rule names (e.g. underscores become hyphens) and strings become character values, which are matched
+// case sensitively by this package, like in the source notations. Exceptions (A - B) are only supported if both sides
+// match single characters. The operators match bytes, so characters that are not ASCII become their UTF-8 encoding, e.g.
+// 'é' as %xC3.A9 and [#x80-#x7FF] as %xC2-DF %x80-BF.
+func (i Importer) ABNF(raw []byte) ([]byte, error) {
+	x := &importer{
+		notation: i.Notation,
+		src:      []rune(string(raw)),
+		rules:    make(map[string]*importExpression),
+		names:    make(map[string]string),
+	}
+	var err error
+	switch i.Notation {
+	case ISOEBNF:
+		err = x.isoSyntax()
+	case W3CEBNF:
+		err = x.w3cGrammar()
+	default:
+		return nil, fmt.Errorf("importing %s is not supported", i.Notation)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	for _, name := range x.order {
+		elements, _, err := x.abnf(x.rules[name], make(map[string]bool))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", name, err)
+		}
+		fmt.Fprintf(&b, "%s = %s\n", name, elements)
+	}
+	return []byte(b.String()), nil
+}
+
+type importKind int
+
+const (
+	importAlternation importKind = iota
+	importConcatenation
+	importRepetition
+	importReference
+	importLiteral
+	importRanges
+	importException
+)
+
+// importExpression is an expression of an imported grammar.
+type importExpression struct {
+	kind     importKind
+	subs     []*importExpression
+	min, max int
+	// name of the referenced rule, already converted to ABNF
+	name    string
+	literal []rune
+	// sorted and non overlapping
+	ranges [][2]int
+}
+
+// importer contains the state of a single import.
+type importer struct {
+	notation Notation
+	src      []rune
+	i        int
+
+	rules map[string]*importExpression
+	// order in which the rules were defined
+	order []string
+	// original names of the converted rule names
+	names map[string]string
+}
+
+func (x *importer) errorf(format string, args ...interface{}) error {
+	line := 1 + strings.Count(string(x.src[:x.i]), "\n")
+	return fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+func (x *importer) eof() bool {
+	return len(x.src) <= x.i
+}
+
+// peek returns the current rune, or 0 at the end of the input.
+func (x *importer) peek() rune {
+	if x.eof() {
+		return 0
+	}
+	return x.src[x.i]
+}
+
+func (x *importer) hasPrefix(prefix string) bool {
+	return strings.HasPrefix(string(x.src[x.i:]), prefix)
+}
+
+// skipUntil skips all runes up to and including the given suffix, or the rest of the input if it is not found.
+func (x *importer) skipUntil(suffix string) {
+	if i := strings.Index(string(x.src[x.i:]), suffix); i >= 0 {
+		x.i += len([]rune(string(x.src[x.i:])[:i])) + len([]rune(suffix))
+		return
+	}
+	x.i = len(x.src)
+}
+
+// skipSpace skips white space and comments. The constraint annotations of W3C grammars (e.g. [ wfc: ... ]) are
+// treated as comments.
+func (x *importer) skipSpace() {
+	for !x.eof() {
+		switch {
+		case unicode.IsSpace(x.peek()):
+			x.i++
+		case x.notation == W3CEBNF && x.hasPrefix("/*"):
+			x.skipUntil("*/")
+		case x.notation == W3CEBNF && x.constraint():
+			x.skipUntil("]")
+		case x.notation == ISOEBNF && x.hasPrefix("(*"):
+			x.i += 2
+			// comments can be nested
+			for depth := 1; 0 < depth && !x.eof(); {
+				switch {
+				case x.hasPrefix("(*"):
+					x.i += 2
+					depth++
+				case x.hasPrefix("*)"):
+					x.i += 2
+					depth--
+				default:
+					x.i++
+				}
+			}
+		default:
+			return
+		}
+	}
+}
+
+// constraint returns whether the input starts with a well-formedness or validity constraint.
+func (x *importer) constraint() bool {
+	if !x.hasPrefix("[") {
+		return false
+	}
+	rest := strings.TrimLeftFunc(string(x.src[x.i+1:]), unicode.IsSpace)
+	return strings.HasPrefix(rest, "wfc:") || strings.HasPrefix(rest, "vc:")
+}
+
+// accept skips white space and consumes the given token if the input starts with it.
+func (x *importer) accept(token string) bool {
+	x.skipSpace()
+	if x.hasPrefix(token) {
+		x.i += len([]rune(token))
+		return true
+	}
+	return false
+}
+
+func (x *importer) expect(token string) error {
+	if !x.accept(token) {
+		return x.errorf("expected %s", token)
+	}
+	return nil
+}
+
+// identifier consumes an identifier, or returns an empty string if there is none. The words of an ISO EBNF identifier
+// are joined with hyphens.
+func (x *importer) identifier() string {
+	x.skipSpace()
+	start := x.i
+	if r := x.peek(); !unicode.IsLetter(r) && r != '_' {
+		return ""
+	}
+	var words []string
+	for {
+		for r := x.peek(); unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.' && x.notation == W3CEBNF ||
+			r == '-' && x.i+1 < len(x.src) && (unicode.IsLetter(x.src[x.i+1]) || unicode.IsDigit(x.src[x.i+1])); r = x.peek() {
+			x.i++
+		}
+		words = append(words, string(x.src[start:x.i]))
+		if x.notation != ISOEBNF {
+			break
+		}
+		// white space within identifiers is insignificant
+		next := x.i
+		for next < len(x.src) && unicode.IsSpace(x.src[next]) {
+			next++
+		}
+		if next == len(x.src) || next == x.i || !unicode.IsLetter(x.src[next]) && !unicode.IsDigit(x.src[next]) {
+			break
+		}
+		x.i, start = next, next
+	}
+	return strings.Join(words, "-")
+}
+
+// ruleName converts the given name to a valid ABNF rule name.
+func ruleName(name string) (string, error) {
+	converted := strings.NewReplacer("_", "-", ".", "-").Replace(name)
+	for i, r := range converted {
+		if r < 'A' || 'z' < r || 'Z' < r && r < 'a' {
+			if i == 0 || r != '-' && (r < '0' || '9' < r) {
+				return "", fmt.Errorf("can not convert %q to an ABNF rule name", name)
+			}
+		}
+	}
+	return converted, nil
+}
+
+// define adds the rule with the given name.
+func (x *importer) define(name string, expression *importExpression) error {
+	converted, err := ruleName(name)
+	if err != nil {
+		return x.errorf("%s", err)
+	}
+	if other, ok := x.names[converted]; ok {
+		if other == name {
+			return x.errorf("rule %s is defined twice", name)
+		}
+		return x.errorf("rules %s and %s are both imported as %s", other, name, converted)
+	}
+	x.names[converted] = name
+	x.rules[converted] = expression
+	x.order = append(x.order, converted)
+	return nil
+}
+
+// reference returns a reference to the rule with the given name.
+func (x *importer) reference(name string) (*importExpression, error) {
+	converted, err := ruleName(name)
+	if err != nil {
+		return nil, x.errorf("%s", err)
+	}
+	return &importExpression{kind: importReference, name: converted}, nil
+}
+
+// quoted consumes a string between single or double quotes.
+func (x *importer) quoted() (*importExpression, error) {
+	quote := x.peek()
+	x.i++
+	start := x.i
+	for x.peek() != quote {
+		if x.eof() || x.peek() == '\n' {
+			return nil, x.errorf("unterminated string")
+		}
+		x.i++
+	}
+	x.i++
+	return &importExpression{kind: importLiteral, literal: x.src[start : x.i-1]}, nil
+}
+
+// alternation returns the alternation of the given expressions, if there is more than one.
+func alternation(alternatives []*importExpression) *importExpression {
+	if len(alternatives) == 1 {
+		return alternatives[0]
+	}
+	return &importExpression{kind: importAlternation, subs: alternatives}
+}
+
+// concatenation returns the concatenation of the given expressions, if there is more than one.
+func concatenation(elements []*importExpression) *importExpression {
+	if len(elements) == 1 {
+		return elements[0]
+	}
+	return &importExpression{kind: importConcatenation, subs: elements}
+}
+
+func repetition(expression *importExpression, min, max int) *importExpression {
+	return &importExpression{kind: importRepetition, subs: []*importExpression{expression}, min: min, max: max}
+}
+
+// W3C EBNF (https://www.w3.org/TR/xml/#sec-notation)
+//  grammar = *(["[" number "]"] symbol "::=" expression)
+
+func (x *importer) w3cGrammar() error {
+	for {
+		x.skipSpace()
+		if x.eof() {
+			return nil
+		}
+		x.productionNumber()
+		name := x.identifier()
+		if name == "" {
+			return x.errorf("expected a rule name")
+		}
+		if err := x.expect("::="); err != nil {
+			return err
+		}
+		expression, err := x.w3cAlternation()
+		if err != nil {
+			return err
+		}
+		if err := x.define(name, expression); err != nil {
+			return err
+		}
+	}
+}
+
+// productionNumber skips the number of a production, e.g. [1].
+func (x *importer) productionNumber() {
+	x.skipSpace()
+	if !x.hasPrefix("[") {
+		return
+	}
+	i := x.i + 1
+	for i < len(x.src) && '0' <= x.src[i] && x.src[i] <= '9' {
+		i++
+	}
+	if x.i+1 < i && i < len(x.src) && x.src[i] == ']' {
+		x.i = i + 1
+	}
+}
+
+// w3cEnd returns whether the input is at the end of a sequence, this is also the case at the start of the next rule.
+func (x *importer) w3cEnd() bool {
+	x.skipSpace()
+	if x.eof() || x.hasPrefix("|") || x.hasPrefix(")") {
+		return true
+	}
+	start := x.i
+	defer func() { x.i = start }()
+	x.productionNumber()
+	return x.identifier() != "" && x.accept("::=")
+}
+
+// expression = sequence *("|" sequence)
+func (x *importer) w3cAlternation() (*importExpression, error) {
+	var alternatives []*importExpression
+	for {
+		sequence, err := x.w3cSequence()
+		if err != nil {
+			return nil, err
+		}
+		alternatives = append(alternatives, sequence)
+		if !x.accept("|") {
+			return alternation(alternatives), nil
+		}
+	}
+}
+
+// sequence = 1*difference
+func (x *importer) w3cSequence() (*importExpression, error) {
+	var elements []*importExpression
+	for !x.w3cEnd() {
+		difference, err := x.w3cDifference()
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, difference)
+	}
+	if len(elements) == 0 {
+		return nil, x.errorf("expected an expression")
+	}
+	return concatenation(elements), nil
+}
+
+// difference = item ["-" item]
+func (x *importer) w3cDifference() (*importExpression, error) {
+	item, err := x.w3cItem()
+	if err != nil || !x.accept("-") {
+		return item, err
+	}
+	except, err := x.w3cItem()
+	if err != nil {
+		return nil, err
+	}
+	return &importExpression{kind: importException, subs: []*importExpression{item, except}}, nil
+}
+
+// item = primary *("?" / "*" / "+")
+func (x *importer) w3cItem() (*importExpression, error) {
+	item, err := x.w3cPrimary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch {
+		case x.accept("?"):
+			item = repetition(item, 0, 1)
+		case x.accept("*"):
+			item = repetition(item, 0, -1)
+		case x.accept("+"):
+			item = repetition(item, 1, -1)
+		default:
+			return item, nil
+		}
+	}
+}
+
+// primary = symbol / string / "#x" hex / "[" ["^"] 1*(char ["-" char]) "]" / "(" expression ")"
+func (x *importer) w3cPrimary() (*importExpression, error) {
+	x.skipSpace()
+	switch r := x.peek(); {
+	case r == '(':
+		x.i++
+		expression, err := x.w3cAlternation()
+		if err != nil {
+			return nil, err
+		}
+		return expression, x.expect(")")
+	case r == '"' || r == '\'':
+		return x.quoted()
+	case x.hasPrefix("#x"):
+		v, err := x.w3cCharacter()
+		if err != nil {
+			return nil, err
+		}
+		return &importExpression{kind: importRanges, ranges: [][2]int{{v, v}}}, nil
+	case r == '[':
+		return x.w3cClass()
+	case unicode.IsLetter(r) || r == '_':
+		return x.reference(x.identifier())
+	case x.eof():
+		return nil, x.errorf("unexpected end of input")
+	default:
+		return nil, x.errorf("unexpected %q", r)
+	}
+}
+
+// w3cCharacter consumes a character of a class, which is either a literal character or a hexadecimal code point.
+func (x *importer) w3cCharacter() (int, error) {
+	if !x.hasPrefix("#x") {
+		r := x.peek()
+		x.i++
+		return int(r), nil
+	}
+	x.i += 2
+	start := x.i
+	for r := unicode.ToLower(x.peek()); '0' <= r && r <= '9' || 'a' <= r && r <= 'f'; r = unicode.ToLower(x.peek()) {
+		x.i++
+	}
+	v, err := strconv.ParseInt(string(x.src[start:x.i]), 16, 32)
+	if err != nil || maxCodePoint < v {
+		return 0, x.errorf("invalid character: #x%s", string(x.src[start:x.i]))
+	}
+	return int(v), nil
+}
+
+func (x *importer) w3cClass() (*importExpression, error) {
+	x.i++
+	negated := x.peek() == '^'
+	if negated {
+		x.i++
+	}
+	var ranges [][2]int
+	for x.peek() != ']' {
+		if x.eof() {
+			return nil, x.errorf("unterminated character class")
+		}
+		low, err := x.w3cCharacter()
+		if err != nil {
+			return nil, err
+		}
+		high := low
+		if x.peek() == '-' && x.i+1 < len(x.src) && x.src[x.i+1] != ']' {
+			x.i++
+			if high, err = x.w3cCharacter(); err != nil {
+				return nil, err
+			}
+		}
+		ranges = append(ranges, [2]int{low, high})
+	}
+	x.i++
+	if len(ranges) == 0 {
+		return nil, x.errorf("empty character class")
+	}
+	ranges = normalizeRanges(ranges)
+	if negated {
+		ranges = subtractRanges([][2]int{{0, maxCodePoint}}, ranges)
+	}
+	return &importExpression{kind: importRanges, ranges: ranges}, nil
+}
+
+// ISO EBNF (ISO/IEC 14977)
+//  syntax = *(identifier "=" definitions (";" / "."))
+
+func (x *importer) isoSyntax() error {
+	for {
+		x.skipSpace()
+		if x.eof() {
+			return nil
+		}
+		name := x.identifier()
+		if name == "" {
+			return x.errorf("expected a rule name")
+		}
+		if err := x.expect("="); err != nil {
+			return err
+		}
+		expression, err := x.isoDefinitions()
+		if err != nil {
+			return err
+		}
+		if !x.accept(";") && !x.accept(".") {
+			return x.errorf("expected ; at the end of rule %s", name)
+		}
+		if err := x.define(name, expression); err != nil {
+			return err
+		}
+	}
+}
+
+// isoEnd returns whether the input is at the end of a term.
+func (x *importer) isoEnd() bool {
+	x.skipSpace()
+	if x.eof() {
+		return true
+	}
+	return strings.ContainsRune(",|!/;.)]}:", x.peek())
+}
+
+// isoSeparator consumes a definition separator, the slash of "/)" ends an option.
+func (x *importer) isoSeparator() bool {
+	if x.accept("|") || x.accept("!") {
+		return true
+	}
+	return !x.hasPrefix("/)") && x.accept("/")
+}
+
+// definitions = single *(("|" / "/" / "!") single)
+func (x *importer) isoDefinitions() (*importExpression, error) {
+	var alternatives []*importExpression
+	for {
+		single, err := x.isoSingle()
+		if err != nil {
+			return nil, err
+		}
+		alternatives = append(alternatives, single)
+		if !x.isoSeparator() {
+			return alternation(alternatives), nil
+		}
+	}
+}
+
+// single = term *("," term)
+func (x *importer) isoSingle() (*importExpression, error) {
+	var elements []*importExpression
+	for {
+		term, err := x.isoTerm()
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, term)
+		if !x.accept(",") {
+			return concatenation(elements), nil
+		}
+	}
+}
+
+// term = factor ["-" [factor]]
+func (x *importer) isoTerm() (*importExpression, error) {
+	factor, err := x.isoFactor()
+	if err != nil || !x.accept("-") {
+		return factor, err
+	}
+	if x.isoEnd() {
+		// {a}- is a common notation for one or more repetitions
+		if factor.kind == importRepetition && factor.min == 0 && factor.max < 0 {
+			factor.min = 1
+			return factor, nil
+		}
+		return nil, x.errorf("expected an exception")
+	}
+	except, err := x.isoFactor()
+	if err != nil {
+		return nil, err
+	}
+	return &importExpression{kind: importException, subs: []*importExpression{factor, except}}, nil
+}
+
+// factor = [integer "*"] primary
+func (x *importer) isoFactor() (*importExpression, error) {
+	x.skipSpace()
+	start := x.i
+	for '0' <= x.peek() && x.peek() <= '9' {
+		x.i++
+	}
+	if start == x.i {
+		return x.isoPrimary()
+	}
+	n, _ := strconv.Atoi(string(x.src[start:x.i]))
+	if err := x.expect("*"); err != nil {
+		return nil, err
+	}
+	primary, err := x.isoPrimary()
+	if err != nil {
+		return nil, err
+	}
+	return repetition(primary, n, n), nil
+}
+
+// primary = optional / repeated / group / identifier / terminal / empty
+func (x *importer) isoPrimary() (*importExpression, error) {
+	x.skipSpace()
+	var end string
+	var min, max int
+	switch r := x.peek(); {
+	case x.hasPrefix("(/"):
+		end, min, max = "/)", 0, 1
+	case x.hasPrefix("(:"):
+		end, min, max = ":)", 0, -1
+	case r == '(':
+		end, min, max = ")", 1, 1
+	case r == '[':
+		end, min, max = "]", 0, 1
+	case r == '{':
+		end, min, max = "}", 0, -1
+	case r == '"' || r == '\'':
+		return x.quoted()
+	case r == '?':
+		return nil, x.errorf("special sequences are not supported")
+	case unicode.IsLetter(r) || r == '_':
+		return x.reference(x.identifier())
+	case x.isoEnd():
+		// empty sequence
+		return &importExpression{kind: importLiteral}, nil
+	default:
+		return nil, x.errorf("unexpected %q", r)
+	}
+
+	x.i += len([]rune(end))
+	definitions, err := x.isoDefinitions()
+	if err != nil {
+		return nil, err
+	}
+	if err := x.expect(end); err != nil {
+		return nil, err
+	}
+	if min == 1 && max == 1 {
+		return definitions, nil
+	}
+	return repetition(definitions, min, max), nil
+}
+
+// abnf returns the ABNF elements of the given expression, and its precedence (same as the regular expressions).
+func (x *importer) abnf(expression *importExpression, visiting map[string]bool) (string, int, error) {
+	switch expression.kind {
+	case importAlternation:
+		var alternatives []string
+		for _, sub := range expression.subs {
+			alternative, _, err := x.abnf(sub, visiting)
+			if err != nil {
+				return "", 0, err
+			}
+			alternatives = append(alternatives, alternative)
+		}
+		return strings.Join(alternatives, " / "), regexpAlternation, nil
+	case importConcatenation:
+		var elements []string
+		for _, sub := range expression.subs {
+			element, err := x.group(sub, regexpConcatenation, visiting)
+			if err != nil {
+				return "", 0, err
+			}
+			elements = append(elements, element)
+		}
+		return strings.Join(elements, " "), regexpConcatenation, nil
+	case importRepetition:
+		if expression.min == 0 && expression.max == 1 {
+			option, _, err := x.abnf(expression.subs[0], visiting)
+			return "[" + option + "]", regexpAtom, err
+		}
+		element, err := x.group(expression.subs[0], regexpAtom, visiting)
+		if err != nil {
+			return "", 0, err
+		}
+		var repeat string
+		switch {
+		case expression.min == expression.max:
+			repeat = strconv.Itoa(expression.min)
+		case expression.min == 0:
+			repeat = "*"
+		default:
+			repeat = strconv.Itoa(expression.min) + "*"
+		}
+		if expression.min != expression.max && 0 <= expression.max {
+			repeat += strconv.Itoa(expression.max)
+		}
+		// a repetition can not be repeated without a group
+		return repeat + element, regexpConcatenation, nil
+	case importReference:
+		return expression.name, regexpAtom, nil
+	case importLiteral:
+		elements, precedence := literalABNF(expression.literal)
+		return elements, precedence, nil
+	case importRanges:
+		elements, err := rangesABNF(expression.ranges)
+		return elements, regexpAlternation, err
+	case importException:
+		ranges, ok := x.characters(expression.subs[0], visiting)
+		except, exceptOk := x.characters(expression.subs[1], visiting)
+		if !ok || !exceptOk {
+			return "", 0, fmt.Errorf("exceptions are only supported between single characters")
+		}
+		if ranges = subtractRanges(ranges, except); len(ranges) == 0 {
+			return "", 0, fmt.Errorf("exception matches nothing")
+		}
+		elements, err := rangesABNF(ranges)
+		return elements, regexpAlternation, err
+	default:
+		return "", 0, fmt.Errorf("unknown expression")
+	}
+}
+
+// group returns the ABNF elements of the given expression, grouped if its precedence is lower than the given one.
+func (x *importer) group(expression *importExpression, precedence int, visiting map[string]bool) (string, error) {
+	elements, p, err := x.abnf(expression, visiting)
+	if err != nil {
+		return "", err
+	}
+	if p < precedence {
+		return "(" + elements + ")", nil
+	}
+	return elements, nil
+}
+
+// characters returns the characters matched by the given expression, if it always matches a single character.
+func (x *importer) characters(expression *importExpression, visiting map[string]bool) ([][2]int, bool) {
+	switch expression.kind {
+	case importAlternation:
+		var ranges [][2]int
+		for _, sub := range expression.subs {
+			subRanges, ok := x.characters(sub, visiting)
+			if !ok {
+				return nil, false
+			}
+			ranges = append(ranges, subRanges...)
+		}
+		return normalizeRanges(ranges), true
+	case importReference:
+		rule, ok := x.rules[expression.name]
+		if !ok || visiting[expression.name] {
+			return nil, false
+		}
+		visiting[expression.name] = true
+		defer delete(visiting, expression.name)
+		return x.characters(rule, visiting)
+	case importLiteral:
+		if len(expression.literal) != 1 {
+			return nil, false
+		}
+		return [][2]int{{int(expression.literal[0]), int(expression.literal[0])}}, true
+	case importRanges:
+		return expression.ranges, true
+	case importException:
+		ranges, ok := x.characters(expression.subs[0], visiting)
+		except, exceptOk := x.characters(expression.subs[1], visiting)
+		if !ok || !exceptOk {
+			return nil, false
+		}
+		return subtractRanges(ranges, except), true
+	default:
+		return nil, false
+	}
+}
+
+// normalizeRanges sorts the given ranges and merges the ones that overlap or are adjacent.
+func normalizeRanges(ranges [][2]int) [][2]int {
+	sorted := append([][2]int{}, ranges...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i][0] < sorted[j][0]
+	})
+	var merged [][2]int
+	for _, r := range sorted {
+		if r[1] < r[0] {
+			continue
+		}
+		if l := len(merged); l != 0 && r[0] <= merged[l-1][1]+1 {
+			if merged[l-1][1] < r[1] {
+				merged[l-1][1] = r[1]
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// subtractRanges returns the (normalized) ranges without the characters of the other ranges.
+func subtractRanges(ranges, other [][2]int) [][2]int {
+	var result [][2]int
+	for _, r := range normalizeRanges(ranges) {
+		for _, o := range normalizeRanges(other) {
+			if o[1] < r[0] || r[1] < o[0] {
+				continue
+			}
+			if r[0] < o[0] {
+				result = append(result, [2]int{r[0], o[0] - 1})
+			}
+			r[0] = o[1] + 1
+		}
+		if r[0] <= r[1] {
+			result = append(result, r)
+		}
+	}
+	return result
+}
+
+// literalABNF converts the given string to ABNF elements, characters that are not allowed in character values become
+// numeric values.
+func literalABNF(literal []rune) (string, int) {
+	if len(literal) == 0 {
+		return `""`, regexpAtom
+	}
+	var elements []string
+	var quoted, numeric []string
+	flush := func() {
+		if len(quoted) != 0 {
+			elements = append(elements, `"`+strings.Join(quoted, "")+`"`)
+		}
+		if len(numeric) != 0 {
+			elements = append(elements, "%x"+strings.Join(numeric, "."))
+		}
+		quoted, numeric = nil, nil
+	}
+	for _, r := range literal {
+		// char-val = DQUOTE *(%x20-21 / %x23-7E) DQUOTE
+		if 0x20 <= r && r <= 0x7E && r != '"' {
+			if len(numeric) != 0 {
+				flush()
+			}
+			quoted = append(quoted, string(r))
+			continue
+		}
+		if len(quoted) != 0 {
+			flush()
+		}
+		encoded := make([]byte, utf8.UTFMax)
+		for _, b := range encoded[:utf8.EncodeRune(encoded, r)] {
+			numeric = append(numeric, fmt.Sprintf("%02X", b))
+		}
+	}
+	flush()
+	if len(elements) == 1 {
+		return elements[0], regexpAtom
+	}
+	return strings.Join(elements, " "), regexpConcatenation
+}
+
+// rangesABNF converts the given ranges of code points to an alternation of the (concatenated) ranges of bytes of their
+// UTF-8 encodings. Surrogates can not be encoded, so they are left out.
+func rangesABNF(ranges [][2]int) (string, error) {
+	var alternatives []string
+	for _, r := range subtractRanges(ranges, [][2]int{{0xD800, 0xDFFF}}) {
+		if maxCodePoint < r[1] {
+			r[1] = maxCodePoint
+		}
+		if r[1] < r[0] {
+			continue
+		}
+		for _, sequence := range utf8Sequences(rune(r[0]), rune(r[1])) {
+			// consecutive single bytes are joined, e.g. %xEF.BF %x80-BD, other single values get encoded as ASCII (see
+			// NumericValueOperator.bytes), so a single byte that is not ASCII is written as a range, e.g. %xF0-F0
+			var elements []string
+			for i := 0; i < len(sequence); i++ {
+				j := i
+				for j+1 < len(sequence) && sequence[j][0] == sequence[j][1] && sequence[j+1][0] == sequence[j+1][1] {
+					j++
+				}
+				switch {
+				case i < j:
+					var values []string
+					for _, b := range sequence[i : j+1] {
+						values = append(values, fmt.Sprintf("%02X", b[0]))
+					}
+					elements = append(elements, "%x"+strings.Join(values, "."))
+					i = j
+				case sequence[i][0] == sequence[i][1] && sequence[i][0] <= 0x7F:
+					elements = append(elements, fmt.Sprintf("%%x%02X", sequence[i][0]))
+				default:
+					elements = append(elements, fmt.Sprintf("%%x%02X-%02X", sequence[i][0], sequence[i][1]))
+				}
+			}
+			alternatives = append(alternatives, strings.Join(elements, " "))
+		}
+	}
+	if len(alternatives) == 0 {
+		return "", fmt.Errorf("surrogates can not be encoded as UTF-8")
+	}
+	return strings.Join(alternatives, " / "), nil
+}
+
+// utf8Sequences returns the sequences of byte ranges that match exactly the UTF-8 encodings of the given range of code
+// points (without surrogates). The range is split until all the bytes of a sequence, except for the first one of the
+// range, cover the whole range of continuation bytes (%x80-BF), or until the sequence only contains single bytes.
+func utf8Sequences(low, high rune) [][][2]byte {
+	// the encodings of a sequence need to have the same length
+	for _, max := range []rune{0x7F, 0x7FF, 0xFFFF} {
+		if low <= max && max < high {
+			return append(utf8Sequences(low, max), utf8Sequences(max+1, high)...)
+		}
+	}
+	if high <= 0x7F {
+		return [][][2]byte{{{byte(low), byte(high)}}}
+	}
+	for i := uint(1); i < utf8.UTFMax; i++ {
+		// the last i bytes are continuation bytes of 6 bits
+		mask := rune(1)<<(6*i) - 1
+		if low&^mask == high&^mask {
+			continue
+		}
+		if low&mask != 0 {
+			return append(utf8Sequences(low, low|mask), utf8Sequences(low|mask+1, high)...)
+		}
+		if high&mask != mask {
+			return append(utf8Sequences(low, high&^mask-1), utf8Sequences(high&^mask, high)...)
+		}
+	}
+	lowBytes, highBytes := make([]byte, utf8.UTFMax), make([]byte, utf8.UTFMax)
+	n := utf8.EncodeRune(lowBytes, low)
+	utf8.EncodeRune(highBytes, high)
+	sequence := make([][2]byte, n)
+	for i := range sequence {
+		sequence[i] = [2]byte{lowBytes[i], highBytes[i]}
+	}
+	return [][][2]byte{sequence}
+}
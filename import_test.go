@@ -0,0 +1,185 @@
+package abnf
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestImporterW3CEBNF(t *testing.T) {
+	rawABNF, err := Importer{Notation: W3CEBNF}.ABNF([]byte(`/* from the XML specification */
+[3]  S        ::= (#x20 | #x9 | #xD | #xA)+
+[2]  Char     ::= #x9 | #xA | #xD | [#x20-#xD7FF] | [#xE000-#xFFFD] | [#x10000-#x10FFFF]
+[15] Comment  ::= '<!--' ((Char - '-') | ('-' (Char - '-')))* '-->'
+[10] AttValue ::= '"' ([^<&"] | Reference)* '"'
+                | "'" ([^<&'] | Reference)* "'" [ wfc: No < in Attribute Values ]
+     Name_Char ::= [a-zA-Z_] [a-zA-Z0-9_.]*?
+     Reference ::= '&' [a-z]+ ';'
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// code points that are not ASCII are matched by their UTF-8 encoding
+	char := "%x09 / %x0A / %x0D / %x20-7F / %xC2-DF %x80-BF / %xE0-E0 %xA0-BF %x80-BF / %xE1-EC %x80-BF %x80-BF / " +
+		"%xED-ED %x80-9F %x80-BF / %xEE-EE %x80-BF %x80-BF / %xEF-EF %x80-BE %x80-BF / %xEF.BF %x80-BD / " +
+		"%xF0-F0 %x90-BF %x80-BF %x80-BF / %xF1-F3 %x80-BF %x80-BF %x80-BF / %xF4-F4 %x80-8F %x80-BF %x80-BF"
+	expected := "S = 1*(%x20 / %x09 / %x0D / %x0A)\n" +
+		"Char = " + char + "\n" +
+		"Comment = \"<!--\" *(" + strings.Replace(char, "%x09 / %x0A / %x0D / %x20-7F", "%x09-0A / %x0D / %x20-2C / %x2E-7F", 1) +
+		" / \"-\" (" + strings.Replace(char, "%x09 / %x0A / %x0D / %x20-7F", "%x09-0A / %x0D / %x20-2C / %x2E-7F", 1) + ")) \"-->\"\n"
+	if lines := strings.Split(string(rawABNF), "\n"); len(lines) != 7 || strings.Join(lines[:3], "\n")+"\n" != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, rawABNF)
+	} else if lines[4] != `Name-Char = (%x41-5A / %x5F / %x61-7A) [*(%x2E / %x30-39 / %x41-5A / %x5F / %x61-7A)]` {
+		t.Errorf("unexpected %s", lines[4])
+	}
+
+	g := ParserGenerator{RawABNF: rawABNF}
+	comment := g.GenerateABNFAsOperators()["Comment"]
+	for _, test := range []struct {
+		s     string
+		match bool
+	}{
+		{"<!-- a - b -->", true},
+		{"<!---->", true},
+		{"<!-- é - 中 - 😀 -->", true},
+		{"<!-- a -- b -->", false},
+		// not valid UTF-8
+		{"<!-- \xff -->", false},
+		{"<!-- \xed\xa0\x80 -->", false},
+	} {
+		if match := comment([]byte(test.s)).Best().String() == test.s; match != test.match {
+			t.Errorf("%q: expected %t, got %t", test.s, test.match, match)
+		}
+	}
+
+	rawABNF, err = Importer{Notation: W3CEBNF}.ABNF([]byte(`
+Name ::= 'é' [#x61-#x7A]
+Any  ::= [^a]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(rawABNF), "Name = %xC3.A9 (%x61-7A)\n") {
+		t.Errorf("unexpected %s", rawABNF)
+	}
+	operators := (&ParserGenerator{RawABNF: rawABNF}).GenerateABNFAsOperators()
+	for _, test := range []struct {
+		rule, s string
+		match   bool
+	}{
+		{"Name", "éa", true},
+		{"Name", "\xe9a", false},
+		{"Any", "中", true},
+		{"Any", "\xe4", false},
+	} {
+		if match := operators[test.rule]([]byte(test.s)).Best().String() == test.s; match != test.match {
+			t.Errorf("%s %q: expected %t, got %t", test.rule, test.s, test.match, match)
+		}
+	}
+}
+
+func TestUTF8Sequences(t *testing.T) {
+	for _, r := range [][2]rune{{0x20, 0xD7FF}, {0x80, 0x7FF}, {0xE000, 0xFFFD}, {0x3B1, 0x10FFFF}, {0x801, 0x10001}} {
+		sequences := utf8Sequences(r[0], r[1])
+		for c := rune(0); c <= maxCodePoint; c++ {
+			if 0xD800 <= c && c <= 0xDFFF {
+				continue
+			}
+			encoded := []byte(string(c))
+			var matched bool
+			for _, sequence := range sequences {
+				if len(sequence) != len(encoded) {
+					continue
+				}
+				matched = true
+				for i, b := range sequence {
+					matched = matched && b[0] <= encoded[i] && encoded[i] <= b[1]
+				}
+				if matched {
+					break
+				}
+			}
+			if in := r[0] <= c && c <= r[1]; matched != in {
+				t.Fatalf("%X-%X: %X: expected %t, got %t", r[0], r[1], c, in, matched)
+			}
+		}
+	}
+}
+
+func TestImporterISOEBNF(t *testing.T) {
+	set, err := Importer{Notation: ISOEBNF}.Import([]byte(`(* a (* nested *) comment *)
+digit excluding zero = "1" | "2" | "3" | "4" | "5" | "6" | "7" | "8" | "9" ;
+digit = "0" | digit excluding zero ;
+natural number = digit excluding zero, { digit } ;
+integer = "0" | [ "-" ], natural number ;
+other = 2 * digit, (/ "x" /), (: "y" :), { "z" }-, ( 'q"' ! ) .
+not zero = digit - "0" ;
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := NewRuleSet([]byte(`digit-excluding-zero = "1" / "2" / "3" / "4" / "5" / "6" / "7" / "8" / "9"
+digit = "0" / digit-excluding-zero
+natural-number = digit-excluding-zero *digit
+integer = "0" / ["-"] natural-number
+other = 2digit ["x"] *"y" 1*"z" ("q" %x22 / "")
+not-zero = %x31-39
+`))
+	if len(set) != len(expected) {
+		t.Fatalf("expected %d rules, got %d", len(expected), len(set))
+	}
+	for name, rule := range expected {
+		if err := rule.Equals(set[name]); err != nil {
+			t.Errorf("%s: %s", name, err)
+		}
+	}
+}
+
+func TestImporterErrors(t *testing.T) {
+	for _, test := range []struct {
+		notation Notation
+		grammar  string
+		err      string
+	}{
+		{W3CEBNF, "a ::= 'x'\nb ::= 'y' 'z'\na ::= 'z'", "line 3: rule a is defined twice"},
+		{W3CEBNF, "a_b ::= 'x'\na-b ::= 'y'", "line 2: rules a_b and a-b are both imported as a-b"},
+		{W3CEBNF, "a ::= ('x' | 'y'", "line 1: expected )"},
+		{W3CEBNF, "a ::= 'xy' - 'x'", "a: exceptions are only supported between single characters"},
+		{W3CEBNF, "a ::= [#xD800-#xDFFF]", "a: surrogates can not be encoded as UTF-8"},
+		{ISOEBNF, "a = ? any ? ;", "line 1: special sequences are not supported"},
+		{ISOEBNF, "a = 'x'\nb = 'y';", "line 2: expected ; at the end of rule a"},
+		{ISOEBNF, "1a = 'x';", "line 1: expected a rule name"},
+		{PEG, "a <- 'x'", "importing PEG is not supported"},
+	} {
+		if _, err := (Importer{Notation: test.notation}).ABNF([]byte(test.grammar)); err == nil || err.Error() != test.err {
+			t.Errorf("%q: expected %q, got %v", test.grammar, test.err, err)
+		}
+	}
+}
+
+func TestImporterRoundTrip(t *testing.T) {
+	rawABNF := []byte(strings.Join([]string{
+		`word = 1*(ALPHA / "-") [2*3DIGIT]`,
+		`ALPHA = %x41-5A / %x61-7A`,
+		`DIGIT = %x30-39`,
+	}, "\n") + "\n")
+	set := NewRuleSet(rawABNF)
+	original := (&ParserGenerator{RawABNF: rawABNF}).GenerateABNFAsOperators()["word"]
+	for _, notation := range []Notation{ISOEBNF, W3CEBNF} {
+		b := &bytes.Buffer{}
+		if _, err := (Exporter{Notation: notation}).Export(b, set); err != nil {
+			t.Fatal(err)
+		}
+		imported, err := Importer{Notation: notation}.ABNF(b.Bytes())
+		if err != nil {
+			t.Fatalf("%s: %s", notation, err)
+		}
+		word := (&ParserGenerator{RawABNF: imported}).GenerateABNFAsOperators()["word"]
+		for _, s := range []string{"abc", "a-b12", "x123", "x1234"} {
+			expected := original([]byte(s)).Best().String()
+			if got := word([]byte(s)).Best().String(); got != expected {
+				t.Errorf("%s: %q: expected %q, got %q", notation, s, expected, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package abnf
+
+import "sort"
+
+// RuleRef refers to a rule of a set, so rules of different sets can be compared. e.g. CRLF of the lenient and the
+// strict core rules.
+type RuleRef struct {
+	Set  RuleSet
+	Name string
+}
+
+// Included returns whether every input that is accepted by the first rule is also accepted by the second one. If not,
+// the shortest input that is only accepted by the first rule is returned. Both rules are compiled to DFAs, so they need
+// to be regular (see DFA), use IncludedUpTo for recursive rules.
+func Included(a, b RuleRef) (bool, []byte, error) {
+	aDFA, err := a.Set.DFA(a.Name)
+	if err != nil {
+		return false, nil, err
+	}
+	bDFA, err := b.Set.DFA(b.Name)
+	if err != nil {
+		return false, nil, err
+	}
+	counterexample, ok := difference(aDFA, bDFA)
+	return !ok, counterexample, nil
+}
+
+// Equivalent returns whether both rules accept exactly the same inputs. If not, the shortest input that is only
+// accepted by one of them is returned. Both rules need to be regular, use EquivalentUpTo for recursive rules.
+func Equivalent(a, b RuleRef) (bool, []byte, error) {
+	aDFA, err := a.Set.DFA(a.Name)
+	if err != nil {
+		return false, nil, err
+	}
+	bDFA, err := b.Set.DFA(b.Name)
+	if err != nil {
+		return false, nil, err
+	}
+	onlyA, okA := difference(aDFA, bDFA)
+	onlyB, okB := difference(bDFA, aDFA)
+	switch {
+	case okA && (!okB || len(onlyA) <= len(onlyB)):
+		return false, onlyA, nil
+	case okB:
+		return false, onlyB, nil
+	default:
+		return true, nil, nil
+	}
+}
+
+// IncludedUpTo is the bounded version of Included, only inputs of at most the given length are considered. It also
+// supports recursive rules, but it enumerates all the accepted inputs, so it is exponential in the length. Bytes that
+// can not be distinguished by any of the terminals of both sets are only tried once. An error is returned if an operator
+// accepts too many inputs (like FindAmbiguities).
+func IncludedUpTo(a, b RuleRef, length int) (bool, []byte, error) {
+	aStrings, bStrings, err := boundedLanguages(a, b, length)
+	if err != nil {
+		return false, nil, err
+	}
+	if counterexample, ok := shortestDifference(aStrings, bStrings); ok {
+		return false, counterexample, nil
+	}
+	return true, nil, nil
+}
+
+// EquivalentUpTo is the bounded version of Equivalent, only inputs of at most the given length are considered.
+func EquivalentUpTo(a, b RuleRef, length int) (bool, []byte, error) {
+	aStrings, bStrings, err := boundedLanguages(a, b, length)
+	if err != nil {
+		return false, nil, err
+	}
+	onlyA, okA := shortestDifference(aStrings, bStrings)
+	onlyB, okB := shortestDifference(bStrings, aStrings)
+	switch {
+	case okA && (!okB || len(onlyA) <= len(onlyB)):
+		return false, onlyA, nil
+	case okB:
+		return false, onlyB, nil
+	default:
+		return true, nil, nil
+	}
+}
+
+// shortestDifference returns the shortest (and then smallest) input that is part of the first set, but not of the
+// second one.
+func shortestDifference(a, b map[string]struct{}) ([]byte, bool) {
+	var difference []string
+	for s := range a {
+		if !contains(b, s) {
+			difference = append(difference, s)
+		}
+	}
+	if len(difference) == 0 {
+		return nil, false
+	}
+	sort.Slice(difference, func(i, j int) bool {
+		if len(difference[i]) != len(difference[j]) {
+			return len(difference[i]) < len(difference[j])
+		}
+		return difference[i] < difference[j]
+	})
+	return []byte(difference[0]), true
+}
+
+// boundedLanguages returns the inputs of at most the given length that are accepted by both rules, see language. The
+// representatives are shared, so they are the same bytes in both languages.
+func boundedLanguages(a, b RuleRef, length int) (map[string]struct{}, map[string]struct{}, error) {
+	representatives, err := representatives(a.Set, b.Set)
+	if err != nil {
+		return nil, nil, err
+	}
+	aLanguage, err := newRulesLanguage(a.Set, []string{a.Name}, length, representatives)
+	if err != nil {
+		return nil, nil, err
+	}
+	bLanguage, err := newRulesLanguage(b.Set, []string{b.Name}, length, representatives)
+	if err != nil {
+		return nil, nil, err
+	}
+	return aLanguage.rules[a.Name], bLanguage.rules[b.Name], nil
+}
@@ -0,0 +1,95 @@
+package abnf
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIncluded(t *testing.T) {
+	lenient, strict := CoreRules(false), CoreRules(true)
+	for _, test := range []struct {
+		a, b           RuleRef
+		included       bool
+		counterexample string
+	}{
+		{RuleRef{strict, "CRLF"}, RuleRef{lenient, "CRLF"}, true, ""},
+		{RuleRef{lenient, "CRLF"}, RuleRef{strict, "CRLF"}, false, "\n"},
+		{RuleRef{lenient, "HEXDIG"}, RuleRef{strict, "HEXDIG"}, false, "a"},
+		{RuleRef{lenient, "DIGIT"}, RuleRef{lenient, "HEXDIG"}, true, ""},
+	} {
+		included, counterexample, err := Included(test.a, test.b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if included != test.included || string(counterexample) != test.counterexample {
+			t.Errorf("%s: expected %t %q, got %t %q", test.a.Name, test.included, test.counterexample, included, counterexample)
+		}
+	}
+
+	if _, _, err := Included(RuleRef{lenient, "CRLF"}, RuleRef{lenient, "unknown"}); err == nil {
+		t.Error("expected an error for an unknown rule")
+	}
+}
+
+func TestEquivalent(t *testing.T) {
+	rfc := NewRuleSet([]byte(strings.Join([]string{
+		`token = 1*tchar`,
+		"tchar = \"!\" / \"#\" / \"$\" / \"%\" / \"&\" / \"'\" / \"*\" / \"+\" / \"-\" / \".\" / \"^\" / \"_\" / \"`\" / \"|\" / \"~\" / DIGIT / ALPHA",
+		`DIGIT = %x30-39`,
+		`ALPHA = %x41-5A / %x61-7A`,
+	}, "\n") + "\n"))
+	simplified := NewRuleSet([]byte(strings.Join([]string{
+		`token = 1*(%x21 / %x23-27 / %x2A-2B / %x2D-2E / %x30-39 / %x41-5A / %x5E-7A / %x7C / %x7E)`,
+		`short = 1*(%x30-39 / %x41-5A / %x61-7A)`,
+	}, "\n") + "\n"))
+
+	if equivalent, counterexample, err := Equivalent(RuleRef{rfc, "token"}, RuleRef{simplified, "token"}); err != nil || !equivalent {
+		t.Errorf("expected the tokens to be equivalent, got %q %v", counterexample, err)
+	}
+	if equivalent, counterexample, err := Equivalent(RuleRef{rfc, "token"}, RuleRef{simplified, "short"}); err != nil || equivalent || string(counterexample) != "!" {
+		t.Errorf("expected counterexample \"!\", got %t %q %v", equivalent, counterexample, err)
+	}
+}
+
+func TestIncludedUpTo(t *testing.T) {
+	set := NewRuleSet([]byte(strings.Join([]string{
+		`balanced = *("(" balanced ")")`,
+		`nested = "(" nested ")" / ""`,
+		`parens = *("(" / ")")`,
+	}, "\n") + "\n"))
+
+	if _, _, err := Included(RuleRef{set, "balanced"}, RuleRef{set, "parens"}); err == nil {
+		t.Error("expected an error for a recursive rule")
+	}
+	for _, test := range []struct {
+		a, b           string
+		included       bool
+		counterexample string
+	}{
+		{"balanced", "parens", true, ""},
+		{"nested", "balanced", true, ""},
+		{"parens", "balanced", false, "("},
+		{"balanced", "nested", false, "()()"},
+	} {
+		included, counterexample, err := IncludedUpTo(RuleRef{set, test.a}, RuleRef{set, test.b}, 6)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if included != test.included || string(counterexample) != test.counterexample {
+			t.Errorf("%s in %s: expected %t %q, got %t %q", test.a, test.b, test.included, test.counterexample, included, counterexample)
+		}
+	}
+
+	if equivalent, counterexample, err := EquivalentUpTo(RuleRef{set, "balanced"}, RuleRef{set, "nested"}, 6); err != nil || equivalent || string(counterexample) != "()()" {
+		t.Errorf("expected counterexample \"()()\", got %t %q %v", equivalent, counterexample, err)
+	}
+	// 27 groups of bytes, the languages grow too large
+	letters := NewRuleSet([]byte("word = *ALPHA\nALPHA = %x41-5A / %x61-7A / \"a\" / \"b\" / \"c\" / \"d\" / \"e\" / \"f\" / \"g\" / \"h\" / \"i\" / \"j\" / \"k\" / \"l\" / \"m\" / \"n\" / \"o\" / \"p\" / \"q\" / \"r\" / \"s\" / \"t\" / \"u\" / \"v\" / \"w\" / \"x\" / \"y\" / \"z\"\n"))
+	if _, _, err := IncludedUpTo(RuleRef{letters, "word"}, RuleRef{letters, "word"}, 8); err == nil {
+		t.Error("expected an error for too many inputs")
+	}
+	lenient, strict := CoreRules(false), CoreRules(true)
+	if equivalent, counterexample, err := EquivalentUpTo(RuleRef{lenient, "LWSP"}, RuleRef{strict, "LWSP"}, 4); err != nil || equivalent || string(counterexample) != "\n\t" {
+		t.Errorf("expected counterexample \"\\n\\t\", got %t %q %v", equivalent, counterexample, err)
+	}
+}
@@ -0,0 +1,249 @@
+package abnf
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+
+	"github.com/elimity-com/abnf/definition"
+	"github.com/elimity-com/abnf/operators"
+)
+
+// importDirective matches an import directive, e.g. "; @import rfc3986.abnf as uri".
+var importDirective = regexp.MustCompile(`(?m)^[ \t]*;[ \t]*@import[ \t]+(\S+)(?:[ \t]+as[ \t]+([A-Za-z][A-Za-z0-9-]*))?[ \t]*\r?$`)
+
+// Loader loads grammars that consist of multiple ABNF files. Files import other grammars with a directive in a comment,
+// so they remain valid ABNF:
+//
+//	; @import core
+//	; @import rfc3986.abnf as uri
+//
+// Named grammars are looked up in Grammars ("core" and "core-strict" are always available), other imports are read as
+// files relative to the importing file. The rules of a grammar that is imported with "as" get prefixed, e.g. uri-host.
+type Loader struct {
+	// ReadFile reads the file with the given name, it defaults to ioutil.ReadFile.
+	ReadFile func(name string) ([]byte, error)
+	// Grammars that can be imported by name.
+	Grammars map[string][]byte
+}
+
+// Grammar is a set of rules that is combined from multiple files.
+type Grammar struct {
+	Rules RuleSet
+	// Sources of the rules, by rule name.
+	Sources map[string]Source
+	// Files that were loaded, imported files come before the files that import them.
+	Files []string
+
+	rawABNF map[string][]byte
+}
+
+// Source is the location of the definition of a rule.
+type Source struct {
+	File string
+	Line int
+}
+
+func (s Source) String() string {
+	return fmt.Sprintf("%s:%d", s.File, s.Line)
+}
+
+// Load loads the given files and all the grammars they import. Rules can only be defined once and all the references
+// need to be defined in one of the loaded files.
+func (l Loader) Load(files ...string) (*Grammar, error) {
+	x := &loader{
+		Loader: l,
+		grammar: &Grammar{
+			Rules:   make(RuleSet),
+			Sources: make(map[string]Source),
+			rawABNF: make(map[string][]byte),
+		},
+		loaded: make(map[string]bool),
+	}
+	for _, file := range files {
+		if err := x.load(file, ""); err != nil {
+			return nil, err
+		}
+	}
+	if err := x.grammar.check(); err != nil {
+		return nil, err
+	}
+	return x.grammar, nil
+}
+
+// RawABNF returns the (prefixed) rules of the given files as a single ABNF text, which can be used as the RawABNF of
+// both generators. If no files are given, the rules of all the files are returned.
+func (g *Grammar) RawABNF(files ...string) []byte {
+	if len(files) == 0 {
+		files = g.Files
+	}
+	var b bytes.Buffer
+	for _, file := range files {
+		if raw, ok := g.rawABNF[file]; ok {
+			fmt.Fprintf(&b, "; %s\n", file)
+			b.Write(raw)
+		}
+	}
+	return b.Bytes()
+}
+
+// ExternalABNF returns references to the rules of the given files, to be used as the ExternalABNF of the CodeGenerator.
+// e.g. map the rules of "core" to github.com/elimity-com/abnf/core and only generate the other files.
+func (g *Grammar) ExternalABNF(files map[string]ExternalABNF) map[string]ExternalABNF {
+	external := make(map[string]ExternalABNF)
+	for name, source := range g.Sources {
+		if pkg, ok := files[source.File]; ok {
+			external[name] = pkg
+		}
+	}
+	return external
+}
+
+// check returns an error for the first reference to an undefined rule.
+func (g *Grammar) check() error {
+	for _, file := range g.Files {
+		set := NewRuleSet(g.rawABNF[file])
+		for _, name := range set.names() {
+			if err := walkOperators(set[name].operator, func(operator Operator) error {
+				if reference, ok := operator.(RuleNameOperator); ok {
+					if _, ok := g.Rules[reference.key]; !ok {
+						return fmt.Errorf("%s: undefined rule %s referenced by %s", g.Sources[name], reference.key, name)
+					}
+				}
+				return nil
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// loader contains the state of a single load.
+type loader struct {
+	Loader
+	grammar *Grammar
+	// loaded files, by file name and prefix
+	loaded map[string]bool
+}
+
+func (l *loader) read(file string) ([]byte, error) {
+	if raw, ok := l.Grammars[file]; ok {
+		return raw, nil
+	}
+	switch file {
+	case "core":
+		return []byte(coreABNF), nil
+	case "core-strict":
+		return []byte(strictCoreABNF), nil
+	}
+	if l.ReadFile != nil {
+		return l.ReadFile(file)
+	}
+	return ioutil.ReadFile(file)
+}
+
+// resolve returns the name of the imported file, relative to the importing file.
+func (l *loader) resolve(file, imported string) string {
+	if _, ok := l.Grammars[imported]; ok || imported == "core" || imported == "core-strict" {
+		return imported
+	}
+	if filepath.IsAbs(imported) {
+		return imported
+	}
+	return filepath.Join(filepath.Dir(file), imported)
+}
+
+// load loads the given file (with the given prefix) and all the files it imports.
+func (l *loader) load(file, prefix string) error {
+	key := file + " as " + prefix
+	if l.loaded[key] {
+		return nil
+	}
+	l.loaded[key] = true
+
+	raw, err := l.read(file)
+	if err != nil {
+		return err
+	}
+	if 0 < len(raw) && raw[len(raw)-1] != '\n' {
+		raw = append(append([]byte{}, raw...), '\n')
+	}
+	for _, directive := range importDirective.FindAllSubmatch(raw, -1) {
+		if err := l.load(l.resolve(file, string(directive[1])), string(directive[2])); err != nil {
+			return err
+		}
+	}
+
+	tree := definition.Rulelist(raw).Best()
+	if len(tree.Value) != len(raw) {
+		return fmt.Errorf("%s: invalid ABNF", Source{File: file, Line: lineNumber(raw, len(tree.Value))})
+	}
+
+	// names of the rules that are defined in this file, with their source
+	defined := make(map[string]Source)
+	var names []string
+	for _, child := range tree.Children {
+		if rawRule := child.GetSubRule("rule"); rawRule != nil {
+			name := rawRule.GetSubRule("rulename").String()
+			source := Source{File: file, Line: lineNumber(raw, cap(raw)-cap(rawRule.Value))}
+			if other, ok := defined[name]; ok {
+				return fmt.Errorf("rule %s is defined in %s and %s", name, other, source)
+			}
+			defined[name] = source
+			names = append(names, name)
+		}
+	}
+	if prefix != "" {
+		raw = rename(raw, tree, func(name string) string {
+			if _, ok := defined[name]; ok {
+				return prefix + "-" + name
+			}
+			return name
+		})
+	}
+
+	set := NewRuleSet(raw)
+	for _, name := range names {
+		source := defined[name]
+		if prefix != "" {
+			name = prefix + "-" + name
+		}
+		if other, ok := l.grammar.Sources[name]; ok {
+			return fmt.Errorf("rule %s is defined in %s and %s", name, other, source)
+		}
+		l.grammar.Rules[name] = set[name]
+		l.grammar.Sources[name] = source
+	}
+	if _, ok := l.grammar.rawABNF[file]; ok {
+		// the same file with another prefix
+		file = key
+	}
+	l.grammar.Files = append(l.grammar.Files, file)
+	l.grammar.rawABNF[file] = raw
+	return nil
+}
+
+// rename replaces all the rule names within the given (parsed) ABNF.
+func rename(raw []byte, tree *operators.Node, f func(name string) string) []byte {
+	var renamed []byte
+	var offset int
+	operators.Inspect(tree, func(node *operators.Node) bool {
+		if !node.IsRule() || node.Key != "rulename" {
+			return true
+		}
+		start := cap(raw) - cap(node.Value)
+		renamed = append(renamed, raw[offset:start]...)
+		renamed = append(renamed, f(string(node.Value))...)
+		offset = start + len(node.Value)
+		return false
+	})
+	return append(renamed, raw[offset:]...)
+}
+
+// lineNumber returns the line number of the given offset.
+func lineNumber(raw []byte, offset int) int {
+	return 1 + bytes.Count(raw[:offset], []byte("\n"))
+}
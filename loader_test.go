@@ -0,0 +1,99 @@
+package abnf
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// files returns a function that reads the given files.
+func files(files map[string]string) func(name string) ([]byte, error) {
+	return func(name string) ([]byte, error) {
+		raw, ok := files[filepath.ToSlash(name)]
+		if !ok {
+			return nil, fmt.Errorf("file not found: %s", name)
+		}
+		return []byte(raw), nil
+	}
+}
+
+func TestLoader(t *testing.T) {
+	l := Loader{
+		ReadFile: files(map[string]string{
+			"http/message.abnf": strings.Join([]string{
+				"; @import core",
+				"; @import ../uri/uri.abnf as uri",
+				"request-line = method SP uri-path CRLF",
+				"method = 1*ALPHA",
+			}, "\n"),
+			"uri/uri.abnf": strings.Join([]string{
+				"; @import core",
+				"path = *(\"/\" segment)",
+				"segment = *(ALPHA / DIGIT)",
+			}, "\n"),
+		}),
+	}
+	g, err := l.Load("http/message.abnf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"core", filepath.FromSlash("uri/uri.abnf"), filepath.FromSlash("http/message.abnf")}; !reflect.DeepEqual(g.Files, expected) {
+		t.Errorf("expected files %v, got %v", expected, g.Files)
+	}
+	for name, line := range map[string]int{"request-line": 3, "method": 4, "uri-path": 2, "uri-segment": 3, "CRLF": 7} {
+		if source := g.Sources[name]; source.Line != line {
+			t.Errorf("%s: expected line %d, got %s", name, line, source)
+		}
+	}
+	if _, ok := g.Rules["path"]; ok {
+		t.Error("expected the rules of uri to be prefixed")
+	}
+
+	parser := ParserGenerator{RawABNF: g.RawABNF()}
+	requestLine := parser.GenerateABNFAsOperators()["request-line"]
+	if s := "GET /a/b1\r\n"; string(requestLine([]byte(s)).Best().Value) != s {
+		t.Errorf("no match for %q", s)
+	}
+
+	external := g.ExternalABNF(map[string]ExternalABNF{"core": CoreExternalABNF(false)["ALPHA"]})
+	if len(external) != len(CoreRules(false)) || external["CRLF"].PackagePath != corePkgPath {
+		t.Errorf("invalid external ABNF: %v", external)
+	}
+	if raw := string(g.RawABNF(filepath.FromSlash("uri/uri.abnf"))); !strings.Contains(raw, "uri-path = *(\"/\" uri-segment)") {
+		t.Errorf("invalid raw ABNF: %s", raw)
+	}
+}
+
+func TestLoaderErrors(t *testing.T) {
+	for _, test := range []struct {
+		files map[string]string
+		err   string
+	}{
+		{
+			files: map[string]string{"a": "; @import b\nrule = \"a\"\n", "b": "rule = \"b\"\n"},
+			err:   "rule rule is defined in b:1 and a:2",
+		},
+		{
+			files: map[string]string{"a": "rule = \"a\"\nrule = \"b\"\n"},
+			err:   "rule rule is defined in a:1 and a:2",
+		},
+		{
+			files: map[string]string{"a": "; @import core\nrule = ALPHA other\n"},
+			err:   "a:2: undefined rule other referenced by rule",
+		},
+		{
+			files: map[string]string{"a": "rule = \"a\"\nrule \"b\"\n"},
+			err:   "a:2: invalid ABNF",
+		},
+		{
+			files: map[string]string{"a": "; @import b\n"},
+			err:   "file not found: b",
+		},
+	} {
+		if _, err := (Loader{ReadFile: files(test.files)}).Load("a"); err == nil || err.Error() != test.err {
+			t.Errorf("expected %q, got %v", test.err, err)
+		}
+	}
+}
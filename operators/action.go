@@ -0,0 +1,55 @@
+package operators
+
+import "fmt"
+
+// Action builds a (user) value for the given node out of the values that were built for its sub nodes.
+type Action func(node *Node, values []interface{}) (interface{}, error)
+
+// Actions maps keys (e.g. rule names) to the actions that need to be run on the nodes with that key.
+type Actions map[string]Action
+
+// Evaluate runs the actions bottom-up on the given parse tree. Nodes without an action pass the values of their sub
+// nodes on to their parent. If the given node has no action, all values of its sub nodes are returned as an
+// []interface{}.
+func (as Actions) Evaluate(node *Node) (interface{}, error) {
+	values, err := as.evaluate(node)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := as[node.Key]; ok {
+		return values[0], nil
+	}
+	return values, nil
+}
+
+// evaluate returns the values that the given node passes on to its parent.
+func (as Actions) evaluate(node *Node) ([]interface{}, error) {
+	var values []interface{}
+	for _, child := range node.Children {
+		childValues, err := as.evaluate(child)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, childValues...)
+	}
+
+	action, ok := as[node.Key]
+	if !ok {
+		return values, nil
+	}
+	value, err := action(node, values)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", node.Key, err)
+	}
+	return []interface{}{value}, nil
+}
+
+// Parse parses the given input with the given operator and evaluates the actions on the longest parse tree that
+// matches the whole input.
+func Parse(operator Operator, s []byte, actions Actions) (interface{}, error) {
+	best := operator(s).Best()
+	if len(best.Value) != len(s) {
+		return nil, fmt.Errorf("no match found for the whole input: matched %d of %d bytes", len(best.Value), len(s))
+	}
+	return actions.Evaluate(best)
+}
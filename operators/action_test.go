@@ -0,0 +1,82 @@
+package operators
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestActions(t *testing.T) {
+	digit := Range(`digit`, []byte("0"), []byte("9"))
+	number := Repeat1Inf(`number`, digit)
+	sum := Concat(`sum`,
+		number,
+		Repeat0Inf(`*("+" number)`, Concat(`"+" number`,
+			String(`+`, "+"),
+			number,
+		)),
+	)
+
+	actions := Actions{
+		"digit": func(node *Node, _ []interface{}) (interface{}, error) {
+			return int(node.Value[0] - '0'), nil
+		},
+		"number": func(_ *Node, values []interface{}) (interface{}, error) {
+			var n int
+			for _, value := range values {
+				n = n*10 + value.(int)
+			}
+			return n, nil
+		},
+		"sum": func(_ *Node, values []interface{}) (interface{}, error) {
+			var n int
+			for _, value := range values {
+				n += value.(int)
+			}
+			return n, nil
+		},
+	}
+
+	for str, expected := range map[string]int{
+		"1":          1,
+		"12+30":      42,
+		"1+2+3+4+10": 20,
+	} {
+		value, err := Parse(sum, []byte(str), actions)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if value != expected {
+			t.Errorf("%s: expected %d, got %v", str, expected, value)
+		}
+	}
+
+	if _, err := Parse(sum, []byte("1+"), actions); err == nil {
+		t.Error("expected an error for a partial match")
+	}
+
+	t.Run("Error", func(t *testing.T) {
+		_, err := Parse(sum, []byte("1"), Actions{
+			"number": func(_ *Node, _ []interface{}) (interface{}, error) {
+				return nil, fmt.Errorf("invalid")
+			},
+		})
+		if err == nil || err.Error() != "number: invalid" {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("NoAction", func(t *testing.T) {
+		value, err := Parse(sum, []byte("1+2"), Actions{
+			"number": actions["number"],
+			"digit":  actions["digit"],
+		})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if values, ok := value.([]interface{}); !ok || len(values) != 2 {
+			t.Errorf("expected the values of both numbers, got %v", value)
+		}
+	})
+}
@@ -0,0 +1,97 @@
+package operators
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Divergence describes where two parse trees of the same input diverge.
+type Divergence struct {
+	// Path contains the keys of the (shared) ancestors of the diverging nodes, starting at the root.
+	Path []string
+	// Offset of the diverging nodes within the input.
+	Offset int
+	// A and B are the diverging nodes.
+	A, B *Node
+}
+
+func (d Divergence) String() string {
+	return fmt.Sprintf(
+		"offset %d (%s): %s %q / %s %q",
+		d.Offset, strings.Join(d.Path, " > "), d.A.Key, d.A.Value, d.B.Key, d.B.Value,
+	)
+}
+
+// AmbiguityReport contains all the distinct parse trees that match the whole input.
+type AmbiguityReport struct {
+	Trees Alternatives
+	// Divergences between the first tree and each of the other trees.
+	Divergences []Divergence
+}
+
+// Ambiguous returns whether the input has more than one parse tree.
+func (r AmbiguityReport) Ambiguous() bool {
+	return 1 < len(r.Trees)
+}
+
+func (r AmbiguityReport) String() string {
+	str := fmt.Sprintf("%d parse tree(s)", len(r.Trees))
+	for i, divergence := range r.Divergences {
+		str += fmt.Sprintf("\n0-%d: %s", i+1, divergence)
+	}
+	return str
+}
+
+// Ambiguities parses the given input with the given operator and reports all the distinct parse trees that match the
+// whole input, together with the places where they diverge.
+func Ambiguities(operator Operator, s []byte) AmbiguityReport {
+	var report AmbiguityReport
+	for _, node := range operator(s) {
+		if len(node.Value) != len(s) || report.Trees.contains(node) {
+			continue
+		}
+		report.Trees = append(report.Trees, node)
+	}
+	for _, other := range report.Trees[min(1, len(report.Trees)):] {
+		if divergence := diverge(report.Trees[0], other, nil, 0); divergence != nil {
+			report.Divergences = append(report.Divergences, *divergence)
+		}
+	}
+	return report
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// contains returns whether an equal node is part of the alternatives.
+func (as Alternatives) contains(node *Node) bool {
+	for _, a := range as {
+		if a.Equals(node) == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// diverge returns the first place where both trees diverge, or nil if they are equal.
+func diverge(a, b *Node, path []string, offset int) *Divergence {
+	if a.Key != b.Key || a.Kind != b.Kind || string(a.Value) != string(b.Value) || len(a.Children) != len(b.Children) {
+		return &Divergence{
+			Path:   path,
+			Offset: offset,
+			A:      a, B: b,
+		}
+	}
+	path = append(path[:len(path):len(path)], a.Key)
+	for i, child := range a.Children {
+		if d := diverge(child, b.Children[i], path, offset); d != nil {
+			return d
+		}
+		offset += len(child.Value)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package operators
+
+import (
+	"testing"
+)
+
+func TestAmbiguities(t *testing.T) {
+	rule := Concat(`*a *a`, Repeat0Inf(`*a1`, a), Repeat0Inf(`*a2`, a))
+
+	report := Ambiguities(rule, []byte("ba"))
+	if len(report.Trees) != 0 || report.Ambiguous() {
+		t.Errorf("expected no trees, got %d", len(report.Trees))
+	}
+
+	report = Ambiguities(rule, []byte("a"))
+	if !report.Ambiguous() || len(report.Trees) != 2 {
+		t.Errorf("expected two trees, got %d", len(report.Trees))
+		return
+	}
+	if len(report.Divergences) != 1 {
+		t.Errorf("expected one divergence, got %d", len(report.Divergences))
+		return
+	}
+	d := report.Divergences[0]
+	if d.Offset != 0 || len(d.Path) != 1 || d.Path[0] != "*a *a" || d.A.Key != "*a1" {
+		t.Errorf("invalid divergence: %s", d)
+	}
+
+	report = Ambiguities(Concat(`a b`, a, Alts(`b / b`, b, b)), []byte("ab"))
+	if len(report.Trees) != 1 {
+		t.Errorf("expected duplicates to be removed, got %d trees", len(report.Trees))
+	}
+
+	report = Ambiguities(Concat(`a (b / b)`, a, Alts(`b / b`, b, Concat(`b`, b))), []byte("ab"))
+	if !report.Ambiguous() {
+		t.Error("expected an ambiguous input")
+		return
+	}
+	if d := report.Divergences[0]; d.Offset != 1 || d.A.Kind != KindTerminal || d.B.Kind != KindConcatenation {
+		t.Errorf("invalid divergence: %s", d)
+	}
+}
@@ -0,0 +1,70 @@
+package operators
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ByteSet is a set of bytes, e.g. the bytes a rule can start with.
+type ByteSet [4]uint64
+
+// Add adds the given byte to the set.
+func (s *ByteSet) Add(b byte) {
+	s[b/64] |= 1 << (b % 64)
+}
+
+// AddRange adds all the bytes from low up to and including high to the set.
+func (s *ByteSet) AddRange(low, high byte) {
+	for b := int(low); b <= int(high); b++ {
+		s.Add(byte(b))
+	}
+}
+
+// Contains returns whether the given byte is part of the set.
+func (s ByteSet) Contains(b byte) bool {
+	return s[b/64]&(1<<(b%64)) != 0
+}
+
+// Union returns the set of bytes that are part of either set.
+func (s ByteSet) Union(other ByteSet) ByteSet {
+	for i := range s {
+		s[i] |= other[i]
+	}
+	return s
+}
+
+// Intersects returns whether both sets have a byte in common.
+func (s ByteSet) Intersects(other ByteSet) bool {
+	for i := range s {
+		if s[i]&other[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// IsEmpty returns whether the set does not contain any bytes.
+func (s ByteSet) IsEmpty() bool {
+	return s == ByteSet{}
+}
+
+// String returns the set as an ABNF alternation of numeric values, e.g. %x30-39 / %x41.
+func (s ByteSet) String() string {
+	var values []string
+	for b := 0; b < 256; b++ {
+		if !s.Contains(byte(b)) {
+			continue
+		}
+		high := b
+		for high < 255 && s.Contains(byte(high+1)) {
+			high++
+		}
+		if b == high {
+			values = append(values, fmt.Sprintf("%%x%02X", b))
+		} else {
+			values = append(values, fmt.Sprintf("%%x%02X-%02X", b, high))
+		}
+		b = high
+	}
+	return strings.Join(values, " / ")
+}
@@ -0,0 +1,31 @@
+package operators
+
+import "testing"
+
+func TestByteSet(t *testing.T) {
+	var digits, letters ByteSet
+	digits.AddRange('0', '9')
+	letters.AddRange('A', 'Z')
+	letters.Add('_')
+	if !digits.Contains('5') || digits.Contains('A') {
+		t.Error("invalid digits")
+	}
+	if digits.Intersects(letters) {
+		t.Error("digits and letters do not intersect")
+	}
+	union := digits.Union(letters)
+	if !union.Contains('5') || !union.Contains('_') || union.Contains('a') {
+		t.Error("invalid union")
+	}
+	if s := union.String(); s != "%x30-39 / %x41-5A / %x5F" {
+		t.Errorf("invalid string: %s", s)
+	}
+	var all ByteSet
+	all.AddRange(0, 255)
+	if s := all.String(); s != "%x00-FF" {
+		t.Errorf("invalid string: %s", s)
+	}
+	if !(ByteSet{}).IsEmpty() || all.IsEmpty() {
+		t.Error("invalid empty set")
+	}
+}
@@ -0,0 +1,102 @@
+package operators
+
+// DFA is a deterministic finite automaton over bytes, it matches (regular) rules without building a parse tree.
+// The first state is the start state.
+type DFA struct {
+	States []DFAState
+}
+
+// DFAState is a state of a DFA.
+type DFAState struct {
+	Accepting bool
+	// Edges are sorted and do not overlap.
+	Edges []DFAEdge
+}
+
+// DFAEdge is a transition to the target state on all bytes from low up to and including high.
+type DFAEdge struct {
+	Low, High byte
+	Target    int
+}
+
+// next returns the state after reading the given byte, or -1 if there is no such state.
+func (d *DFA) next(state int, b byte) int {
+	edges := d.States[state].Edges
+	// binary search
+	i, j := 0, len(edges)
+	for i < j {
+		h := int(uint(i+j) >> 1)
+		if edges[h].High < b {
+			i = h + 1
+		} else {
+			j = h
+		}
+	}
+	if i < len(edges) && edges[i].Low <= b {
+		return edges[i].Target
+	}
+	return -1
+}
+
+// Match returns the lengths of all the prefixes of the input that are accepted, longest first.
+func (d *DFA) Match(s []byte) []int {
+	lengths := d.AppendMatches(nil, s)
+	// longest first
+	for i, j := 0, len(lengths)-1; i < j; i, j = i+1, j-1 {
+		lengths[i], lengths[j] = lengths[j], lengths[i]
+	}
+	return lengths
+}
+
+// AppendMatches appends the lengths of all the prefixes of the input that are accepted to the given slice, shortest
+// first. It does not allocate if the slice has enough capacity.
+func (d *DFA) AppendMatches(lengths []int, s []byte) []int {
+	if len(d.States) == 0 {
+		return lengths
+	}
+	state := 0
+	for i := 0; ; i++ {
+		if d.States[state].Accepting {
+			lengths = append(lengths, i)
+		}
+		if i == len(s) {
+			break
+		}
+		if state = d.next(state, s[i]); state < 0 {
+			break
+		}
+	}
+	return lengths
+}
+
+// Accepts returns whether the whole input is accepted.
+func (d *DFA) Accepts(s []byte) bool {
+	if len(d.States) == 0 {
+		return false
+	}
+	state := 0
+	for _, b := range s {
+		if state = d.next(state, b); state < 0 {
+			return false
+		}
+	}
+	return d.States[state].Accepting
+}
+
+// Operator returns an operator that matches the rule with the given name. It returns a single node per accepted prefix
+// (longest first), without children. So unlike the operators of the rule, different parse trees of the same prefix are
+// not distinguished.
+func (d *DFA) Operator(name string) Operator {
+	return func(s []byte) Alternatives {
+		var nodes Alternatives
+		for _, l := range d.Match(s) {
+			nodes = append(nodes, &Node{
+				Key:     name,
+				Kind:    KindRule,
+				Element: Element{Rule: name},
+				Value:   s[:l],
+			})
+		}
+		return nodes
+	}
+}
@@ -0,0 +1,44 @@
+package operators
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDFA(t *testing.T) {
+	// 1*DIGIT ["." 1*DIGIT]
+	dfa := &DFA{States: []DFAState{
+		{Edges: []DFAEdge{{Low: '0', High: '9', Target: 1}}},
+		{Accepting: true, Edges: []DFAEdge{{Low: '.', High: '.', Target: 2}, {Low: '0', High: '9', Target: 1}}},
+		{Edges: []DFAEdge{{Low: '0', High: '9', Target: 3}}},
+		{Accepting: true, Edges: []DFAEdge{{Low: '0', High: '9', Target: 3}}},
+	}}
+
+	for _, test := range []struct {
+		input   string
+		lengths []int
+	}{
+		{"", nil},
+		{"1", []int{1}},
+		{"12.5x", []int{4, 2, 1}},
+		{"1.", []int{1}},
+		{"a1", nil},
+	} {
+		if lengths := dfa.Match([]byte(test.input)); !reflect.DeepEqual(lengths, test.lengths) {
+			t.Errorf("%q: expected %v, got %v", test.input, test.lengths, lengths)
+		}
+	}
+
+	if !dfa.Accepts([]byte("12.5")) || dfa.Accepts([]byte("12.")) {
+		t.Error("invalid acceptance")
+	}
+
+	nodes := dfa.Operator("number")([]byte("1.2"))
+	if len(nodes) != 2 {
+		t.Errorf("expected two nodes, got %d", len(nodes))
+		return
+	}
+	if node := nodes[0]; node.Key != "number" || !node.IsRule() || node.String() != "1.2" || node.Children != nil {
+		t.Errorf("invalid node: %v", node)
+	}
+}
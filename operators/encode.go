@@ -0,0 +1,210 @@
+package operators
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// MarshalText encodes the kind as its name.
+func (k Kind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// UnmarshalText decodes the name of a kind.
+func (k *Kind) UnmarshalText(text []byte) error {
+	for kind, name := range kindNames {
+		if name == string(text) {
+			*k = Kind(kind)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown kind: %s", text)
+}
+
+// jsonNode is the JSON representation of a node.
+type jsonNode struct {
+	Key      string      `json:"key"`
+	Kind     Kind        `json:"kind"`
+	Start    int         `json:"start"`
+	End      int         `json:"end"`
+	Text     string      `json:"text"`
+	Children []*jsonNode `json:"children,omitempty"`
+}
+
+func (n *Node) toJSON(start int) *jsonNode {
+	node := &jsonNode{
+		Key:   n.Key,
+		Kind:  n.Kind,
+		Start: start,
+		End:   start + len(n.Value),
+		Text:  string(n.Value),
+	}
+	offset := start
+	for _, child := range n.Children {
+		// values point to the same underlying input, which makes it possible to calculate the offset of a child
+		// based on the capacities, this does not hold for trees that are constructed by hand, their children are
+		// expected to follow each other
+		childStart := offset
+		if i, ok := childOffset(n.Value, child.Value); ok && offset <= start+i {
+			childStart = start + i
+		}
+		node.Children = append(node.Children, child.toJSON(childStart))
+		offset = childStart + len(child.Value)
+	}
+	return node
+}
+
+// childOffset returns the offset of the value of a child within the value of its parent, if it points to the same
+// underlying array.
+func childOffset(parent, child []byte) (int, bool) {
+	i := cap(parent) - cap(child)
+	if i < 0 || len(parent) < i+len(child) || cap(child) == 0 {
+		return 0, false
+	}
+	return i, &parent[:cap(parent)][i] == &child[:1][0]
+}
+
+// MarshalJSON encodes the (sub)tree as JSON. The start and end offsets are relative to the start of the node.
+func (n *Node) MarshalJSON() ([]byte, error) {
+	return json.Marshal(n.toJSON(0))
+}
+
+// UnmarshalJSON decodes a (sub)tree that was encoded by MarshalJSON. All the values share the same underlying input,
+// so the offsets are kept when encoded again.
+func (n *Node) UnmarshalJSON(data []byte) error {
+	var node jsonNode
+	if err := json.Unmarshal(data, &node); err != nil {
+		return err
+	}
+	if node.Start != 0 {
+		return fmt.Errorf("root node does not start at 0: %d", node.Start)
+	}
+	input := make([]byte, node.End)
+	if err := node.fill(input); err != nil {
+		return err
+	}
+	*n = *node.toNode(input)
+	return nil
+}
+
+// fill writes the text of all the nodes to the given input.
+func (n *jsonNode) fill(input []byte) error {
+	if n.Start < 0 || n.End < n.Start || len(input) < n.End || n.End-n.Start != len(n.Text) {
+		return fmt.Errorf("invalid offsets for %s: %d-%d", n.Key, n.Start, n.End)
+	}
+	copy(input[n.Start:n.End], n.Text)
+	for _, child := range n.Children {
+		if child.Start < n.Start || child.End < child.Start || n.End < child.End {
+			return fmt.Errorf("invalid offsets for %s: %d-%d is not within %d-%d", child.Key, child.Start, child.End, n.Start, n.End)
+		}
+		// the text of the parent is already written, so the text of the child has to match it
+		if string(input[child.Start:child.End]) != child.Text {
+			return fmt.Errorf("text of %s does not match %s: %q", child.Key, n.Key, child.Text)
+		}
+		if err := child.fill(input); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (n *jsonNode) toNode(input []byte) *Node {
+	node := &Node{
+		Key:   n.Key,
+		Kind:  n.Kind,
+		Value: input[n.Start:n.End],
+	}
+	for _, child := range n.Children {
+		node.Children = append(node.Children, child.toNode(input))
+	}
+	return node
+}
+
+// SExpression returns the (sub)tree as an S-expression, e.g. (ALPHA (%x61-7A "a")).
+func (n *Node) SExpression() string {
+	var b strings.Builder
+	n.sExpression(&b)
+	return b.String()
+}
+
+func (n *Node) sExpression(b *strings.Builder) {
+	b.WriteString("(")
+	b.WriteString(strconv.Quote(n.Key))
+	if len(n.Children) == 0 {
+		b.WriteString(" ")
+		b.WriteString(strconv.Quote(string(n.Value)))
+	}
+	for _, child := range n.Children {
+		b.WriteString(" ")
+		child.sExpression(b)
+	}
+	b.WriteString(")")
+}
+
+// WriteDOT writes the (sub)tree as a Graphviz DOT graph.
+func (n *Node) WriteDOT(w io.Writer) error {
+	return Alternatives{n}.WriteDOT(w)
+}
+
+// WriteDOT writes all the alternatives as a Graphviz DOT graph, each alternative in its own cluster. This can be used to
+// visualize ambiguous parses.
+func (as Alternatives) WriteDOT(w io.Writer) error {
+	d := dot{w: w}
+	d.wln("digraph {")
+	d.indent = "\t"
+	for i, alternative := range as {
+		if 1 < len(as) {
+			d.wf("\tsubgraph cluster_%d {\n", i)
+			d.indent = "\t\t"
+			d.wf("%slabel=%q;\n", d.indent, fmt.Sprintf("alternative %d", i))
+		}
+		d.node(alternative)
+		if 1 < len(as) {
+			d.indent = "\t"
+			d.wln("\t}")
+		}
+	}
+	d.wln("}")
+	return d.err
+}
+
+type dot struct {
+	w      io.Writer
+	indent string
+	id     int
+	err    error
+}
+
+func (d *dot) wf(format string, args ...interface{}) {
+	if d.err != nil {
+		return
+	}
+	_, d.err = fmt.Fprintf(d.w, format, args...)
+}
+
+func (d *dot) wln(s string) {
+	d.wf("%s\n", s)
+}
+
+// node writes the given node and its children, it returns the id of the node.
+func (d *dot) node(n *Node) int {
+	id := d.id
+	d.id++
+	label := n.Key
+	if len(n.Children) == 0 {
+		label += "\n" + strconv.Quote(string(n.Value))
+	}
+	shape := "box"
+	if n.IsRule() {
+		shape = "ellipse"
+	}
+	d.wf("%sn%d [label=%q, shape=%s];\n", d.indent, id, label, shape)
+	for _, child := range n.Children {
+		childID := d.node(child)
+		d.wf("%sn%d -> n%d;\n", d.indent, id, childID)
+	}
+	return id
+}
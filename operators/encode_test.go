@@ -0,0 +1,137 @@
+package operators
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestJSON(t *testing.T) {
+	rule := Rule(`ab`, Concat(`a *b`, a, Repeat0Inf(`*b`, b)))
+	node := rule([]byte("abbc")).Best()
+
+	raw, err := json.Marshal(node)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	expected := `{"key":"ab","kind":"rule","start":0,"end":3,"text":"abb","children":[` +
+		`{"key":"a","kind":"terminal","start":0,"end":1,"text":"a"},` +
+		`{"key":"*b","kind":"repetition","start":1,"end":3,"text":"bb","children":[` +
+		`{"key":"b","kind":"terminal","start":1,"end":2,"text":"b"},` +
+		`{"key":"b","kind":"terminal","start":2,"end":3,"text":"b"}]}]}`
+	if string(raw) != expected {
+		t.Errorf("invalid json: %s", raw)
+	}
+
+	var decoded Node
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		t.Error(err)
+		return
+	}
+	if err := node.Equals(&decoded); err != nil {
+		t.Error(err)
+	}
+	if decoded.Children[1].Kind != KindRepetition {
+		t.Errorf("invalid kind: %s", decoded.Children[1].Kind)
+	}
+	if again, _ := json.Marshal(&decoded); !bytes.Equal(raw, again) {
+		t.Errorf("encoded values do not match: %s", again)
+	}
+
+	t.Run("Constructed", func(t *testing.T) {
+		node := &Node{Key: "ab", Value: []byte("ab"), Children: Children{
+			{Key: "a", Value: []byte("a")},
+			{Key: "b", Value: []byte("b")},
+		}}
+		raw, _ := json.Marshal(node)
+		if !strings.Contains(string(raw), `{"key":"b","kind":"unknown","start":1,"end":2,"text":"b"}`) {
+			t.Errorf("invalid offsets: %s", raw)
+		}
+	})
+
+	t.Run("Allocated", func(t *testing.T) {
+		// the children have the same capacity as the parent, but they do not share its underlying array
+		value := func(s string) []byte {
+			return append(make([]byte, 0, 8), s...)
+		}
+		node := &Node{Key: "abc", Value: value("abc"), Children: Children{
+			{Key: "a", Value: value("a")},
+			{Key: "bc", Value: value("bc"), Children: Children{
+				{Key: "b", Value: value("b")},
+				{Key: "c", Value: value("c")},
+			}},
+		}}
+		raw, err := json.Marshal(node)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := `{"key":"abc","kind":"unknown","start":0,"end":3,"text":"abc","children":[` +
+			`{"key":"a","kind":"unknown","start":0,"end":1,"text":"a"},` +
+			`{"key":"bc","kind":"unknown","start":1,"end":3,"text":"bc","children":[` +
+			`{"key":"b","kind":"unknown","start":1,"end":2,"text":"b"},` +
+			`{"key":"c","kind":"unknown","start":2,"end":3,"text":"c"}]}]}`
+		if string(raw) != expected {
+			t.Errorf("invalid offsets: %s", raw)
+		}
+		var decoded Node
+		if err := json.Unmarshal(raw, &decoded); err != nil {
+			t.Fatal(err)
+		}
+		if err := node.Equals(&decoded); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		for _, raw := range []string{
+			`{"key":"a","kind":"rule","start":1,"end":2,"text":"a"}`,
+			`{"key":"a","kind":"rule","start":0,"end":2,"text":"a"}`,
+			`{"key":"a","kind":"other","start":0,"end":1,"text":"a"}`,
+			`{"key":"ab","kind":"rule","start":0,"end":2,"text":"ab","children":[{"key":"b","kind":"terminal","start":0,"end":1,"text":"b"}]}`,
+			`{"key":"ab","kind":"rule","start":0,"end":2,"text":"ab","children":[{"key":"b","kind":"terminal","start":1,"end":3,"text":"bc"}]}`,
+		} {
+			var node Node
+			if err := json.Unmarshal([]byte(raw), &node); err == nil {
+				t.Errorf("expected an error for %s", raw)
+			}
+		}
+	})
+}
+
+func TestSExpression(t *testing.T) {
+	rule := Rule(`ab`, Concat(`a *b`, a, Repeat0Inf(`*b`, b)))
+	if str := rule([]byte("ab")).Best().SExpression(); str != `("ab" ("a" "a") ("*b" ("b" "b")))` {
+		t.Errorf("invalid s-expression: %s", str)
+	}
+	if str := rule([]byte("a")).Best().SExpression(); str != `("ab" ("a" "a") ("*b" ""))` {
+		t.Errorf("invalid s-expression: %s", str)
+	}
+}
+
+func TestDOT(t *testing.T) {
+	rule := Concat(`a*`, Repeat0Inf(`*a1`, a), Repeat0Inf(`*a2`, a))
+	b := &bytes.Buffer{}
+	if err := rule([]byte("a")).WriteDOT(b); err != nil {
+		t.Error(err)
+		return
+	}
+	for _, expected := range []string{
+		"digraph {\n",
+		"\tsubgraph cluster_2 {\n",
+		"\t\tn0 [label=\"a*\", shape=box];\n",
+		"\t\tn0 -> n1;\n",
+		"\t\tn2 [label=\"a\\n\\\"a\\\"\", shape=box];\n",
+	} {
+		if !strings.Contains(b.String(), expected) {
+			t.Errorf("expected %q in:\n%s", expected, b)
+		}
+	}
+
+	b.Reset()
+	_ = Rule(`A`, a)([]byte("a")).Best().WriteDOT(b)
+	if b.String() != "digraph {\n\tn0 [label=\"A\\n\\\"a\\\"\", shape=ellipse];\n}\n" {
+		t.Errorf("invalid graph:\n%s", b)
+	}
+}
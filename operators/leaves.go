@@ -15,6 +15,7 @@ func Terminal(key string, value []byte) Operator {
 		return []*Node{
 			{
 				Key:   key,
+				Kind:  KindTerminal,
 				Value: s[:len(value)],
 			},
 		}
@@ -30,6 +31,7 @@ func String(key string, str string) Operator {
 		return []*Node{
 			{
 				Key:   key,
+				Kind:  KindTerminal,
 				Value: s[:len(str)],
 			},
 		}
@@ -46,6 +48,7 @@ func StringCI(key string, str string) Operator {
 		return []*Node{
 			{
 				Key:   key,
+				Kind:  KindTerminal,
 				Value: s[:len(str)],
 			},
 		}
@@ -77,6 +80,7 @@ func Range(key string, low, high []byte) Operator {
 		return []*Node{
 			{
 				Key:   key,
+				Kind:  KindTerminal,
 				Value: s[:l],
 			},
 		}
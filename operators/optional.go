@@ -5,6 +5,7 @@ func Optional(key string, r Operator) Operator {
 	return func(s []byte) Alternatives {
 		empty := &Node{
 			Key:   key,
+			Kind:  KindOption,
 			Value: s[:0],
 		}
 
@@ -17,6 +18,7 @@ func Optional(key string, r Operator) Operator {
 		for _, node := range subNodes {
 			nodes = append(nodes, &Node{
 				Key:      key,
+				Kind:     KindOption,
 				Value:    node.Value,
 				Children: Children{node},
 			})
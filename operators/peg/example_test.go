@@ -0,0 +1,56 @@
+package peg_test
+
+import (
+	"fmt"
+
+	"github.com/elimity-com/abnf/operators"
+	"github.com/elimity-com/abnf/operators/peg"
+)
+
+// The first alternative that matches is chosen, even if another one matches more of the input.
+func ExampleAlts() {
+	input := []byte("ab")
+
+	full := operators.Alts(`"a" / "ab"`, operators.String(`a`, "a"), operators.String(`ab`, "ab"))
+	fmt.Println(string(full(input).Best().Value))
+
+	ordered := peg.Alts(`"a" / "ab"`, peg.String(`a`, "a"), peg.String(`ab`, "ab"))
+	fmt.Println(string(ordered(input).Value))
+	// Output:
+	// ab
+	// a
+}
+
+// Repetitions are greedy and do not backtrack, so "*a a" never matches.
+func ExampleRepeat() {
+	input := []byte("aaa")
+
+	full := operators.Concat(`*"a" "a"`,
+		operators.Repeat0Inf(`*"a"`, operators.String(`a`, "a")),
+		operators.String(`a`, "a"),
+	)
+	fmt.Println(string(full(input).Best().Value))
+
+	greedy := peg.Concat(`*"a" "a"`,
+		peg.Repeat0Inf(`*"a"`, peg.String(`a`, "a")),
+		peg.String(`a`, "a"),
+	)
+	fmt.Println(greedy(input) == nil)
+	// Output:
+	// aaa
+	// true
+}
+
+// Both modes agree on grammars that never need to backtrack.
+func ExampleConcat() {
+	input := []byte("ab")
+
+	full := operators.Concat(`"a" "b"`, operators.String(`a`, "a"), operators.String(`b`, "b"))
+	fmt.Println(full(input).Best().SExpression())
+
+	ordered := peg.Concat(`"a" "b"`, peg.String(`a`, "a"), peg.String(`b`, "b"))
+	fmt.Println(ordered(input).SExpression())
+	// Output:
+	// ("\"a\" \"b\"" ("a" "a") ("b" "b"))
+	// ("\"a\" \"b\"" ("a" "a") ("b" "b"))
+}
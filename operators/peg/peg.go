@@ -0,0 +1,196 @@
+// Package peg contains an alternative execution mode for the operators, based on parsing expression grammars (PEG).
+// Alternatives are ordered (the first successful one is chosen) and repetitions are greedy, so every operator returns
+// at most one parse tree. This trades completeness for (linear) performance: inputs that only match by backtracking
+// into an alternative or a repetition are rejected.
+package peg
+
+import (
+	"github.com/elimity-com/abnf/operators"
+)
+
+// Operator represents an ABNF operator that returns the first parse tree, or nil if there is none.
+type Operator func([]byte) *operators.Node
+
+// Lift converts an operator that returns all alternatives to one that only returns the first one.
+func Lift(r operators.Operator) Operator {
+	return func(s []byte) *operators.Node {
+		if nodes := r(s); len(nodes) != 0 {
+			return nodes[0]
+		}
+		return nil
+	}
+}
+
+// AsOperator converts the operator to one that returns its parse tree as (the only) alternative.
+func (r Operator) AsOperator() operators.Operator {
+	return func(s []byte) operators.Alternatives {
+		if node := r(s); node != nil {
+			return operators.Alternatives{node}
+		}
+		return nil
+	}
+}
+
+// Terminal defines a single character.
+func Terminal(key string, value []byte) Operator {
+	return Lift(operators.Terminal(key, value))
+}
+
+// String defines a certain sequence of case sensitive characters.
+func String(key string, str string) Operator {
+	return Lift(operators.String(key, str))
+}
+
+// StringCI defines a certain sequence of case insensitive characters.
+func StringCI(key string, str string) Operator {
+	return Lift(operators.StringCI(key, str))
+}
+
+// Range defines the range of alternative numeric values compactly.
+func Range(key string, low, high []byte) Operator {
+	return Lift(operators.Range(key, low, high))
+}
+
+// Concat defines a simple, ordered string of values. It fails if one of the rules fails.
+func Concat(key string, rules ...Operator) Operator {
+	return func(s []byte) *operators.Node {
+		var (
+			l        int
+			children operators.Children
+		)
+		for _, rule := range rules {
+			node := rule(s[l:])
+			if node == nil {
+				return nil
+			}
+			children = append(children, node)
+			l += len(node.Value)
+		}
+		return &operators.Node{
+			Key:      key,
+			Kind:     operators.KindConcatenation,
+			Value:    s[:l],
+			Children: children,
+		}
+	}
+}
+
+// Alts defines an ordered choice, the first alternative that matches gets chosen.
+func Alts(key string, rules ...Operator) Operator {
+	return func(s []byte) *operators.Node {
+		for _, rule := range rules {
+			if node := rule(s); node != nil {
+				return &operators.Node{
+					Key:      key,
+					Kind:     operators.KindAlternation,
+					Value:    node.Value,
+					Children: operators.Children{node},
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// Repeat defines a greedy variable repetition, it matches as many times as possible (up to max).
+func Repeat(key string, min, max int, r Operator) Operator {
+	return func(s []byte) *operators.Node {
+		var (
+			l        int
+			children operators.Children
+		)
+		for max < 0 || len(children) < max {
+			node := r(s[l:])
+			if node == nil {
+				break
+			}
+			children = append(children, node)
+			l += len(node.Value)
+			if len(node.Value) == 0 && min <= len(children) {
+				// no progress, would repeat forever
+				break
+			}
+		}
+		if len(children) < min {
+			return nil
+		}
+		return &operators.Node{
+			Key:      key,
+			Kind:     operators.KindRepetition,
+			Value:    s[:l],
+			Children: children,
+		}
+	}
+}
+
+// RepeatN defines a specific repetition.
+func RepeatN(key string, n int, r Operator) Operator {
+	return Repeat(key, n, n, r)
+}
+
+// Repeat0Inf defines a specific repetition from 0 to infinity.
+func Repeat0Inf(key string, r Operator) Operator {
+	return Repeat(key, 0, -1, r)
+}
+
+// Repeat1Inf defines a specific repetition from 1 to infinity.
+func Repeat1Inf(key string, r Operator) Operator {
+	return Repeat(key, 1, -1, r)
+}
+
+// RepeatOptional defines a specific repetition from 0 to 1. Behaves the same as Optional.
+func RepeatOptional(key string, r Operator) Operator {
+	return Repeat(key, 0, 1, r)
+}
+
+// Optional defines an optional element sequence, it matches if possible.
+func Optional(key string, r Operator) Operator {
+	return func(s []byte) *operators.Node {
+		if node := r(s); node != nil {
+			return &operators.Node{
+				Key:      key,
+				Kind:     operators.KindOption,
+				Value:    node.Value,
+				Children: operators.Children{node},
+			}
+		}
+		return &operators.Node{
+			Key:   key,
+			Kind:  operators.KindOption,
+			Value: s[:0],
+		}
+	}
+}
+
+// WithElement sets the element of the root node of the given (anonymous) operator, see operators.WithElement.
+func WithElement(rule string, index int, r Operator) Operator {
+	return func(s []byte) *operators.Node {
+		node := r(s)
+		if node != nil {
+			node.Element = operators.Element{Rule: rule, Index: index}
+		}
+		return node
+	}
+}
+
+// Rule defines a named rule. The root node of the given operator is renamed after the rule, unless the operator is a
+// rule itself (e.g. "a = b"), then its node is kept as child.
+func Rule(name string, r Operator) Operator {
+	return func(s []byte) *operators.Node {
+		node := r(s)
+		if node == nil {
+			return nil
+		}
+		children := node.Children
+		if node.IsRule() {
+			children = operators.Children{node}
+		}
+		return &operators.Node{
+			Key:      name,
+			Kind:     operators.KindRule,
+			Element:  operators.Element{Rule: name},
+			Value:    node.Value,
+			Children: children,
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package peg
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/elimity-com/abnf/operators"
+)
+
+var (
+	a = Terminal(`a`, []byte("a"))
+	b = Terminal(`b`, []byte("b"))
+)
+
+func TestOperators(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		rule  Operator
+		str   string
+		value string
+		fail  bool
+	}{
+		{name: "Terminal", rule: a, str: "ab", value: "a"},
+		{name: "TerminalFail", rule: a, str: "b", fail: true},
+		{name: "String", rule: String(`ab`, "ab"), str: "abc", value: "ab"},
+		{name: "StringCI", rule: StringCI(`ab`, "ab"), str: "AB", value: "AB"},
+		{name: "Range", rule: Range(`a-z`, []byte("a"), []byte("z")), str: "x", value: "x"},
+		{name: "Concat", rule: Concat(`a b`, a, b), str: "abc", value: "ab"},
+		{name: "ConcatFail", rule: Concat(`a b`, a, b), str: "aa", fail: true},
+		{name: "Alts", rule: Alts(`a / b`, a, b), str: "b", value: "b"},
+		{name: "AltsFail", rule: Alts(`a / b`, a, b), str: "c", fail: true},
+		{name: "Repeat", rule: Repeat0Inf(`*a`, a), str: "aaab", value: "aaa"},
+		{name: "RepeatMax", rule: Repeat(`*2a`, 0, 2, a), str: "aaa", value: "aa"},
+		{name: "RepeatMin", rule: Repeat(`3*a`, 3, -1, a), str: "aa", fail: true},
+		{name: "RepeatEmpty", rule: Repeat0Inf(`*[a]`, Optional(`[a]`, a)), str: "aab", value: "aa"},
+		{name: "RepeatN", rule: RepeatN(`2a`, 2, a), str: "aaa", value: "aa"},
+		{name: "Repeat1Inf", rule: Repeat1Inf(`1*a`, a), str: "b", fail: true},
+		{name: "RepeatOptional", rule: RepeatOptional(`*1a`, a), str: "aa", value: "a"},
+		{name: "Optional", rule: Optional(`[a]`, a), str: "b", value: ""},
+		{name: "Lift", rule: Lift(operators.Repeat0Inf(`*a`, operators.Terminal(`a`, []byte("a")))), str: "aa", value: "aa"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			node := test.rule([]byte(test.str))
+			if test.fail {
+				if node != nil {
+					t.Errorf("expected no match, got %q", node.Value)
+				}
+				return
+			}
+			if node == nil {
+				t.Error("no match found")
+				return
+			}
+			if string(node.Value) != test.value {
+				t.Errorf("expected %q, got %q", test.value, node.Value)
+			}
+		})
+	}
+}
+
+func TestRule(t *testing.T) {
+	rule := Rule(`ab`, Concat(`a b`, a, Rule(`b`, b)))
+	node := rule([]byte("ab"))
+	if node.Key != "ab" || node.Kind != operators.KindRule || len(node.Children) != 2 {
+		t.Errorf("invalid tree:\n%s", node.StringRecursive())
+	}
+
+	if node := Rule(`c`, Rule(`b`, b))([]byte("b")); len(node.Children) != 1 || node.Children[0].Key != "b" {
+		t.Errorf("invalid tree:\n%s", node.StringRecursive())
+	}
+
+	alternatives := rule.AsOperator()([]byte("ab"))
+	if len(alternatives) != 1 || !strings.Contains(alternatives[0].StringRecursive(), "- b: b") {
+		t.Errorf("invalid alternatives: %v", alternatives)
+	}
+	if alternatives := rule.AsOperator()([]byte("b")); alternatives != nil {
+		t.Errorf("expected no alternatives, got %v", alternatives)
+	}
+}
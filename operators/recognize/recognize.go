@@ -0,0 +1,249 @@
+// Package recognize contains an alternative execution mode for the operators that does not build parse trees. An
+// operator only returns the lengths of the prefixes of the input it matches, so the results are the same as those of
+// the operators package, but different parse trees of the same prefix are not distinguished. The lengths are appended
+// to a given slice, so (with a slice that is large enough) matching does not allocate.
+package recognize
+
+import (
+	"bytes"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/elimity-com/abnf/operators"
+)
+
+// Operator represents an ABNF operator that appends the lengths of the prefixes of the input it matches to the given
+// slice, without duplicates and in no particular order.
+type Operator func(s []byte, lengths []int) []int
+
+// buffers contains the slices that are used by Recognize.
+var buffers = sync.Pool{
+	New: func() interface{} {
+		return new([]int)
+	},
+}
+
+// Recognize returns the length of the longest prefix of the input that is matched by the given operator, the input is
+// valid if that is the length of the input. It does not allocate (apart from the first calls, which grow the reused
+// slices).
+func Recognize(r Operator, s []byte) (int, bool) {
+	buffer := buffers.Get().(*[]int)
+	lengths := r(s, (*buffer)[:0])
+	n, ok := 0, false
+	for _, l := range lengths {
+		if !ok || n < l {
+			n, ok = l, true
+		}
+	}
+	*buffer = lengths[:0]
+	buffers.Put(buffer)
+	return n, ok
+}
+
+// Lift converts an operator that returns all alternatives to a recognizer, it allocates the parse trees.
+func Lift(r operators.Operator) Operator {
+	return func(s []byte, lengths []int) []int {
+		start := len(lengths)
+		for _, node := range r(s) {
+			lengths = append(lengths, len(node.Value))
+		}
+		return unique(lengths, start, start)
+	}
+}
+
+// DFA converts the given automaton to a recognizer.
+func DFA(d *operators.DFA) Operator {
+	return func(s []byte, lengths []int) []int {
+		return d.AppendMatches(lengths, s)
+	}
+}
+
+// Terminal defines a single character.
+func Terminal(value []byte) Operator {
+	return func(s []byte, lengths []int) []int {
+		if !bytes.HasPrefix(s, value) {
+			return lengths
+		}
+		return append(lengths, len(value))
+	}
+}
+
+// String defines a certain sequence of case sensitive characters.
+func String(str string) Operator {
+	return func(s []byte, lengths []int) []int {
+		if len(str) > len(s) || string(s[:len(str)]) != str {
+			return lengths
+		}
+		return append(lengths, len(str))
+	}
+}
+
+// StringCI defines a certain sequence of case insensitive characters.
+func StringCI(str string) Operator {
+	value := []byte(str)
+	return func(s []byte, lengths []int) []int {
+		if len(value) > len(s) || !bytes.EqualFold(s[:len(value)], value) {
+			return lengths
+		}
+		return append(lengths, len(value))
+	}
+}
+
+// Range defines the range of alternative numeric values compactly, it compares the input like operators.Range.
+func Range(low, high []byte) Operator {
+	return func(s []byte, lengths []int) []int {
+		if len(s) == 0 || len(s) < len(low) || bytes.Compare(s[:len(low)], low) < 0 {
+			return lengths
+		}
+
+		var l int
+
+		_, size := utf8.DecodeRune(s)
+		for i := len(high); 0 < i; i-- {
+			if len(high)-i < size && s[len(high)-i] <= high[i-1] {
+				l++
+			} else {
+				break
+			}
+		}
+
+		if l == 0 {
+			return lengths
+		}
+		return append(lengths, l)
+	}
+}
+
+// Concat defines a simple, ordered string of values.
+func Concat(rules ...Operator) Operator {
+	return func(s []byte, lengths []int) []int {
+		start := len(lengths)
+		lengths = append(lengths, 0)
+		for _, rule := range rules {
+			end := len(lengths)
+			lengths = extend(rule, s, lengths, start, end)
+			// only keep the lengths that include the rule
+			lengths = lengths[:start+copy(lengths[start:], lengths[end:])]
+			lengths = unique(lengths, start, start)
+		}
+		return lengths
+	}
+}
+
+// Alts defines a sequence of alternative elements that are separated by a forward slash ("/").
+func Alts(rules ...Operator) Operator {
+	return func(s []byte, lengths []int) []int {
+		start := len(lengths)
+		for _, rule := range rules {
+			lengths = rule(s, lengths)
+		}
+		return unique(lengths, start, start)
+	}
+}
+
+// AltsFirst is the same as Alts, but it only tries the alternatives that can start with the next byte of the input (see
+// operators.AltsFirst).
+func AltsFirst(first []operators.ByteSet, rules ...Operator) Operator {
+	return func(s []byte, lengths []int) []int {
+		start := len(lengths)
+		for i, rule := range rules {
+			if len(s) != 0 && !first[i].Contains(s[0]) {
+				continue
+			}
+			lengths = rule(s, lengths)
+		}
+		return unique(lengths, start, start)
+	}
+}
+
+// Repeat defines a variable repetition, a max of -1 is infinite.
+func Repeat(min, max int, r Operator) Operator {
+	return func(s []byte, lengths []int) []int {
+		// after start are the results, followed by the lengths that are reached by the current amount of repetitions
+		start := len(lengths)
+		lengths = append(lengths, 0)
+		results, current := start, start
+		for i := 0; ; i++ {
+			end := len(lengths)
+			if min <= i {
+				// the current lengths are not part of the results yet, see below
+				results = end
+			}
+			if i == max || current == end {
+				break
+			}
+			lengths = extend(r, s, lengths, current, end)
+			lengths = lengths[:results+copy(lengths[results:], lengths[end:])]
+			current = results
+			if min <= i+1 {
+				// lengths that were already reached do not lead to new results
+				lengths = unique(lengths, current, start)
+			} else {
+				lengths = unique(lengths, current, current)
+			}
+		}
+		return lengths[:results]
+	}
+}
+
+// RepeatN defines a specific repetition.
+func RepeatN(n int, r Operator) Operator {
+	return Repeat(n, n, r)
+}
+
+// Repeat0Inf defines a specific repetition from 0 to infinity.
+func Repeat0Inf(r Operator) Operator {
+	return Repeat(0, -1, r)
+}
+
+// Repeat1Inf defines a specific repetition from 1 to infinity.
+func Repeat1Inf(r Operator) Operator {
+	return Repeat(1, -1, r)
+}
+
+// RepeatOptional defines a specific repetition from 0 to 1. Behaves the same as Optional.
+func RepeatOptional(r Operator) Operator {
+	return Repeat(0, 1, r)
+}
+
+// Optional defines an optional element sequence.
+func Optional(r Operator) Operator {
+	return func(s []byte, lengths []int) []int {
+		start := len(lengths)
+		lengths = r(s, append(lengths, 0))
+		return unique(lengths, start, start)
+	}
+}
+
+// extend appends the lengths of the matches of the given operator after each of the lengths from up to to.
+func extend(r Operator, s []byte, lengths []int, from, to int) []int {
+	for i := from; i < to; i++ {
+		l, next := lengths[i], len(lengths)
+		lengths = r(s[l:], lengths)
+		for j := next; j < len(lengths); j++ {
+			lengths[j] += l
+		}
+	}
+	return lengths
+}
+
+// unique removes the lengths after from that are duplicates, or that are part of the lengths from except up to from.
+func unique(lengths []int, from, except int) []int {
+	n := from
+	for _, l := range lengths[from:] {
+		if !contains(lengths[except:n], l) {
+			lengths[n] = l
+			n++
+		}
+	}
+	return lengths[:n]
+}
+
+func contains(lengths []int, l int) bool {
+	for _, other := range lengths {
+		if other == l {
+			return true
+		}
+	}
+	return false
+}
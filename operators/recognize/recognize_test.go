@@ -0,0 +1,71 @@
+package recognize
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/elimity-com/abnf/operators"
+)
+
+var (
+	a = Terminal([]byte("a"))
+	b = Terminal([]byte("b"))
+)
+
+func TestOperators(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		rule    Operator
+		str     string
+		lengths []int
+	}{
+		{name: "Terminal", rule: a, str: "ab", lengths: []int{1}},
+		{name: "TerminalFail", rule: a, str: "b"},
+		{name: "String", rule: String("ab"), str: "abc", lengths: []int{2}},
+		{name: "StringCI", rule: StringCI("ab"), str: "AB", lengths: []int{2}},
+		{name: "Range", rule: Range([]byte("a"), []byte("z")), str: "x", lengths: []int{1}},
+		{name: "Concat", rule: Concat(a, b), str: "abc", lengths: []int{2}},
+		{name: "ConcatFail", rule: Concat(a, b), str: "aa"},
+		{name: "ConcatBacktrack", rule: Concat(Repeat0Inf(a), a), str: "aaa", lengths: []int{1, 2, 3}},
+		{name: "Alts", rule: Alts(a, Concat(a, b)), str: "ab", lengths: []int{1, 2}},
+		{name: "AltsDuplicate", rule: Alts(a, a), str: "a", lengths: []int{1}},
+		{name: "Repeat", rule: Repeat0Inf(a), str: "aab", lengths: []int{0, 1, 2}},
+		{name: "RepeatMax", rule: Repeat(0, 2, a), str: "aaa", lengths: []int{0, 1, 2}},
+		{name: "RepeatMin", rule: Repeat(2, -1, a), str: "aaa", lengths: []int{2, 3}},
+		{name: "RepeatMinFail", rule: Repeat(3, -1, a), str: "aa"},
+		{name: "RepeatEmpty", rule: Repeat(2, -1, Optional(a)), str: "ab", lengths: []int{0, 1}},
+		{name: "RepeatN", rule: RepeatN(2, a), str: "aaa", lengths: []int{2}},
+		{name: "Optional", rule: Optional(a), str: "a", lengths: []int{0, 1}},
+		{name: "Lift", rule: Lift(operators.Repeat0Inf(`*a`, operators.Terminal(`a`, []byte("a")))), str: "aa", lengths: []int{0, 1, 2}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			lengths := test.rule([]byte(test.str), nil)
+			sort.Ints(lengths)
+			if len(lengths) != len(test.lengths) {
+				t.Fatalf("expected %v, got %v", test.lengths, lengths)
+			}
+			for i, l := range lengths {
+				if l != test.lengths[i] {
+					t.Errorf("expected %v, got %v", test.lengths, lengths)
+				}
+			}
+		})
+	}
+}
+
+func TestRecognize(t *testing.T) {
+	rule := Concat(Repeat1Inf(Alts(a, b)), Optional(String(";")))
+	if n, ok := Recognize(rule, []byte("abba;c")); !ok || n != 5 {
+		t.Errorf("expected 5, got %d %t", n, ok)
+	}
+	if _, ok := Recognize(rule, []byte("c")); ok {
+		t.Error("expected no match")
+	}
+
+	input := []byte("abababababab;")
+	if allocs := testing.AllocsPerRun(100, func() {
+		Recognize(rule, input)
+	}); allocs != 0 {
+		t.Errorf("expected no allocations, got %v", allocs)
+	}
+}
@@ -20,6 +20,7 @@ func Repeat(key string, min, max int, r Operator) Operator {
 			}
 			node := Node{
 				Key:   key,
+				Kind:  KindRepetition,
 				Value: s[:l],
 			}
 			return append(nodes, &node)
@@ -0,0 +1,35 @@
+package operators
+
+// Rule defines a named rule. The root nodes of the given operator are renamed after the rule, unless the operator is
+// a rule itself (e.g. "a = b"), then its nodes are kept as children.
+func Rule(name string, r Operator) Operator {
+	return func(s []byte) Alternatives {
+		var nodes Alternatives
+		for _, node := range r(s) {
+			children := node.Children
+			if node.IsRule() {
+				children = Children{node}
+			}
+			nodes = append(nodes, &Node{
+				Key:      name,
+				Kind:     KindRule,
+				Element:  Element{Rule: name},
+				Value:    node.Value,
+				Children: children,
+			})
+		}
+		return nodes
+	}
+}
+
+// WithElement sets the element of the root nodes of the given (anonymous) operator, which is the operator with the
+// given index within the given rule.
+func WithElement(rule string, index int, r Operator) Operator {
+	return func(s []byte) Alternatives {
+		nodes := r(s)
+		for _, node := range nodes {
+			node.Element = Element{Rule: rule, Index: index}
+		}
+		return nodes
+	}
+}
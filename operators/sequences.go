@@ -10,6 +10,7 @@ func Concat(key string, rules ...Operator) Operator {
 				return Alternatives{
 					{
 						Key:   key,
+						Kind:  KindConcatenation,
 						Value: s[:l],
 					},
 				}
@@ -40,6 +41,31 @@ func Alts(key string, rules ...Operator) Operator {
 			for _, node := range subNodes {
 				nodes = append(nodes, &Node{
 					Key:      key,
+					Kind:     KindAlternation,
+					Value:    node.Value,
+					Children: Children{node},
+				})
+			}
+		}
+		return nodes
+	}
+}
+
+// AltsFirst is the same as Alts, but it only tries the alternatives that can start with the next byte of the input. The
+// given sets contain the bytes the alternatives (in the same order) can start with, those that can match the empty
+// input need to contain all the bytes. At the end of the input all the alternatives are tried.
+func AltsFirst(key string, first []ByteSet, rules ...Operator) Operator {
+	return func(s []byte) Alternatives {
+		var nodes Alternatives
+		for i, rule := range rules {
+			if len(s) != 0 && !first[i].Contains(s[0]) {
+				continue
+			}
+			subNodes := rule(s)
+			for _, node := range subNodes {
+				nodes = append(nodes, &Node{
+					Key:      key,
+					Kind:     KindAlternation,
 					Value:    node.Value,
 					Children: Children{node},
 				})
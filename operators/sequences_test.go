@@ -1,6 +1,7 @@
 package operators
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -108,3 +109,42 @@ func TestAlts(t *testing.T) {
 		}
 	})
 }
+
+func TestAltsFirst(t *testing.T) {
+	var tried []string
+	trace := func(name string, rule Operator) Operator {
+		return func(s []byte) Alternatives {
+			tried = append(tried, name)
+			return rule(s)
+		}
+	}
+	var firstA, firstB, all ByteSet
+	firstA.Add('a')
+	firstB.Add('b')
+	all.AddRange(0, 255)
+	rule := AltsFirst(`a / b / [a]`, []ByteSet{firstA, firstB, all},
+		trace("a", a),
+		trace("b", b),
+		trace("[a]", Optional(`[a]`, a)),
+	)
+
+	for _, test := range []struct {
+		s     string
+		tried string
+		nodes int
+	}{
+		{"a", "a [a]", 3},
+		{"b", "b [a]", 2},
+		{"c", "[a]", 1},
+		{"", "a b [a]", 1},
+	} {
+		tried = nil
+		nodes := rule([]byte(test.s))
+		if got := strings.Join(tried, " "); got != test.tried {
+			t.Errorf("%q: expected to try %s, got %s", test.s, test.tried, got)
+		}
+		if len(nodes) != test.nodes {
+			t.Errorf("%q: expected %d nodes, got %d", test.s, test.nodes, len(nodes))
+		}
+	}
+}
@@ -8,11 +8,60 @@ import (
 
 // Node represents a single node in a tree.
 type Node struct {
-	Key      string
+	// Key is either the name of a rule or the ABNF text of an (anonymous) operator, depending on the Kind.
+	Key string
+	// Kind of the operator that created the node.
+	Kind Kind
+	// Element identifies the operator that created the node within its rule set, it is empty if it is not known.
+	Element  Element
 	Value    []byte
 	Children Children
 }
 
+// Element identifies an operator of a rule: the operators of a rule are numbered in pre-order, starting with 0 for the
+// operator of the rule itself.
+type Element struct {
+	Rule  string
+	Index int
+}
+
+// Kind represents the kind of operator that created a node.
+type Kind int
+
+const (
+	// KindUnknown is the kind of nodes that were not created by one of the operators.
+	KindUnknown Kind = iota
+	// KindRule is the kind of the root nodes of a rule, their key is the name of the rule.
+	KindRule
+	KindAlternation
+	KindConcatenation
+	KindRepetition
+	KindOption
+	KindTerminal
+)
+
+var kindNames = [...]string{
+	KindUnknown:       "unknown",
+	KindRule:          "rule",
+	KindAlternation:   "alternation",
+	KindConcatenation: "concatenation",
+	KindRepetition:    "repetition",
+	KindOption:        "option",
+	KindTerminal:      "terminal",
+}
+
+func (k Kind) String() string {
+	if k < 0 || int(k) >= len(kindNames) {
+		return fmt.Sprintf("kind(%d)", int(k))
+	}
+	return kindNames[k]
+}
+
+// IsRule returns whether the node is the root node of a rule.
+func (n *Node) IsRule() bool {
+	return n.Kind == KindRule
+}
+
 // String returns the string representation of the node without new lines and duplicate spaces.
 func (n *Node) String() string {
 	return regexp.MustCompile(`\s+`).ReplaceAllString(string(n.Value), " ")
@@ -95,6 +144,56 @@ func (n *Node) GetSubNodesBefore(key string, stop ...string) Children {
 	return n.Children.GetAllBefore(key, stop...)
 }
 
+// GetSubRule searches ALL the children of the node for a rule with the given name and returns that child. Nodes of
+// anonymous operators with the same key are ignored.
+func (n *Node) GetSubRule(name string) *Node {
+	for _, child := range n.Children {
+		if child.IsRule() && child.Key == name {
+			return child
+		}
+		if n := child.GetSubRule(name); n != nil {
+			return n
+		}
+	}
+	return nil
+}
+
+// GetSubRules searches ALL the children of the node for rules with the given name and returns all matching children.
+func (n *Node) GetSubRules(name string) Children {
+	var nodes Children
+	for _, child := range n.Children {
+		if child.IsRule() && child.Key == name {
+			nodes = append(nodes, child)
+		}
+		nodes = append(nodes, child.GetSubRules(name)...)
+	}
+	return nodes
+}
+
+// RulesOnly returns a simplified copy of the tree that only contains the nodes of rules, the nodes of anonymous
+// operators are left out. The node itself is always kept as the root of the tree.
+func (n *Node) RulesOnly() *Node {
+	return &Node{
+		Key:      n.Key,
+		Kind:     n.Kind,
+		Element:  n.Element,
+		Value:    n.Value,
+		Children: n.Children.rulesOnly(),
+	}
+}
+
+func (c Children) rulesOnly() Children {
+	var nodes Children
+	for _, child := range c {
+		if child.IsRule() {
+			nodes = append(nodes, child.RulesOnly())
+			continue
+		}
+		nodes = append(nodes, child.Children.rulesOnly()...)
+	}
+	return nodes
+}
+
 // Contains returns whether the subtree contains the given key.
 func (n *Node) Contains(key string) bool {
 	for _, child := range n.Children {
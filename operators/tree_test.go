@@ -403,3 +403,57 @@ func TestBestAlternative(t *testing.T) {
 		}
 	})
 }
+
+func TestKind(t *testing.T) {
+	digit := Rule(`DIGIT`, Range(`%x30-39`, []byte("0"), []byte("9")))
+	rule := Rule(`number`, Concat(`["-"] 1*DIGIT`,
+		Optional(`["-"]`, String(`-`, "-")),
+		Repeat1Inf(`1*DIGIT`, Alts(`DIGIT / "DIGIT"`, digit, String(`DIGIT`, "DIGIT"))),
+	))
+	node := rule([]byte("-1DIGIT")).Best()
+
+	for key, kind := range map[string]Kind{
+		`["-"]`:           KindOption,
+		`-`:               KindTerminal,
+		`1*DIGIT`:         KindRepetition,
+		`DIGIT / "DIGIT"`: KindAlternation,
+	} {
+		if n := node.GetNode(key); n == nil || n.Kind != kind {
+			t.Errorf("%s: expected kind %s, got %v", key, kind, n)
+		}
+	}
+	// the concatenation is renamed after the rule
+	if node.Key != "number" || node.Kind != KindRule {
+		t.Errorf("expected the rule node, got %s (%s)", node.Key, node.Kind)
+	}
+
+	if rules := node.GetSubRules("DIGIT"); len(rules) != 1 || string(rules[0].Value) != "1" {
+		t.Errorf("expected one DIGIT rule, got %v", rules)
+	}
+	if terminal := node.GetSubNodes("DIGIT"); len(terminal) != 2 {
+		t.Errorf("expected two DIGIT nodes, got %d", len(terminal))
+	}
+	if n := node.GetSubRule("DIGIT"); n == nil || n.Kind != KindRule {
+		t.Error("DIGIT rule not found")
+	}
+
+	simplified := node.RulesOnly()
+	if len(simplified.Children) != 1 || simplified.Children[0].Key != "DIGIT" {
+		t.Errorf("invalid rules only tree:\n%s", simplified.StringRecursive())
+	}
+	if len(simplified.Children[0].Children) != 0 {
+		t.Error("expected DIGIT to be a leaf")
+	}
+
+	t.Run("Rule", func(t *testing.T) {
+		// a rule that is defined as another rule keeps that rule as sub node
+		node := Rule(`d`, digit)([]byte("1")).Best()
+		if node.Key != "d" || len(node.Children) != 1 || node.Children[0].Key != "DIGIT" {
+			t.Errorf("invalid tree:\n%s", node.StringRecursive())
+		}
+	})
+
+	if KindTerminal.String() != "terminal" || Kind(42).String() != "kind(42)" {
+		t.Error("invalid kind names")
+	}
+}
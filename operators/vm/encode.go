@@ -0,0 +1,242 @@
+package vm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/elimity-com/abnf/operators"
+)
+
+// magic starts every encoded program, the last byte is the version of the encoding.
+const magic = "abnfvm\x01"
+
+// MarshalBinary encodes the program, so it can be stored and loaded with UnmarshalBinary.
+func (p *Program) MarshalBinary() ([]byte, error) {
+	b := []byte(magic)
+	if p.PEG {
+		b = append(b, 1)
+	} else {
+		b = append(b, 0)
+	}
+	b = appendUvarint(b, len(p.Rules))
+	for _, r := range p.Rules {
+		b = appendBytes(b, []byte(r.Name))
+		b = appendUvarint(b, r.Entry)
+	}
+	b = appendUvarint(b, len(p.Sets))
+	for _, set := range p.Sets {
+		for _, word := range set {
+			var buf [8]byte
+			binary.LittleEndian.PutUint64(buf[:], word)
+			b = append(b, buf[:]...)
+		}
+	}
+	b = appendUvarint(b, len(p.Strings))
+	for _, str := range p.Strings {
+		b = appendBytes(b, str)
+	}
+	b = appendUvarint(b, len(p.Ranges))
+	for _, r := range p.Ranges {
+		b = appendBytes(b, r.Low)
+		b = appendBytes(b, r.High)
+	}
+	b = appendUvarint(b, len(p.Instructions))
+	for _, in := range p.Instructions {
+		b = append(b, byte(in.Op))
+		b = appendUvarint(b, in.Arg)
+		b = appendUvarint(b, in.Target)
+	}
+	return b, nil
+}
+
+func appendUvarint(b []byte, i int) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	return append(b, buf[:binary.PutUvarint(buf[:], uint64(i))]...)
+}
+
+func appendBytes(b []byte, value []byte) []byte {
+	return append(appendUvarint(b, len(value)), value...)
+}
+
+// decoder reads an encoded program, it keeps the first error.
+type decoder struct {
+	b   []byte
+	err error
+}
+
+var errTruncated = errors.New("truncated program")
+
+func (d *decoder) uvarint() int {
+	if d.err != nil {
+		return 0
+	}
+	i, n := binary.Uvarint(d.b)
+	if n <= 0 || math.MaxInt32 < i {
+		d.err = errTruncated
+		return 0
+	}
+	d.b = d.b[n:]
+	return int(i)
+}
+
+func (d *decoder) bytes(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if len(d.b) < n {
+		d.err = errTruncated
+		return nil
+	}
+	value := append([]byte(nil), d.b[:n]...)
+	d.b = d.b[n:]
+	return value
+}
+
+// count reads the length of a list of elements that take at least the given amount of bytes each.
+func (d *decoder) count(size int) int {
+	n := d.uvarint()
+	if d.err == nil && len(d.b)/size < n {
+		d.err = errTruncated
+		return 0
+	}
+	return n
+}
+
+// UnmarshalBinary decodes a program that was encoded with MarshalBinary, it checks that all the instructions are valid.
+func (p *Program) UnmarshalBinary(data []byte) error {
+	if !bytes.HasPrefix(data, []byte(magic)) {
+		return errors.New("not an encoded program")
+	}
+	d := &decoder{b: data[len(magic):]}
+	var q Program
+	if peg := d.bytes(1); d.err == nil {
+		q.PEG = peg[0] == 1
+	}
+	for i, n := 0, d.count(2); i < n; i++ {
+		name := d.bytes(d.uvarint())
+		q.Rules = append(q.Rules, Rule{Name: string(name), Entry: d.uvarint()})
+	}
+	for i, n := 0, d.count(32); i < n; i++ {
+		var set operators.ByteSet
+		for j := range set {
+			if word := d.bytes(8); d.err == nil {
+				set[j] = binary.LittleEndian.Uint64(word)
+			}
+		}
+		q.Sets = append(q.Sets, set)
+	}
+	for i, n := 0, d.count(1); i < n; i++ {
+		q.Strings = append(q.Strings, d.bytes(d.uvarint()))
+	}
+	for i, n := 0, d.count(2); i < n; i++ {
+		low := d.bytes(d.uvarint())
+		q.Ranges = append(q.Ranges, Range{Low: low, High: d.bytes(d.uvarint())})
+	}
+	for i, n := 0, d.count(3); i < n; i++ {
+		var in Instruction
+		if op := d.bytes(1); d.err == nil {
+			in.Op = Opcode(op[0])
+		}
+		in.Arg, in.Target = d.uvarint(), d.uvarint()
+		q.Instructions = append(q.Instructions, in)
+	}
+	if d.err != nil {
+		return d.err
+	}
+	if len(d.b) != 0 {
+		return errors.New("trailing bytes after the program")
+	}
+	if err := q.check(); err != nil {
+		return err
+	}
+	*p = q
+	return nil
+}
+
+// check returns an error if an instruction or a rule refers to something that does not exist, or if the last
+// instruction can continue with the next one.
+func (p *Program) check() error {
+	for _, r := range p.Rules {
+		if r.Entry < 0 || len(p.Instructions) <= r.Entry {
+			return fmt.Errorf("rule %s: invalid entry %d", r.Name, r.Entry)
+		}
+	}
+	for i, in := range p.Instructions {
+		// the amount of possible arguments, instructions without an argument (or target) need it to be 0
+		args, target := 1, false
+		switch in.Op {
+		case OpByte:
+			args = 256
+		case OpSet:
+			args = len(p.Sets)
+		case OpTestSet:
+			args, target = len(p.Sets), true
+		case OpString:
+			args = len(p.Strings)
+		case OpRange:
+			args = len(p.Ranges)
+		case OpOpen:
+			args = len(p.Rules)
+		case OpChoice, OpCommit, OpJump, OpCall:
+			target = true
+		case OpReturn, OpFail, OpMark, OpProgress, OpClose:
+		default:
+			return fmt.Errorf("instruction %d: invalid opcode %d", i, in.Op)
+		}
+		if in.Arg < 0 || args <= in.Arg {
+			return fmt.Errorf("instruction %d: invalid argument %d", i, in.Arg)
+		}
+		if in.Target < 0 || target && len(p.Instructions) <= in.Target || !target && in.Target != 0 {
+			return fmt.Errorf("instruction %d: invalid target %d", i, in.Target)
+		}
+	}
+	if n := len(p.Instructions); n != 0 {
+		switch p.Instructions[n-1].Op {
+		case OpCommit, OpJump, OpReturn, OpFail:
+		default:
+			return errors.New("the last instruction does not end the program")
+		}
+	}
+	return nil
+}
+
+// String returns the instructions of the program, e.g.
+//
+//	rule:
+//	     0  open rule
+//	     1  choice 4
+func (p *Program) String() string {
+	entries := make(map[int][]string)
+	for _, r := range p.Rules {
+		entries[r.Entry] = append(entries[r.Entry], r.Name)
+	}
+	var b strings.Builder
+	for i, in := range p.Instructions {
+		for _, name := range entries[i] {
+			fmt.Fprintf(&b, "%s:\n", name)
+		}
+		fmt.Fprintf(&b, "%6d  %s", i, in.Op)
+		switch in.Op {
+		case OpByte:
+			fmt.Fprintf(&b, " %%x%02X", in.Arg)
+		case OpSet:
+			fmt.Fprintf(&b, " %s", p.Sets[in.Arg])
+		case OpString:
+			fmt.Fprintf(&b, " %q", p.Strings[in.Arg])
+		case OpRange:
+			fmt.Fprintf(&b, " %q-%q", p.Ranges[in.Arg].Low, p.Ranges[in.Arg].High)
+		case OpTestSet:
+			fmt.Fprintf(&b, " %s %d", p.Sets[in.Arg], in.Target)
+		case OpChoice, OpCommit, OpJump, OpCall:
+			fmt.Fprintf(&b, " %d", in.Target)
+		case OpOpen:
+			fmt.Fprintf(&b, " %s", p.Rules[in.Arg].Name)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
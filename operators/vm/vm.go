@@ -0,0 +1,325 @@
+// Package vm contains a virtual machine that matches compiled grammars. A program is a flat list of instructions
+// (e.g. compiled from a RuleSet by the ParserGenerator), so unlike the operators it is cheap to construct, it can be
+// inspected (see Program.String) and it can be stored as bytes (see Program.MarshalBinary).
+//
+// The machine backtracks: a choice saves the state to continue from if the rest of the match fails. By default every
+// choice is explored until the longest match of the rule is found, like the operators do. Programs that are compiled
+// in PEG mode commit to the first alternative that matches instead, like the peg operators do.
+package vm
+
+import (
+	"bytes"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/elimity-com/abnf/operators"
+)
+
+// Opcode is the kind of an instruction.
+type Opcode byte
+
+const (
+	// OpByte matches the byte Arg.
+	OpByte Opcode = iota
+	// OpSet matches a byte of the set Sets[Arg].
+	OpSet
+	// OpString matches the bytes Strings[Arg].
+	OpString
+	// OpRange matches the range Ranges[Arg], in the same way as operators.Range.
+	OpRange
+	// OpTestSet jumps to Target if the next byte is not part of the set Sets[Arg], at the end of the input it does not.
+	OpTestSet
+	// OpChoice saves the state to continue from at Target if the match fails.
+	OpChoice
+	// OpCommit drops the last saved state and jumps to Target.
+	OpCommit
+	// OpJump jumps to Target.
+	OpJump
+	// OpCall calls the rule that starts at Target.
+	OpCall
+	// OpReturn returns from the current rule.
+	OpReturn
+	// OpFail fails the match, it continues from the last saved state.
+	OpFail
+	// OpMark remembers the current position, for the next OpProgress.
+	OpMark
+	// OpProgress fails if the position is still the one of the last OpMark, e.g. to stop repeating empty matches.
+	OpProgress
+	// OpOpen captures the start of the rule Rules[Arg].
+	OpOpen
+	// OpClose captures the end of the last opened rule.
+	OpClose
+
+	opcodes
+)
+
+var opcodeNames = [...]string{
+	OpByte:     "byte",
+	OpSet:      "set",
+	OpString:   "string",
+	OpRange:    "range",
+	OpTestSet:  "testset",
+	OpChoice:   "choice",
+	OpCommit:   "commit",
+	OpJump:     "jump",
+	OpCall:     "call",
+	OpReturn:   "return",
+	OpFail:     "fail",
+	OpMark:     "mark",
+	OpProgress: "progress",
+	OpOpen:     "open",
+	OpClose:    "close",
+}
+
+func (op Opcode) String() string {
+	if opcodes <= op {
+		return fmt.Sprintf("opcode(%d)", int(op))
+	}
+	return opcodeNames[op]
+}
+
+// Instruction is a single instruction of a program.
+type Instruction struct {
+	Op     Opcode
+	Arg    int
+	Target int
+}
+
+// Rule is a rule of a program, it starts at the instruction Entry.
+type Rule struct {
+	Name  string
+	Entry int
+}
+
+// Range is a range of numeric values, see operators.Range.
+type Range struct {
+	Low, High []byte
+}
+
+// Program is a compiled grammar.
+type Program struct {
+	Instructions []Instruction
+	Rules        []Rule
+	Sets         []operators.ByteSet
+	Strings      [][]byte
+	Ranges       []Range
+	// PEG programs return the first match instead of the longest one.
+	PEG bool
+}
+
+// choice is a saved state, to continue from at pc.
+type choice struct {
+	pc, pos       int
+	frame, frames int
+	mark, marks   int
+	captures      int
+}
+
+// frame is an entry of the call stack, frames are never changed, so saved states can refer to them.
+type frame struct {
+	ret, prev int
+}
+
+// mark is a remembered position, like frames they are never changed.
+type mark struct {
+	pos, prev int
+}
+
+// capture is the start (rule >= 0) or the end (rule < 0) of a rule.
+type capture struct {
+	rule, pos int
+}
+
+// Parse matches the given input with the rule with the given name. It returns the parse tree of the longest match (or
+// the first one in PEG mode), it only contains the nodes of the rules. The tree is nil if the rule does not match.
+func (p *Program) Parse(rule string, s []byte) (*operators.Node, error) {
+	var entry = -1
+	for _, r := range p.Rules {
+		if r.Name == rule {
+			entry = r.Entry
+		}
+	}
+	if entry < 0 {
+		return nil, fmt.Errorf("unknown rule: %s", rule)
+	}
+
+	var (
+		pc, pos  int
+		choices  []choice
+		frames   = []frame{{ret: -1, prev: -1}}
+		frameTop int
+		marks    []mark
+		markTop  = -1
+		captures []capture
+		best     = -1
+		bestCaps []capture
+	)
+	pc = entry
+	for {
+		in := p.Instructions[pc]
+		fail := false
+		switch in.Op {
+		case OpByte:
+			if pos < len(s) && s[pos] == byte(in.Arg) {
+				pos++
+				pc++
+			} else {
+				fail = true
+			}
+		case OpSet:
+			if pos < len(s) && p.Sets[in.Arg].Contains(s[pos]) {
+				pos++
+				pc++
+			} else {
+				fail = true
+			}
+		case OpString:
+			if str := p.Strings[in.Arg]; bytes.HasPrefix(s[pos:], str) {
+				pos += len(str)
+				pc++
+			} else {
+				fail = true
+			}
+		case OpRange:
+			if l := rangeLength(s[pos:], p.Ranges[in.Arg]); l != 0 {
+				pos += l
+				pc++
+			} else {
+				fail = true
+			}
+		case OpTestSet:
+			if pos < len(s) && !p.Sets[in.Arg].Contains(s[pos]) {
+				pc = in.Target
+			} else {
+				pc++
+			}
+		case OpChoice:
+			choices = append(choices, choice{
+				pc: in.Target, pos: pos,
+				frame: frameTop, frames: len(frames),
+				mark: markTop, marks: len(marks),
+				captures: len(captures),
+			})
+			pc++
+		case OpCommit:
+			if len(choices) == 0 {
+				return nil, fmt.Errorf("instruction %d: nothing to commit", pc)
+			}
+			choices = choices[:len(choices)-1]
+			pc = in.Target
+		case OpJump:
+			pc = in.Target
+		case OpCall:
+			frames = append(frames, frame{ret: pc + 1, prev: frameTop})
+			frameTop = len(frames) - 1
+			pc = in.Target
+		case OpReturn:
+			f := frames[frameTop]
+			if 0 <= f.ret {
+				pc, frameTop = f.ret, f.prev
+				break
+			}
+			// the end of the rule that is parsed
+			if best < pos {
+				best = pos
+				bestCaps = append(bestCaps[:0], captures...)
+			}
+			if p.PEG || pos == len(s) {
+				return tree(p, s, bestCaps), nil
+			}
+			fail = true
+		case OpFail:
+			fail = true
+		case OpMark:
+			marks = append(marks, mark{pos: pos, prev: markTop})
+			markTop = len(marks) - 1
+			pc++
+		case OpProgress:
+			if markTop < 0 {
+				return nil, fmt.Errorf("instruction %d: no position to compare", pc)
+			}
+			m := marks[markTop]
+			if m.pos == pos {
+				fail = true
+			} else {
+				markTop = m.prev
+				pc++
+			}
+		case OpOpen:
+			captures = append(captures, capture{rule: in.Arg, pos: pos})
+			pc++
+		case OpClose:
+			captures = append(captures, capture{rule: -1, pos: pos})
+			pc++
+		default:
+			return nil, fmt.Errorf("invalid instruction %d: %s", pc, in.Op)
+		}
+
+		if !fail {
+			continue
+		}
+		if len(choices) == 0 {
+			if best < 0 {
+				return nil, nil
+			}
+			return tree(p, s, bestCaps), nil
+		}
+		c := choices[len(choices)-1]
+		choices = choices[:len(choices)-1]
+		pc, pos = c.pc, c.pos
+		frameTop, frames = c.frame, frames[:c.frames]
+		markTop, marks = c.mark, marks[:c.marks]
+		captures = captures[:c.captures]
+	}
+}
+
+// tree builds the parse tree of the given captures.
+func tree(p *Program, s []byte, captures []capture) *operators.Node {
+	var (
+		root  *operators.Node
+		stack []*operators.Node
+		start []int
+	)
+	for _, c := range captures {
+		if 0 <= c.rule {
+			stack = append(stack, &operators.Node{
+				Key:     p.Rules[c.rule].Name,
+				Kind:    operators.KindRule,
+				Element: operators.Element{Rule: p.Rules[c.rule].Name},
+			})
+			start = append(start, c.pos)
+			continue
+		}
+		if len(stack) == 0 {
+			// not opened
+			continue
+		}
+		node := stack[len(stack)-1]
+		node.Value = s[start[len(start)-1]:c.pos]
+		stack, start = stack[:len(stack)-1], start[:len(start)-1]
+		if len(stack) == 0 {
+			root = node
+		} else {
+			parent := stack[len(stack)-1]
+			parent.Children = append(parent.Children, node)
+		}
+	}
+	return root
+}
+
+// rangeLength returns the length of the match of the given range, it compares the input like operators.Range.
+func rangeLength(s []byte, r Range) int {
+	if len(s) == 0 || len(s) < len(r.Low) || bytes.Compare(s[:len(r.Low)], r.Low) < 0 {
+		return 0
+	}
+	var l int
+	_, size := utf8.DecodeRune(s)
+	for i := len(r.High); 0 < i; i-- {
+		if len(r.High)-i < size && s[len(r.High)-i] <= r.High[i-1] {
+			l++
+		} else {
+			break
+		}
+	}
+	return l
+}
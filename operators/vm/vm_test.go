@@ -0,0 +1,100 @@
+package vm
+
+import (
+	"testing"
+
+	"github.com/elimity-com/abnf/operators"
+)
+
+// digits = 1*%x30-39
+var digits = &Program{
+	Rules: []Rule{{Name: "digits", Entry: 0}},
+	Sets:  []operators.ByteSet{{0x3ff000000000000, 0, 0, 0}},
+	Instructions: []Instruction{
+		{Op: OpOpen, Arg: 0},
+		{Op: OpSet, Arg: 0},
+		{Op: OpChoice, Target: 5},
+		{Op: OpSet, Arg: 0},
+		{Op: OpJump, Target: 2},
+		{Op: OpClose},
+		{Op: OpReturn},
+	},
+}
+
+func TestProgramParse(t *testing.T) {
+	for _, test := range []struct {
+		input, value string
+		match        bool
+	}{
+		{"123a", "123", true},
+		{"1", "1", true},
+		{"a", "", false},
+	} {
+		node, err := digits.Parse("digits", []byte(test.input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (node != nil) != test.match || node != nil && node.String() != test.value {
+			t.Errorf("%q: expected %q, got %v", test.input, test.value, node)
+		}
+	}
+
+	peg := *digits
+	peg.PEG = true
+	peg.Instructions = append([]Instruction(nil), digits.Instructions...)
+	peg.Instructions[4] = Instruction{Op: OpCommit, Target: 2}
+	if node, err := peg.Parse("digits", []byte("123a")); err != nil || node.String() != "123" {
+		t.Errorf("expected \"123\", got %v %v", node, err)
+	}
+}
+
+func TestProgramString(t *testing.T) {
+	expected := "digits:\n" +
+		"     0  open digits\n" +
+		"     1  set %x30-39\n" +
+		"     2  choice 5\n" +
+		"     3  set %x30-39\n" +
+		"     4  jump 2\n" +
+		"     5  close\n" +
+		"     6  return\n"
+	if actual := digits.String(); actual != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, actual)
+	}
+}
+
+func TestProgramUnmarshalBinary(t *testing.T) {
+	data, err := digits.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var p Program
+	if err := p.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if p.String() != digits.String() {
+		t.Errorf("expected\n%s\ngot\n%s", digits, &p)
+	}
+
+	invalid := func(f func(p *Program)) []byte {
+		p := *digits
+		p.Instructions = append([]Instruction(nil), digits.Instructions...)
+		p.Rules = append([]Rule(nil), digits.Rules...)
+		f(&p)
+		data, _ := p.MarshalBinary()
+		return data
+	}
+	for name, data := range map[string][]byte{
+		"magic":     []byte("abnf"),
+		"truncated": data[:len(data)-1],
+		"trailing":  append(append([]byte(nil), data...), 0),
+		"opcode":    invalid(func(p *Program) { p.Instructions[1].Op = opcodes }),
+		"set":       invalid(func(p *Program) { p.Instructions[1].Arg = 1 }),
+		"target":    invalid(func(p *Program) { p.Instructions[2].Target = 7 }),
+		"entry":     invalid(func(p *Program) { p.Rules[0].Entry = 7 }),
+		"end":       invalid(func(p *Program) { p.Instructions = p.Instructions[:6] }),
+	} {
+		if err := p.UnmarshalBinary(data); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package operators
+
+import "errors"
+
+// SkipChildren can be returned by a (pre) WalkFunc to skip the children of the visited node, the post WalkFunc will not
+// be called for that node either.
+var SkipChildren = errors.New("skip children")
+
+// WalkFunc is called for every visited node. The path contains all the (reported) ancestors of the node, starting at
+// the root. Returning an error other than SkipChildren stops the walk.
+type WalkFunc func(node *Node, path []*Node) error
+
+// Walker traverses a tree depth-first.
+type Walker struct {
+	// Pre is called before the children of a node are visited.
+	Pre WalkFunc
+	// Post is called after the children of a node are visited.
+	Post WalkFunc
+	// Filter decides which nodes get reported to Pre and Post. The children of nodes that are filtered out are still
+	// visited, e.g. Rules("rulename", "alternation") only reports the nodes of these rules.
+	Filter func(node *Node) bool
+}
+
+// Walk traverses the tree starting from the given node.
+func (w Walker) Walk(node *Node) error {
+	err := w.walk(node, nil)
+	if err == SkipChildren {
+		return nil
+	}
+	return err
+}
+
+func (w Walker) walk(node *Node, path []*Node) error {
+	report := w.Filter == nil || w.Filter(node)
+	if report && w.Pre != nil {
+		if err := w.Pre(node, path); err != nil {
+			return err
+		}
+	}
+
+	childPath := path
+	if report {
+		childPath = append(path[:len(path):len(path)], node)
+	}
+	for _, child := range node.Children {
+		if err := w.walk(child, childPath); err != nil && err != SkipChildren {
+			return err
+		}
+	}
+
+	if report && w.Post != nil {
+		if err := w.Post(node, path); err != nil && err != SkipChildren {
+			return err
+		}
+	}
+	return nil
+}
+
+// Walk traverses the tree starting from the given node, calling pre and post for every node. Both can be nil.
+func Walk(node *Node, pre, post WalkFunc) error {
+	return Walker{Pre: pre, Post: post}.Walk(node)
+}
+
+// Inspect traverses the tree in pre-order, starting from the given node. The children of a node are only visited if f
+// returns true.
+func Inspect(node *Node, f func(node *Node) bool) {
+	_ = Walk(node, func(node *Node, _ []*Node) error {
+		if !f(node) {
+			return SkipChildren
+		}
+		return nil
+	}, nil)
+}
+
+// Keys returns a filter that only accepts nodes with one of the given keys. Anonymous nodes are named after their ABNF
+// text, so use Rules to only accept the nodes of rules.
+func Keys(keys ...string) func(node *Node) bool {
+	set := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		set[key] = struct{}{}
+	}
+	return func(node *Node) bool {
+		_, ok := set[node.Key]
+		return ok
+	}
+}
+
+// Kinds returns a filter that only accepts nodes of one of the given kinds, e.g. KindRule and KindTerminal.
+func Kinds(kinds ...Kind) func(node *Node) bool {
+	set := make(map[Kind]struct{}, len(kinds))
+	for _, kind := range kinds {
+		set[kind] = struct{}{}
+	}
+	return func(node *Node) bool {
+		_, ok := set[node.Kind]
+		return ok
+	}
+}
+
+// Rules returns a filter that only accepts the root nodes of the rules with the given names.
+func Rules(names ...string) func(node *Node) bool {
+	keys := Keys(names...)
+	return func(node *Node) bool {
+		return node.IsRule() && keys(node)
+	}
+}
@@ -0,0 +1,120 @@
+package operators
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestWalk(t *testing.T) {
+	rule := Concat(`abc`, a, Alts(`b / c`, b, c), Optional(`[ c ]`, c))
+	node := rule([]byte("abc")).Best()
+
+	var pre, post []string
+	if err := Walk(node, func(node *Node, path []*Node) error {
+		pre = append(pre, node.Key)
+		return nil
+	}, func(node *Node, path []*Node) error {
+		post = append(post, node.Key)
+		return nil
+	}); err != nil {
+		t.Error(err)
+	}
+	if str := strings.Join(pre, ","); str != "abc,a,b / c,b,[ c ],c" {
+		t.Errorf("invalid pre-order: %s", str)
+	}
+	if str := strings.Join(post, ","); str != "a,b,b / c,c,[ c ],abc" {
+		t.Errorf("invalid post-order: %s", str)
+	}
+
+	t.Run("Path", func(t *testing.T) {
+		_ = Walk(node, func(node *Node, path []*Node) error {
+			if node.Key == "c" {
+				var keys []string
+				for _, n := range path {
+					keys = append(keys, n.Key)
+				}
+				if str := strings.Join(keys, ","); str != "abc,[ c ]" {
+					t.Errorf("invalid path: %s", str)
+				}
+			}
+			return nil
+		}, nil)
+	})
+
+	t.Run("SkipChildren", func(t *testing.T) {
+		var keys []string
+		Inspect(node, func(node *Node) bool {
+			keys = append(keys, node.Key)
+			return node.Key != "b / c"
+		})
+		if str := strings.Join(keys, ","); str != "abc,a,b / c,[ c ],c" {
+			t.Errorf("invalid order: %s", str)
+		}
+	})
+
+	t.Run("Filter", func(t *testing.T) {
+		var keys []string
+		if err := (Walker{
+			Pre: func(node *Node, path []*Node) error {
+				keys = append(keys, node.Key)
+				if node.Key != "abc" && (len(path) != 1 || path[0].Key != "abc") {
+					t.Errorf("invalid path for %s: %v", node.Key, path)
+				}
+				return nil
+			},
+			Filter: Keys("abc", "b", "c"),
+		}).Walk(node); err != nil {
+			t.Error(err)
+		}
+		if str := strings.Join(keys, ","); str != "abc,b,c" {
+			t.Errorf("invalid order: %s", str)
+		}
+	})
+
+	t.Run("Kinds", func(t *testing.T) {
+		// the terminal c has the same key as the rule c
+		node := Concat(`a c`, a, Rule("c", Alts(`c / "x"`, c, Terminal("x", []byte("x")))))([]byte("ac")).Best()
+		for _, test := range []struct {
+			filter   func(node *Node) bool
+			expected string
+		}{
+			{Kinds(KindTerminal), "a,c"},
+			{Kinds(KindRule, KindConcatenation), "a c,c"},
+			{Rules("c"), "c"},
+			{Keys("c"), "c,c"},
+		} {
+			var keys []string
+			if err := (Walker{
+				Pre: func(node *Node, _ []*Node) error {
+					keys = append(keys, node.Key)
+					return nil
+				},
+				Filter: test.filter,
+			}).Walk(node); err != nil {
+				t.Error(err)
+			}
+			if str := strings.Join(keys, ","); str != test.expected {
+				t.Errorf("expected %s, got %s", test.expected, str)
+			}
+		}
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		stop := errors.New("stop")
+		var visited int
+		err := Walk(node, func(node *Node, _ []*Node) error {
+			visited++
+			if node.Key == "a" {
+				return stop
+			}
+			return nil
+		}, nil)
+		if err != stop {
+			t.Errorf("expected stop error, got %v", err)
+		}
+		if visited != 2 {
+			t.Errorf("expected two visited nodes, got %d", visited)
+		}
+	})
+}
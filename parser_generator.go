@@ -1,9 +1,13 @@
 package abnf
 
 import (
+	"fmt"
+	"sync"
+
 	"github.com/elimity-com/abnf/encoding"
 	"github.com/elimity-com/abnf/operators"
-	"sync"
+	"github.com/elimity-com/abnf/operators/peg"
+	"github.com/elimity-com/abnf/operators/recognize"
 )
 
 type ParserGenerator struct {
@@ -12,108 +16,312 @@ type ParserGenerator struct {
 	// ExternalABNF reference to abnf syntax
 	// e.g. ALPHA from github.com/elimity-com/abnf/core
 	ExternalABNF map[string]operators.Operator
+	// Actions to run bottom-up on the parse tree, by rule name
+	// e.g. turn a date-time into a time.Time
+	Actions operators.Actions
+	// PEG uses ordered choice and greedy repetitions, so every operator returns at most one alternative
+	// e.g. "a" / "ab" only matches "a"
+	PEG bool
+	// DFA compiles the rules that are not recursive to deterministic automata, these only return a leaf node per match
+	// rules that refer to the ExternalABNF can not be compiled, it is ignored in PEG mode
+	DFA bool
+	// Dispatch only tries the alternatives of an alternation that can start with the next byte of the input, based on
+	// their FIRST sets (see Analyze), rules of the ExternalABNF are assumed to start with any byte
+	// it is ignored in PEG mode
+	Dispatch bool
+	// ExternalRecognizers reference to recognizers of the ExternalABNF, used by GenerateABNFAsRecognizers
+	// e.g. CoreRecognizers(false), the other ExternalABNF get lifted (and allocate their parse trees)
+	ExternalRecognizers map[string]recognize.Operator
 
 	sync.WaitGroup
 	internalABNFMutex sync.RWMutex
 	internalABNF      map[string]operators.Operator
+	internalPEG       map[string]peg.Operator
+	analysis          *Analysis
+
+	// references to the rules, they get resolved after all the rules are generated (e.g. recursive rules)
+	references          map[string]*operators.Operator
+	pegReferences       map[string]*peg.Operator
+	recognizeReferences map[string]*recognize.Operator
 }
 
 func (g *ParserGenerator) GenerateABNFAsOperators() map[string]operators.Operator {
+	return g.generate(NewRuleSet(g.RawABNF))
+}
+
+// Generate returns the rules as operators, like GenerateABNFAsOperators. An error is returned if a rule refers to a rule
+// that is neither part of the RawABNF nor of the ExternalABNF, instead of panicking when the reference gets used.
+func (g *ParserGenerator) Generate() (map[string]operators.Operator, error) {
 	ruleSet := NewRuleSet(g.RawABNF)
+	if err := g.check(ruleSet, false); err != nil {
+		return nil, err
+	}
+	return g.generate(ruleSet), nil
+}
 
+func (g *ParserGenerator) generate(ruleSet RuleSet) map[string]operators.Operator {
 	g.internalABNFMutex = sync.RWMutex{}
 	g.internalABNF = make(map[string]operators.Operator)
+	g.internalPEG = make(map[string]peg.Operator)
+	g.references = make(map[string]*operators.Operator)
+	g.pegReferences = make(map[string]*peg.Operator)
 	for name, _ := range ruleSet {
-		g.internalABNFMutex.Lock()
 		g.internalABNF[name] = nil
-		g.internalABNFMutex.Unlock()
+		g.references[name] = new(operators.Operator)
+		g.pegReferences[name] = new(peg.Operator)
+	}
+
+	g.analyze(ruleSet)
+
+	compiled := make(map[string]bool)
+	if g.DFA && !g.PEG {
+		for _, name := range ruleSet.NonRecursive() {
+			dfa, err := ruleSet.DFA(name)
+			if err != nil {
+				continue
+			}
+			g.internalABNF[name] = dfa.Operator(name)
+			compiled[name] = true
+		}
 	}
 
 	for name, rule := range ruleSet {
+		if compiled[name] {
+			continue
+		}
 		g.Add(1)
 		go func(name string, rule Rule) {
+			defer g.Done()
+			if g.PEG {
+				function := rule.toPEG(g)
+				g.internalABNFMutex.Lock()
+				g.internalPEG[name] = function
+				g.internalABNF[name] = function.AsOperator()
+				g.internalABNFMutex.Unlock()
+				return
+			}
 			function := rule.toFunc(g)
 			g.internalABNFMutex.Lock()
 			g.internalABNF[name] = function
 			g.internalABNFMutex.Unlock()
-			defer g.Done()
 		}(name, rule)
 	}
 	g.Wait()
+	for name, reference := range g.references {
+		*reference = g.internalABNF[name]
+		*g.pegReferences[name] = g.internalPEG[name]
+	}
 	return g.internalABNF
 }
 
+// check returns an error for the first reference to a rule that is neither part of the given set nor of the
+// ExternalABNF (or the ExternalRecognizers, for recognizers).
+func (g *ParserGenerator) check(ruleSet RuleSet, recognizers bool) error {
+	for _, name := range ruleSet.names() {
+		if err := walkOperators(ruleSet[name].operator, func(operator Operator) error {
+			reference, ok := operator.(RuleNameOperator)
+			if !ok {
+				return nil
+			}
+			if _, ok := ruleSet[reference.key]; ok {
+				return nil
+			}
+			if _, ok := g.ExternalABNF[reference.key]; ok {
+				return nil
+			}
+			if _, ok := g.ExternalRecognizers[reference.key]; ok && recognizers {
+				return nil
+			}
+			return fmt.Errorf("unknown rule: %s, referenced by %s", reference.key, name)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// analyze sets the analysis of the given rules if alternations get dispatched.
+func (g *ParserGenerator) analyze(ruleSet RuleSet) {
+	g.analysis = nil
+	if g.Dispatch && !g.PEG {
+		internal := make(RuleSet)
+		for name, rule := range ruleSet {
+			if _, ok := g.ExternalABNF[name]; !ok {
+				internal[name] = rule
+			}
+		}
+		g.analysis = internal.Analyze()
+	}
+}
+
+// GenerateABNFAsRecognizers returns the rules as recognizers, these do not build parse trees but only return the
+// lengths of the matches (see recognize.Recognize). The PEG option is ignored.
+func (g *ParserGenerator) GenerateABNFAsRecognizers() map[string]recognize.Operator {
+	return g.recognizers(NewRuleSet(g.RawABNF))
+}
+
+// GenerateRecognizers returns the rules as recognizers, like GenerateABNFAsRecognizers. An error is returned if a rule
+// refers to a rule that is neither part of the RawABNF nor of the ExternalABNF (or the ExternalRecognizers).
+func (g *ParserGenerator) GenerateRecognizers() (map[string]recognize.Operator, error) {
+	ruleSet := NewRuleSet(g.RawABNF)
+	if err := g.check(ruleSet, true); err != nil {
+		return nil, err
+	}
+	return g.recognizers(ruleSet), nil
+}
+
+func (g *ParserGenerator) recognizers(ruleSet RuleSet) map[string]recognize.Operator {
+	g.analyze(ruleSet)
+	g.recognizeReferences = make(map[string]*recognize.Operator)
+	for name := range ruleSet {
+		g.recognizeReferences[name] = new(recognize.Operator)
+	}
+	recognizers := make(map[string]recognize.Operator)
+	if g.DFA {
+		for _, name := range ruleSet.NonRecursive() {
+			if dfa, err := ruleSet.DFA(name); err == nil {
+				recognizers[name] = recognize.DFA(dfa)
+			}
+		}
+	}
+	for name, rule := range ruleSet {
+		if _, ok := recognizers[name]; !ok {
+			recognizers[name] = rule.operator.toRecognizer(g)
+		}
+	}
+	for name, reference := range g.recognizeReferences {
+		*reference = recognizers[name]
+	}
+	return recognizers
+}
+
+// Parse parses the given input with the given rule and runs the Actions bottom-up on the longest parse tree that matches
+// the whole input.
+func (g *ParserGenerator) Parse(rule string, s []byte) (interface{}, error) {
+	if g.internalABNF == nil {
+		if _, err := g.Generate(); err != nil {
+			return nil, err
+		}
+	}
+	operator, ok := g.internalABNF[rule]
+	if !ok {
+		return nil, fmt.Errorf("unknown rule: %s", rule)
+	}
+	return operators.Parse(operator, s, g.Actions)
+}
+
 type parserGeneratorNode interface {
-	toFunc(g *ParserGenerator) operators.Operator
+	toFunc(g *ParserGenerator, element operators.Element) operators.Operator
+	toPEG(g *ParserGenerator, element operators.Element) peg.Operator
+	toRecognizer(g *ParserGenerator) recognize.Operator
 }
 
 func (r Rule) toFunc(g *ParserGenerator) operators.Operator {
-	return r.operator.toFunc(g)
+	return operators.Rule(r.name, r.operator.toFunc(g, operators.Element{Rule: r.name}))
 }
 
-func (alt AlternationOperator) toFunc(g *ParserGenerator) operators.Operator {
+// subFuncs returns the operators of the sub operators of the given operator, which is the given element. The nodes of
+// anonymous sub operators refer to their own element, see operators.WithElement.
+func (g *ParserGenerator) subFuncs(operator Operator, element operators.Element) []operators.Operator {
 	var rules []operators.Operator
-	for _, subOperator := range alt.subOperators {
-		rules = append(rules, subOperator.toFunc(g))
+	for i, subElement := range subElements(operator, element) {
+		subOperator := subOperators(operator)[i]
+		rule := subOperator.toFunc(g, subElement)
+		if _, ok := subOperator.(RuleNameOperator); !ok {
+			rule = operators.WithElement(subElement.Rule, subElement.Index, rule)
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+func (alt AlternationOperator) toFunc(g *ParserGenerator, element operators.Element) operators.Operator {
+	rules := g.subFuncs(alt, element)
+	if first, ok := dispatch(g.analysis, alt); ok {
+		return operators.AltsFirst(alt.key, first, rules...)
 	}
 	return operators.Alts(alt.key, rules...)
 }
 
-func (concat ConcatenationOperator) toFunc(g *ParserGenerator) operators.Operator {
-	var rules []operators.Operator
-	for _, subOperator := range concat.subOperators {
-		rules = append(rules, subOperator.toFunc(g))
+// dispatch returns the bytes the alternatives of the given alternation can start with, alternatives that can match the
+// empty input can start with any byte. It returns false if there is no analysis or if every alternative can start with
+// any byte, then there is nothing to gain.
+func dispatch(a *Analysis, alt AlternationOperator) ([]operators.ByteSet, bool) {
+	if a == nil {
+		return nil, false
 	}
+	var all operators.ByteSet
+	all.AddRange(0, 255)
+	var first []operators.ByteSet
+	var ok bool
+	for _, subOperator := range alt.subOperators {
+		bytes := all
+		if !a.NullableOperator(subOperator) {
+			bytes = a.FirstOperator(subOperator)
+		}
+		ok = ok || bytes != all
+		first = append(first, bytes)
+	}
+	return first, ok
+}
+
+func (concat ConcatenationOperator) toFunc(g *ParserGenerator, element operators.Element) operators.Operator {
+	rules := g.subFuncs(concat, element)
 	return operators.Concat(concat.key, rules...)
 }
 
-func (rep RepetitionOperator) toFunc(g *ParserGenerator) operators.Operator {
+func (rep RepetitionOperator) toFunc(g *ParserGenerator, element operators.Element) operators.Operator {
+	rule := g.subFuncs(rep, element)[0]
 	if rep.min == rep.max {
-		return operators.RepeatN(rep.key, rep.min, rep.subOperator.toFunc(g))
+		return operators.RepeatN(rep.key, rep.min, rule)
 	}
 
 	if rep.max == -1 {
 		switch rep.min {
 		case 0:
-			return operators.Repeat0Inf(rep.key, rep.subOperator.toFunc(g))
+			return operators.Repeat0Inf(rep.key, rule)
 		case 1:
-			return operators.Repeat1Inf(rep.key, rep.subOperator.toFunc(g))
+			return operators.Repeat1Inf(rep.key, rule)
 		}
 	}
 
-	return operators.Repeat(rep.key, rep.min, rep.max, rep.subOperator.toFunc(g))
+	return operators.Repeat(rep.key, rep.min, rep.max, rule)
 }
 
-func (name RuleNameOperator) toFunc(g *ParserGenerator) operators.Operator {
+func (name RuleNameOperator) toFunc(g *ParserGenerator, _ operators.Element) operators.Operator {
 	if external, ok := g.ExternalABNF[name.key]; ok {
 		return external
 	}
-	var wg sync.WaitGroup
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		for {
-			g.internalABNFMutex.RLock()
-			v := g.internalABNF[name.key]
-			g.internalABNFMutex.RUnlock()
-			if v != nil {
-				break
-			}
-		}
-	}()
-	wg.Wait()
-	return g.internalABNF[name.key]
+	// the operator of the rule might not be generated yet (e.g. recursive rules), it gets resolved after generation
+	reference := g.references[name.key]
+	return func(s []byte) operators.Alternatives {
+		return (*reference)(s)
+	}
 }
 
-func (opt OptionOperator) toFunc(g *ParserGenerator) operators.Operator {
-	return operators.Optional(opt.key, opt.subOperator.toFunc(g))
+func (opt OptionOperator) toFunc(g *ParserGenerator, element operators.Element) operators.Operator {
+	return operators.Optional(opt.key, g.subFuncs(opt, element)[0])
 }
 
-func (value CharacterValueOperator) toFunc(_ *ParserGenerator) operators.Operator {
+func (value CharacterValueOperator) toFunc(*ParserGenerator, operators.Element) operators.Operator {
 	return operators.String(value.value, value.value)
 }
 
-func (value NumericValueOperator) toFunc(g *ParserGenerator) operators.Operator {
+func (value NumericValueOperator) toFunc(*ParserGenerator, operators.Element) operators.Operator {
+	low, high := value.bytes()
+	switch {
+	case value.hyphen:
+		return operators.Range(value.key, low, high)
+	case value.points:
+		return operators.String(value.key, string(low))
+	default:
+		return operators.Terminal(value.key, low)
+	}
+}
+
+// bytes returns the lowest and highest bytes of a range, or the bytes of the value (and nil). Single values get encoded
+// as ASCII.
+func (value NumericValueOperator) bytes() ([]byte, []byte) {
 	values := value.toIntegers()
 
 	if value.hyphen {
@@ -127,20 +335,17 @@ func (value NumericValueOperator) toFunc(g *ParserGenerator) operators.Operator
 		for i, v := range max {
 			maxValues[i] = byte(v)
 		}
-
-		return operators.Range(value.key, minValues, maxValues)
+		return minValues, maxValues
 	}
 
 	if value.points {
-		var str string
+		var bytes []byte
 		for _, part := range values {
-			var bytes []byte
 			for _, i := range part {
 				bytes = append(bytes, byte(i))
 			}
-			str += string(bytes)
 		}
-		return operators.String(value.key, str)
+		return bytes, nil
 	}
 
 	bytes := make([]byte, len(values[0]))
@@ -148,5 +353,115 @@ func (value NumericValueOperator) toFunc(g *ParserGenerator) operators.Operator
 		bytes[i] = byte(v)
 	}
 	bytes, _ = encoding.ASCII.NewEncoder().Bytes(bytes)
-	return operators.Terminal(value.key, bytes)
+	return bytes, nil
+}
+
+func (r Rule) toPEG(g *ParserGenerator) peg.Operator {
+	return peg.Rule(r.name, r.operator.toPEG(g, operators.Element{Rule: r.name}))
+}
+
+// subPEGs returns the PEG operators of the sub operators of the given operator, see subFuncs.
+func (g *ParserGenerator) subPEGs(operator Operator, element operators.Element) []peg.Operator {
+	var rules []peg.Operator
+	for i, subElement := range subElements(operator, element) {
+		subOperator := subOperators(operator)[i]
+		rule := subOperator.toPEG(g, subElement)
+		if _, ok := subOperator.(RuleNameOperator); !ok {
+			rule = peg.WithElement(subElement.Rule, subElement.Index, rule)
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+func (alt AlternationOperator) toPEG(g *ParserGenerator, element operators.Element) peg.Operator {
+	return peg.Alts(alt.key, g.subPEGs(alt, element)...)
+}
+
+func (concat ConcatenationOperator) toPEG(g *ParserGenerator, element operators.Element) peg.Operator {
+	return peg.Concat(concat.key, g.subPEGs(concat, element)...)
+}
+
+func (rep RepetitionOperator) toPEG(g *ParserGenerator, element operators.Element) peg.Operator {
+	return peg.Repeat(rep.key, rep.min, rep.max, g.subPEGs(rep, element)[0])
+}
+
+func (name RuleNameOperator) toPEG(g *ParserGenerator, _ operators.Element) peg.Operator {
+	if external, ok := g.ExternalABNF[name.key]; ok {
+		return peg.Lift(external)
+	}
+	// the operator of the rule might not be generated yet (e.g. recursive rules), it gets resolved after generation
+	reference := g.pegReferences[name.key]
+	return func(s []byte) *operators.Node {
+		return (*reference)(s)
+	}
+}
+
+func (opt OptionOperator) toPEG(g *ParserGenerator, element operators.Element) peg.Operator {
+	return peg.Optional(opt.key, g.subPEGs(opt, element)[0])
+}
+
+func (value CharacterValueOperator) toPEG(g *ParserGenerator, element operators.Element) peg.Operator {
+	return peg.Lift(value.toFunc(g, element))
+}
+
+func (value NumericValueOperator) toPEG(g *ParserGenerator, element operators.Element) peg.Operator {
+	return peg.Lift(value.toFunc(g, element))
+}
+
+func (alt AlternationOperator) toRecognizer(g *ParserGenerator) recognize.Operator {
+	var rules []recognize.Operator
+	for _, subOperator := range alt.subOperators {
+		rules = append(rules, subOperator.toRecognizer(g))
+	}
+	if first, ok := dispatch(g.analysis, alt); ok {
+		return recognize.AltsFirst(first, rules...)
+	}
+	return recognize.Alts(rules...)
+}
+
+func (concat ConcatenationOperator) toRecognizer(g *ParserGenerator) recognize.Operator {
+	var rules []recognize.Operator
+	for _, subOperator := range concat.subOperators {
+		rules = append(rules, subOperator.toRecognizer(g))
+	}
+	return recognize.Concat(rules...)
+}
+
+func (rep RepetitionOperator) toRecognizer(g *ParserGenerator) recognize.Operator {
+	return recognize.Repeat(rep.min, rep.max, rep.subOperator.toRecognizer(g))
+}
+
+func (name RuleNameOperator) toRecognizer(g *ParserGenerator) recognize.Operator {
+	if external, ok := g.ExternalRecognizers[name.key]; ok {
+		return external
+	}
+	if external, ok := g.ExternalABNF[name.key]; ok {
+		return recognize.Lift(external)
+	}
+	// the recognizers are only complete after they are generated, they get resolved after generation
+	reference := g.recognizeReferences[name.key]
+	return func(s []byte, lengths []int) []int {
+		return (*reference)(s, lengths)
+	}
+}
+
+func (opt OptionOperator) toRecognizer(g *ParserGenerator) recognize.Operator {
+	return recognize.Optional(opt.subOperator.toRecognizer(g))
+}
+
+func (value CharacterValueOperator) toRecognizer(_ *ParserGenerator) recognize.Operator {
+	return recognize.String(value.value)
+}
+
+func (value NumericValueOperator) toRecognizer(_ *ParserGenerator) recognize.Operator {
+	low, high := value.bytes()
+	switch {
+	case value.hyphen:
+		return recognize.Range(low, high)
+	case value.points:
+		return recognize.String(string(low))
+	default:
+		return recognize.Terminal(low)
+	}
 }
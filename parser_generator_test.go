@@ -2,7 +2,10 @@ package abnf
 
 import (
 	"github.com/elimity-com/abnf/operators"
+	"github.com/elimity-com/abnf/operators/recognize"
 	"io/ioutil"
+	"reflect"
+	"strconv"
 	"testing"
 )
 
@@ -148,3 +151,209 @@ func testRanges(t *testing.T, ranges []characterRange, operator operators.Operat
 		}
 	}
 }
+
+func TestParserGeneratorParse(t *testing.T) {
+	g := ParserGenerator{
+		RawABNF:      []byte("sum = number *(\"+\" number)\nnumber = 1*DIGIT\n"),
+		ExternalABNF: CoreOperators(false),
+		Actions: operators.Actions{
+			"number": func(node *operators.Node, _ []interface{}) (interface{}, error) {
+				return strconv.Atoi(node.String())
+			},
+			"sum": func(_ *operators.Node, values []interface{}) (interface{}, error) {
+				var sum int
+				for _, value := range values {
+					sum += value.(int)
+				}
+				return sum, nil
+			},
+		},
+	}
+
+	value, err := g.Parse("sum", []byte("12+30"))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if value != 42 {
+		t.Errorf("expected 42, got %v", value)
+	}
+
+	if _, err := g.Parse("product", []byte("1*2")); err == nil {
+		t.Error("expected an error for an unknown rule")
+	}
+	if _, err := g.Parse("sum", []byte("1+")); err == nil {
+		t.Error("expected an error for a partial match")
+	}
+}
+
+func TestParserGeneratorRecursive(t *testing.T) {
+	rawABNF, err := ioutil.ReadFile("./testdata/definition.abnf")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	g := ParserGenerator{
+		RawABNF:      rawABNF,
+		ExternalABNF: CoreOperators(false),
+	}
+	rulelist := g.GenerateABNFAsOperators()["rulelist"]
+
+	rawCore, err := ioutil.ReadFile("./testdata/core.abnf")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if best := rulelist(rawCore).Best(); len(best.Value) != len(rawCore) {
+		t.Errorf("expected a full match, got %d of %d bytes", len(best.Value), len(rawCore))
+	}
+}
+
+func TestParserGeneratorUnknownRule(t *testing.T) {
+	g := ParserGenerator{
+		RawABNF:      []byte("a = b / DIGIT\nb = \"b\" [a] c\n"),
+		ExternalABNF: CoreOperators(false),
+	}
+	expected := "unknown rule: c, referenced by b"
+	if _, err := g.Generate(); err == nil || err.Error() != expected {
+		t.Errorf("expected %q, got %v", expected, err)
+	}
+	if _, err := g.GenerateRecognizers(); err == nil || err.Error() != expected {
+		t.Errorf("expected %q, got %v", expected, err)
+	}
+	if _, err := g.Parse("a", []byte("b")); err == nil || err.Error() != expected {
+		t.Errorf("expected %q, got %v", expected, err)
+	}
+
+	g.RawABNF = append(g.RawABNF, "c = \"c\"\n"...)
+	for _, peg := range []bool{false, true} {
+		g.PEG = peg
+		rules, err := g.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if best := rules["a"]([]byte("bbcc")).Best(); best.String() != "bbcc" {
+			t.Errorf("expected a full match, got %q", best)
+		}
+	}
+	recognizers, err := g.GenerateRecognizers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, ok := recognize.Recognize(recognizers["a"], []byte("bbcc")); !ok || n != 4 {
+		t.Errorf("expected a full match, got %d", n)
+	}
+}
+
+func TestParserGeneratorPEG(t *testing.T) {
+	rawABNF := []byte("word = (\"a\" / \"ab\") *\"c\"\n")
+
+	full := ParserGenerator{RawABNF: rawABNF}
+	if best := full.GenerateABNFAsOperators()["word"]([]byte("abcc")).Best(); best.String() != "abcc" {
+		t.Errorf("expected \"abcc\", got %q", best)
+	}
+
+	ordered := ParserGenerator{RawABNF: rawABNF, PEG: true}
+	nodes := ordered.GenerateABNFAsOperators()["word"]([]byte("abcc"))
+	if len(nodes) != 1 || nodes[0].String() != "a" {
+		t.Errorf("expected a single \"a\" node, got %v", nodes)
+	}
+	if nodes := ordered.GenerateABNFAsOperators()["word"]([]byte("acc")); len(nodes) != 1 || nodes[0].String() != "acc" {
+		t.Errorf("expected a single \"acc\" node, got %v", nodes)
+	}
+}
+
+// definitionWithCore returns the ABNF definition together with the (lenient) core rules, so no ExternalABNF is needed.
+func definitionWithCore(t testing.TB) []byte {
+	rawABNF, err := ioutil.ReadFile("./testdata/definition.abnf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return append(rawABNF, coreABNF...)
+}
+
+func TestParserGeneratorDispatch(t *testing.T) {
+	rawABNF := definitionWithCore(t)
+	alts := (&ParserGenerator{RawABNF: rawABNF}).GenerateABNFAsOperators()["rulelist"]
+	dispatch := (&ParserGenerator{RawABNF: rawABNF, Dispatch: true}).GenerateABNFAsOperators()["rulelist"]
+
+	for _, s := range []string{coreABNF, "a = b / c\n", "a = %x30-39 / \"-\" [b]\n", "a = "} {
+		if expected, actual := alts([]byte(s)), dispatch([]byte(s)); !reflect.DeepEqual(expected, actual) {
+			t.Errorf("%q: expected %d alternatives, got %d", s, len(expected), len(actual))
+		}
+	}
+}
+
+func BenchmarkParserGeneratorDispatch(b *testing.B) {
+	rawCore := []byte(coreABNF)
+	for _, grammar := range []struct {
+		name  string
+		raw   []byte
+		rule  string
+		input []byte
+	}{
+		{"core", rawCore, "HEXDIG", []byte("f")},
+		{"definition", definitionWithCore(b), "rulelist", rawCore},
+	} {
+		for _, dispatch := range []bool{false, true} {
+			name := grammar.name + "/Alts"
+			if dispatch {
+				name = grammar.name + "/AltsFirst"
+			}
+			operator := (&ParserGenerator{RawABNF: grammar.raw, Dispatch: dispatch}).GenerateABNFAsOperators()[grammar.rule]
+			b.Run(name, func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					operator(grammar.input)
+				}
+			})
+		}
+	}
+}
+
+func TestParserGeneratorRecognizers(t *testing.T) {
+	rawABNF, err := ioutil.ReadFile("./testdata/definition.abnf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, g := range []*ParserGenerator{
+		{RawABNF: rawABNF, ExternalABNF: CoreOperators(false), ExternalRecognizers: CoreRecognizers(false)},
+		{RawABNF: rawABNF, ExternalABNF: CoreOperators(false)},
+		{RawABNF: definitionWithCore(t), DFA: true, Dispatch: true},
+	} {
+		rules := g.GenerateABNFAsOperators()
+		recognizers := g.GenerateABNFAsRecognizers()
+		for _, test := range []struct {
+			rule, input string
+		}{
+			{"rulelist", coreABNF},
+			{"rulelist", "a = b / c\nd = 1*2\"x\" ; y\n"},
+			{"rulelist", "a = "},
+			{"repeat", "12*34x"},
+			{"num-val", "%x30-39"},
+			{"c-wsp", ""},
+		} {
+			n, ok := recognize.Recognize(recognizers[test.rule], []byte(test.input))
+			nodes := rules[test.rule]([]byte(test.input))
+			if ok != (len(nodes) != 0) || ok && n != len(nodes.Best().Value) {
+				t.Errorf("%s %q: expected %d nodes, got %d %t", test.rule, test.input, len(nodes), n, ok)
+			}
+		}
+	}
+}
+
+func BenchmarkParserGeneratorRecognizers(b *testing.B) {
+	g := ParserGenerator{RawABNF: definitionWithCore(b), Dispatch: true}
+	rulelist := g.GenerateABNFAsOperators()["rulelist"]
+	recognizer := g.GenerateABNFAsRecognizers()["rulelist"]
+	input := []byte(coreABNF)
+	b.Run("Operators", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			rulelist(input)
+		}
+	})
+	b.Run("Recognizers", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			recognize.Recognize(recognizer, input)
+		}
+	})
+}
@@ -0,0 +1,254 @@
+package abnf
+
+import (
+	"fmt"
+
+	"github.com/elimity-com/abnf/operators"
+	"github.com/elimity-com/abnf/operators/vm"
+)
+
+// GenerateProgram compiles the rules to a program of the vm package, the PEG and Dispatch options are taken into account.
+// Unlike the operators a program does not refer to Go functions, so all the referenced rules need to be part of the
+// RawABNF (the ExternalABNF are not used).
+func (g *ParserGenerator) GenerateProgram() (*vm.Program, error) {
+	ruleSet := NewRuleSet(g.RawABNF)
+	c := &programCompiler{
+		program:  &vm.Program{PEG: g.PEG},
+		ruleSet:  ruleSet,
+		analysis: ruleSet.Analyze(),
+		dispatch: g.Dispatch,
+		rules:    make(map[string]int),
+		sets:     make(map[operators.ByteSet]int),
+		strings:  make(map[string]int),
+	}
+	for i, name := range ruleSet.names() {
+		c.rules[name] = i
+		c.program.Rules = append(c.program.Rules, vm.Rule{Name: name})
+	}
+	for i, name := range ruleSet.names() {
+		c.program.Rules[i].Entry = len(c.program.Instructions)
+		c.emit(vm.OpOpen, i, 0)
+		if err := c.compile(ruleSet[name].operator); err != nil {
+			return nil, fmt.Errorf("rule %s: %v", name, err)
+		}
+		c.emit(vm.OpClose, 0, 0)
+		c.emit(vm.OpReturn, 0, 0)
+	}
+	for _, call := range c.calls {
+		c.program.Instructions[call.at].Target = c.program.Rules[c.rules[call.rule]].Entry
+	}
+	return c.program, nil
+}
+
+// programCompiler compiles the operators of a set to instructions.
+type programCompiler struct {
+	program  *vm.Program
+	ruleSet  RuleSet
+	analysis *Analysis
+	dispatch bool
+
+	// rules contains the indices of the rules of the program
+	rules map[string]int
+	// calls need to be pointed to the entry of the rule once all the rules are compiled
+	calls   []programCall
+	sets    map[operators.ByteSet]int
+	strings map[string]int
+}
+
+type programCall struct {
+	at   int
+	rule string
+}
+
+// emit adds an instruction and returns its index.
+func (c *programCompiler) emit(op vm.Opcode, arg, target int) int {
+	c.program.Instructions = append(c.program.Instructions, vm.Instruction{Op: op, Arg: arg, Target: target})
+	return len(c.program.Instructions) - 1
+}
+
+// next returns the index of the next instruction.
+func (c *programCompiler) next() int {
+	return len(c.program.Instructions)
+}
+
+// commit emits the end of a choice that jumps to the given target, in PEG mode the choice is dropped.
+func (c *programCompiler) commit(target int) int {
+	if c.program.PEG {
+		return c.emit(vm.OpCommit, 0, target)
+	}
+	return c.emit(vm.OpJump, 0, target)
+}
+
+func (c *programCompiler) setIndex(set operators.ByteSet) int {
+	if i, ok := c.sets[set]; ok {
+		return i
+	}
+	c.sets[set] = len(c.program.Sets)
+	c.program.Sets = append(c.program.Sets, set)
+	return c.sets[set]
+}
+
+func (c *programCompiler) stringIndex(str []byte) int {
+	if i, ok := c.strings[string(str)]; ok {
+		return i
+	}
+	c.strings[string(str)] = len(c.program.Strings)
+	c.program.Strings = append(c.program.Strings, str)
+	return c.strings[string(str)]
+}
+
+func (c *programCompiler) compile(operator Operator) error {
+	switch operator := operator.(type) {
+	case AlternationOperator:
+		var first []operators.ByteSet
+		if c.dispatch {
+			first, _ = dispatch(c.analysis, operator)
+		}
+		var ends []int
+		for i, subOperator := range operator.subOperators {
+			last := i == len(operator.subOperators)-1
+			test, choice := -1, -1
+			if first != nil && first[i] != fullByteSet() {
+				// skips the alternative if it can not start with the next byte
+				test = c.emit(vm.OpTestSet, c.setIndex(first[i]), 0)
+			}
+			if !last {
+				choice = c.emit(vm.OpChoice, 0, 0)
+			}
+			if err := c.compile(subOperator); err != nil {
+				return err
+			}
+			if !last {
+				ends = append(ends, c.commit(0))
+				c.program.Instructions[choice].Target = c.next()
+			}
+			if test >= 0 {
+				if last {
+					ends = append(ends, c.emit(vm.OpJump, 0, 0))
+					c.program.Instructions[test].Target = c.emit(vm.OpFail, 0, 0)
+				} else {
+					c.program.Instructions[test].Target = c.next()
+				}
+			}
+		}
+		for _, end := range ends {
+			c.program.Instructions[end].Target = c.next()
+		}
+	case ConcatenationOperator:
+		for _, subOperator := range operator.subOperators {
+			if err := c.compile(subOperator); err != nil {
+				return err
+			}
+		}
+	case RepetitionOperator:
+		if 0 <= operator.max && operator.max < operator.min {
+			c.emit(vm.OpFail, 0, 0)
+			break
+		}
+		for i := 0; i < operator.min; i++ {
+			if err := c.compile(operator.subOperator); err != nil {
+				return err
+			}
+		}
+		if operator.max < 0 {
+			return c.star(operator.subOperator)
+		}
+		var choices []int
+		for i := operator.min; i < operator.max; i++ {
+			choices = append(choices, c.emit(vm.OpChoice, 0, 0))
+			if err := c.compile(operator.subOperator); err != nil {
+				return err
+			}
+			if c.program.PEG {
+				c.emit(vm.OpCommit, 0, c.next()+1)
+			}
+		}
+		for _, choice := range choices {
+			c.program.Instructions[choice].Target = c.next()
+		}
+	case RuleNameOperator:
+		if _, ok := c.ruleSet[operator.key]; !ok {
+			return fmt.Errorf("undefined rule: %s", operator.key)
+		}
+		c.calls = append(c.calls, programCall{at: c.emit(vm.OpCall, 0, 0), rule: operator.key})
+	case OptionOperator:
+		choice := c.emit(vm.OpChoice, 0, 0)
+		if err := c.compile(operator.subOperator); err != nil {
+			return err
+		}
+		if c.program.PEG {
+			c.emit(vm.OpCommit, 0, c.next()+1)
+		}
+		c.program.Instructions[choice].Target = c.next()
+	case CharacterValueOperator, NumericValueOperator:
+		return c.terminal(operator)
+	default:
+		return fmt.Errorf("unsupported operator: %s", operator.Key())
+	}
+	return nil
+}
+
+// star compiles an unbounded repetition, if the operator can match the empty input the repetition stops when it does
+// not make progress anymore.
+func (c *programCompiler) star(operator Operator) error {
+	nullable := c.analysis.NullableOperator(operator)
+	loop := c.emit(vm.OpChoice, 0, 0)
+	if nullable {
+		c.emit(vm.OpMark, 0, 0)
+	}
+	if err := c.compile(operator); err != nil {
+		return err
+	}
+	if nullable {
+		c.emit(vm.OpProgress, 0, 0)
+	}
+	c.commit(loop)
+	c.program.Instructions[loop].Target = c.next()
+	return nil
+}
+
+// terminal compiles a character or numeric value.
+func (c *programCompiler) terminal(operator Operator) error {
+	if value, ok := operator.(CharacterValueOperator); ok && 1 < len(value.value) {
+		c.emit(vm.OpString, c.stringIndex([]byte(value.value)), 0)
+		return nil
+	}
+	if sets, err := terminalBytes(operator); err == nil {
+		for _, set := range sets {
+			if b, ok := singleByte(set); ok {
+				c.emit(vm.OpByte, int(b), 0)
+			} else {
+				c.emit(vm.OpSet, c.setIndex(set), 0)
+			}
+		}
+		return nil
+	}
+	value := operator.(NumericValueOperator)
+	low, high := value.bytes()
+	if value.hyphen {
+		c.program.Ranges = append(c.program.Ranges, vm.Range{Low: low, High: high})
+		c.emit(vm.OpRange, len(c.program.Ranges)-1, 0)
+		return nil
+	}
+	c.emit(vm.OpString, c.stringIndex(low), 0)
+	return nil
+}
+
+// singleByte returns the byte of a set that contains exactly one byte.
+func singleByte(set operators.ByteSet) (byte, bool) {
+	var single operators.ByteSet
+	for b := 0; b < 256; b++ {
+		if set.Contains(byte(b)) {
+			single.Add(byte(b))
+			return byte(b), single == set
+		}
+	}
+	return 0, false
+}
+
+// fullByteSet returns the set of all the bytes.
+func fullByteSet() operators.ByteSet {
+	var all operators.ByteSet
+	all.AddRange(0, 255)
+	return all
+}
@@ -0,0 +1,145 @@
+package abnf
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/elimity-com/abnf/operators/vm"
+)
+
+func TestParserGeneratorGenerateProgram(t *testing.T) {
+	rawABNF := definitionWithCore(t)
+	for _, dispatch := range []bool{false, true} {
+		g := ParserGenerator{RawABNF: rawABNF, Dispatch: dispatch}
+		program, err := g.GenerateProgram()
+		if err != nil {
+			t.Fatal(err)
+		}
+		rules := g.GenerateABNFAsOperators()
+		for _, test := range []struct {
+			rule, input string
+		}{
+			{"rulelist", coreABNF},
+			{"rulelist", "a = b / c\nd = 1*2\"x\" ; y\n"},
+			{"rulelist", "a = "},
+			{"repeat", "12*34x"},
+			{"num-val", "%x30-39"},
+			{"c-wsp", ""},
+		} {
+			node, err := program.Parse(test.rule, []byte(test.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			nodes := rules[test.rule]([]byte(test.input))
+			if (node != nil) != (len(nodes) != 0) || node != nil && len(node.Value) != len(nodes.Best().Value) {
+				t.Errorf("%s %q: expected %d nodes, got %v", test.rule, test.input, len(nodes), node)
+			}
+		}
+	}
+
+	// the parse trees of unambiguous rules are the same
+	g := ParserGenerator{RawABNF: []byte(strings.Join([]string{
+		`list = item *("," item)`,
+		`item = 1*%x30-39 / "(" list ")"`,
+	}, "\n") + "\n")}
+	program, err := g.GenerateProgram()
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := []byte("1,(23,(4)),5")
+	node, err := program.Parse("list", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := g.GenerateABNFAsOperators()["list"](input).Best().RulesOnly(); node.SExpression() != expected.SExpression() {
+		t.Errorf("expected %s, got %s", expected.SExpression(), node.SExpression())
+	}
+
+	if _, err := (&ParserGenerator{RawABNF: []byte("a = b\n")}).GenerateProgram(); err == nil {
+		t.Error("expected an error for an undefined rule")
+	}
+	if _, err := program.Parse("unknown", input); err == nil {
+		t.Error("expected an error for an unknown rule")
+	}
+}
+
+func TestParserGeneratorGenerateProgramPEG(t *testing.T) {
+	rawABNF := []byte(strings.Join([]string{
+		`word = ("a" / "ab") *"c"`,
+		`empty = *["x"] "y"`,
+		`bounded = 1*2"a" "a"`,
+	}, "\n") + "\n")
+	for _, test := range []struct {
+		rule, input string
+		full, peg   string
+	}{
+		{"word", "abcc", "abcc", "a"},
+		{"word", "acc", "acc", "acc"},
+		{"empty", "xxy", "xxy", "xxy"},
+		{"bounded", "aa", "aa", ""},
+	} {
+		for _, peg := range []bool{false, true} {
+			program, err := (&ParserGenerator{RawABNF: rawABNF, PEG: peg}).GenerateProgram()
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := test.full
+			if peg {
+				expected = test.peg
+			}
+			node, err := program.Parse(test.rule, []byte(test.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var actual string
+			if node != nil {
+				actual = node.String()
+			}
+			if (node != nil) != (expected != "") || actual != expected {
+				t.Errorf("%s %q (PEG %t): expected %q, got %v", test.rule, test.input, peg, expected, node)
+			}
+		}
+	}
+}
+
+func TestProgramMarshalBinary(t *testing.T) {
+	program, err := (&ParserGenerator{RawABNF: definitionWithCore(t), Dispatch: true}).GenerateProgram()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := program.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded vm.Program
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.String() != program.String() {
+		t.Error("expected the same instructions")
+	}
+	node, err := decoded.Parse("rulelist", []byte(coreABNF))
+	if err != nil || node == nil || len(node.Value) != len(coreABNF) {
+		t.Errorf("expected a full match, got %v %v", node, err)
+	}
+}
+
+func BenchmarkProgram(b *testing.B) {
+	g := ParserGenerator{RawABNF: definitionWithCore(b), Dispatch: true}
+	program, err := g.GenerateProgram()
+	if err != nil {
+		b.Fatal(err)
+	}
+	rulelist := g.GenerateABNFAsOperators()["rulelist"]
+	input := []byte(coreABNF)
+	b.Run("Operators", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			rulelist(input)
+		}
+	})
+	b.Run("Program", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, _ = program.Parse("rulelist", input)
+		}
+	})
+}
@@ -0,0 +1,384 @@
+package abnf
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+)
+
+// dimensions of railroad diagrams, in pixels
+const (
+	railroadArc       = 10 // radius of the arcs
+	railroadGap       = 10 // space between elements
+	railroadBoxHeight = 24
+	railroadCharWidth = 8 // of the monospace font
+	railroadPadding   = 20
+)
+
+const railroadStyle = `<style>
+	path { fill: none; stroke: #333; stroke-width: 2; }
+	rect { fill: #ffc; stroke: #333; stroke-width: 2; }
+	rect.rule { fill: #cef; }
+	text { font: 13px monospace; text-anchor: middle; dominant-baseline: central; }
+	text.label { font-size: 11px; fill: #555; }
+	a text { fill: #036; text-decoration: underline; }
+</style>`
+
+// WriteRailroadDiagram writes a standalone SVG railroad (syntax) diagram of the rule with the given name. References
+// to other rules of the set link to "<name>.svg", so the diagrams of a set can be stored next to each other.
+func (set RuleSet) WriteRailroadDiagram(w io.Writer, rule string) error {
+	r, ok := set[rule]
+	if !ok {
+		return fmt.Errorf("unknown rule: %s", rule)
+	}
+	d := set.railroadDiagram(r, func(name string) string {
+		return name + ".svg"
+	})
+	_, err := io.WriteString(w, d.svg(true))
+	return err
+}
+
+// WriteRailroadIndex writes an HTML page with the railroad diagrams of all the rules of the set. References to other
+// rules link to their diagram within the page.
+func (set RuleSet) WriteRailroadIndex(w io.Writer, title string) error {
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(&b, "<title>%s</title>\n%s\n</head>\n<body>\n", html.EscapeString(title), railroadStyle)
+	fmt.Fprintf(&b, "<h1>%s</h1>\n<ul>\n", html.EscapeString(title))
+	for _, name := range set.names() {
+		fmt.Fprintf(&b, "<li><a href=\"#%s\">%s</a></li>\n", railroadID(name), html.EscapeString(name))
+	}
+	b.WriteString("</ul>\n")
+	for _, name := range set.names() {
+		rule := set[name]
+		fmt.Fprintf(&b, "<h2 id=\"%s\">%s</h2>\n", railroadID(name), html.EscapeString(name))
+		fmt.Fprintf(&b, "<pre>%s = %s</pre>\n", html.EscapeString(name), html.EscapeString(rule.operator.Key()))
+		d := set.railroadDiagram(rule, func(name string) string {
+			return "#" + railroadID(name)
+		})
+		b.WriteString(d.svg(false))
+	}
+	b.WriteString("</body>\n</html>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// railroadID returns the id of the diagram of the rule with the given name within an HTML page.
+func railroadID(name string) string {
+	return "rule-" + html.EscapeString(name)
+}
+
+// railroadDiagram converts the rule to a diagram, link returns the reference to the diagram of another rule.
+func (set RuleSet) railroadDiagram(rule Rule, link func(name string) string) railroadDiagram {
+	var convert func(operator Operator) railroadItem
+	convert = func(operator Operator) railroadItem {
+		switch operator := operator.(type) {
+		case AlternationOperator:
+			var items []railroadItem
+			for _, subOperator := range operator.subOperators {
+				items = append(items, convert(subOperator))
+			}
+			return newRailroadChoice(items)
+		case ConcatenationOperator:
+			var items []railroadItem
+			for _, subOperator := range operator.subOperators {
+				items = append(items, convert(subOperator))
+			}
+			return newRailroadSequence(items)
+		case RepetitionOperator:
+			item := convert(operator.subOperator)
+			switch {
+			case operator.min == 0 && operator.max == 1:
+				return newRailroadOptional(item)
+			case operator.min == 1 && operator.max == 1:
+				return item
+			case operator.max == 0:
+				return newRailroadSequence(nil)
+			}
+			var loop railroadItem = newRailroadLoop(item, railroadRepeatLabel(operator.min, operator.max))
+			if operator.min == 0 {
+				loop = newRailroadOptional(loop)
+			}
+			return loop
+		case RuleNameOperator:
+			var href string
+			if _, ok := set[operator.key]; ok {
+				href = link(operator.key)
+			}
+			return newRailroadBox(operator.key, true, href)
+		case OptionOperator:
+			return newRailroadOptional(convert(operator.subOperator))
+		case CharacterValueOperator:
+			return newRailroadBox(fmt.Sprintf("%q", operator.value), false, "")
+		default:
+			return newRailroadBox(operator.Key(), false, "")
+		}
+	}
+	return railroadDiagram{
+		name: rule.name,
+		item: convert(rule.operator),
+	}
+}
+
+// railroadRepeatLabel returns the label of a loop, e.g. "1+" or "2-3".
+func railroadRepeatLabel(min, max int) string {
+	switch {
+	case max < 0:
+		return fmt.Sprintf("%d+", min)
+	case min == max:
+		return fmt.Sprintf("%dx", min)
+	default:
+		return fmt.Sprintf("%d-%d", min, max)
+	}
+}
+
+// railroadItem is an element of a railroad diagram. All items are drawn on a horizontal line that enters on the left
+// and leaves on the right, up and down are the heights above and below that line.
+type railroadItem interface {
+	size() (width, up, down int)
+	// draw writes the SVG elements of the item, with the line entering at (x, y).
+	draw(b *strings.Builder, x, y int)
+}
+
+type railroadDiagram struct {
+	name string
+	item railroadItem
+}
+
+// svg returns the diagram as an SVG element, standalone diagrams include a namespace and a style.
+func (d railroadDiagram) svg(standalone bool) string {
+	w, up, down := d.item.size()
+	width := w + 2*railroadPadding + 4*railroadGap
+	height := up + down + 2*railroadPadding
+	var b strings.Builder
+	if standalone {
+		fmt.Fprintf(&b,
+			"<svg xmlns=\"http://www.w3.org/2000/svg\" xmlns:xlink=\"http://www.w3.org/1999/xlink\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\">\n",
+			width, height, width, height,
+		)
+		b.WriteString(railroadStyle + "\n")
+	} else {
+		fmt.Fprintf(&b, "<svg width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\">\n", width, height, width, height)
+	}
+	fmt.Fprintf(&b, "<title>%s</title>\n", html.EscapeString(d.name))
+
+	x, y := railroadPadding, railroadPadding+up
+	// start and end markers
+	fmt.Fprintf(&b, "<path d=\"M%d %dv20m%d -20v20m0 -10h%d\"/>\n", x, y-10, railroadGap, railroadGap)
+	d.item.draw(&b, x+2*railroadGap, y)
+	x += 2*railroadGap + w
+	fmt.Fprintf(&b, "<path d=\"M%d %dh%dm0 -10v20m%d -20v20\"/>\n", x, y, railroadGap, railroadGap)
+	b.WriteString("</svg>\n")
+	return b.String()
+}
+
+// railroadBox is a terminal (rounded) or a reference to a rule.
+type railroadBox struct {
+	text string
+	rule bool
+	href string
+}
+
+func newRailroadBox(text string, rule bool, href string) railroadBox {
+	return railroadBox{text: text, rule: rule, href: href}
+}
+
+func (box railroadBox) size() (int, int, int) {
+	return len([]rune(box.text))*railroadCharWidth + 2*railroadGap, railroadBoxHeight / 2, railroadBoxHeight / 2
+}
+
+func (box railroadBox) draw(b *strings.Builder, x, y int) {
+	w, up, _ := box.size()
+	if box.href != "" {
+		href := html.EscapeString(box.href)
+		fmt.Fprintf(b, "<a xlink:href=\"%s\" href=\"%s\">", href, href)
+	}
+	if box.rule {
+		fmt.Fprintf(b, "<rect class=\"rule\" x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\"/>", x, y-up, w, railroadBoxHeight)
+	} else {
+		fmt.Fprintf(b, "<rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" rx=\"10\"/>", x, y-up, w, railroadBoxHeight)
+	}
+	fmt.Fprintf(b, "<text x=\"%d\" y=\"%d\">%s</text>", x+w/2, y, html.EscapeString(box.text))
+	if box.href != "" {
+		b.WriteString("</a>")
+	}
+	b.WriteString("\n")
+}
+
+// railroadSequence draws its items next to each other, an empty sequence is just a line.
+type railroadSequence struct {
+	items []railroadItem
+}
+
+func newRailroadSequence(items []railroadItem) railroadSequence {
+	return railroadSequence{items: items}
+}
+
+func (seq railroadSequence) size() (int, int, int) {
+	var width, up, down int
+	for i, item := range seq.items {
+		w, u, d := item.size()
+		if i != 0 {
+			width += railroadGap
+		}
+		width += w
+		up, down = maxInt(up, u), maxInt(down, d)
+	}
+	return width, up, down
+}
+
+func (seq railroadSequence) draw(b *strings.Builder, x, y int) {
+	for i, item := range seq.items {
+		if i != 0 {
+			fmt.Fprintf(b, "<path d=\"M%d %dh%d\"/>\n", x, y, railroadGap)
+			x += railroadGap
+		}
+		item.draw(b, x, y)
+		w, _, _ := item.size()
+		x += w
+	}
+}
+
+// railroadChoice draws its items below each other, the first one is on the line.
+type railroadChoice struct {
+	items []railroadItem
+}
+
+func newRailroadChoice(items []railroadItem) railroadChoice {
+	return railroadChoice{items: items}
+}
+
+// offsets returns the offsets of the items below the line, and the height below the line.
+func (choice railroadChoice) offsets() ([]int, int) {
+	var offsets []int
+	var down int
+	for i, item := range choice.items {
+		_, u, d := item.size()
+		var offset int
+		if i != 0 {
+			// leave enough space for the arcs
+			offset = maxInt(down+railroadGap+u, 2*railroadArc)
+		}
+		offsets = append(offsets, offset)
+		down = offset + d
+	}
+	return offsets, down
+}
+
+func (choice railroadChoice) size() (int, int, int) {
+	var width, up int
+	for i, item := range choice.items {
+		w, u, _ := item.size()
+		width = maxInt(width, w)
+		if i == 0 {
+			up = u
+		}
+	}
+	_, down := choice.offsets()
+	return width + 4*railroadArc, up, down
+}
+
+func (choice railroadChoice) draw(b *strings.Builder, x, y int) {
+	width, _, _ := choice.size()
+	inner := width - 4*railroadArc
+	offsets, _ := choice.offsets()
+	for i, item := range choice.items {
+		w, _, _ := item.size()
+		offset := offsets[i]
+		if i != 0 {
+			// down from the line on the left, back up on the right
+			fmt.Fprintf(b,
+				"<path d=\"M%d %da%d %d 0 0 1 %d %dv%da%d %d 0 0 0 %d %d\"/>\n",
+				x, y, railroadArc, railroadArc, railroadArc, railroadArc,
+				offset-2*railroadArc, railroadArc, railroadArc, railroadArc, railroadArc,
+			)
+			fmt.Fprintf(b,
+				"<path d=\"M%d %da%d %d 0 0 0 %d %dv%da%d %d 0 0 1 %d %d\"/>\n",
+				x+width-2*railroadArc, y+offset, railroadArc, railroadArc, railroadArc, -railroadArc,
+				-(offset - 2*railroadArc), railroadArc, railroadArc, railroadArc, -railroadArc,
+			)
+		} else {
+			fmt.Fprintf(b, "<path d=\"M%d %dh%d\"/>\n", x, y, 2*railroadArc)
+			fmt.Fprintf(b, "<path d=\"M%d %dh%d\"/>\n", x+width-2*railroadArc, y, 2*railroadArc)
+		}
+		// center the item
+		left := (inner - w) / 2
+		fmt.Fprintf(b, "<path d=\"M%d %dh%d\"/>\n", x+2*railroadArc, y+offset, left)
+		item.draw(b, x+2*railroadArc+left, y+offset)
+		fmt.Fprintf(b, "<path d=\"M%d %dh%d\"/>\n", x+2*railroadArc+left+w, y+offset, inner-left-w)
+	}
+}
+
+// railroadOptional draws its item on the line, with a path above it to skip the item.
+type railroadOptional struct {
+	item railroadItem
+}
+
+func newRailroadOptional(item railroadItem) railroadOptional {
+	return railroadOptional{item: item}
+}
+
+func (opt railroadOptional) size() (int, int, int) {
+	w, u, d := opt.item.size()
+	return w + 4*railroadArc, maxInt(u+railroadGap, 2*railroadArc), d
+}
+
+func (opt railroadOptional) draw(b *strings.Builder, x, y int) {
+	width, up, _ := opt.size()
+	w, _, _ := opt.item.size()
+	fmt.Fprintf(b,
+		"<path d=\"M%d %da%d %d 0 0 0 %d %dv%da%d %d 0 0 1 %d %dh%da%d %d 0 0 1 %d %dv%da%d %d 0 0 0 %d %d\"/>\n",
+		x, y, railroadArc, railroadArc, railroadArc, -railroadArc,
+		-(up - 2*railroadArc), railroadArc, railroadArc, railroadArc, -railroadArc,
+		w, railroadArc, railroadArc, railroadArc, railroadArc,
+		up-2*railroadArc, railroadArc, railroadArc, railroadArc, railroadArc,
+	)
+	fmt.Fprintf(b, "<path d=\"M%d %dh%d\"/>\n", x, y, 2*railroadArc)
+	opt.item.draw(b, x+2*railroadArc, y)
+	fmt.Fprintf(b, "<path d=\"M%d %dh%d\"/>\n", x+width-2*railroadArc, y, 2*railroadArc)
+}
+
+// railroadLoop draws its item on the line, with a path below it to repeat the item.
+type railroadLoop struct {
+	item  railroadItem
+	label string
+}
+
+func newRailroadLoop(item railroadItem, label string) railroadLoop {
+	return railroadLoop{item: item, label: label}
+}
+
+func (loop railroadLoop) size() (int, int, int) {
+	w, u, d := loop.item.size()
+	// the label is written below the loop
+	return maxInt(w, len(loop.label)*railroadCharWidth) + 4*railroadArc, u, maxInt(d+railroadGap, 2*railroadArc) + 2*railroadGap
+}
+
+func (loop railroadLoop) draw(b *strings.Builder, x, y int) {
+	width, _, _ := loop.size()
+	w, _, d := loop.item.size()
+	inner := width - 4*railroadArc
+	down := maxInt(d+railroadGap, 2*railroadArc)
+	left := (inner - w) / 2
+
+	fmt.Fprintf(b, "<path d=\"M%d %dh%d\"/>\n", x, y, 2*railroadArc+left)
+	loop.item.draw(b, x+2*railroadArc+left, y)
+	fmt.Fprintf(b, "<path d=\"M%d %dh%d\"/>\n", x+2*railroadArc+left+w, y, inner-left-w+2*railroadArc)
+	// from the right back to the left, below the item
+	fmt.Fprintf(b,
+		"<path d=\"M%d %da%d %d 0 0 1 %d %dv%da%d %d 0 0 1 %d %dh%da%d %d 0 0 1 %d %dv%da%d %d 0 0 1 %d %d\"/>\n",
+		x+width-2*railroadArc, y, railroadArc, railroadArc, railroadArc, railroadArc,
+		down-2*railroadArc, railroadArc, railroadArc, -railroadArc, railroadArc,
+		-inner, railroadArc, railroadArc, -railroadArc, -railroadArc,
+		-(down - 2*railroadArc), railroadArc, railroadArc, railroadArc, -railroadArc,
+	)
+	fmt.Fprintf(b, "<text class=\"label\" x=\"%d\" y=\"%d\">%s</text>\n", x+width/2, y+down+railroadGap, html.EscapeString(loop.label))
+}
+
+func maxInt(a, b int) int {
+	if a < b {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,90 @@
+package abnf
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestRuleSetWriteRailroadDiagram(t *testing.T) {
+	set := NewRuleSet([]byte(strings.Join([]string{
+		"list = item *(\",\" item) [\";\"]",
+		"item = 1*3ALPHA / \"<\" list \">\" / %x30-39",
+		"empty = 0\"a\"",
+	}, "\n") + "\n"))
+
+	for _, name := range set.names() {
+		b := &bytes.Buffer{}
+		if err := set.WriteRailroadDiagram(b, name); err != nil {
+			t.Error(err)
+			continue
+		}
+		if err := wellFormed(b.Bytes()); err != nil {
+			t.Errorf("%s: %s", name, err)
+		}
+	}
+
+	b := &bytes.Buffer{}
+	_ = set.WriteRailroadDiagram(b, "item")
+	for _, expected := range []string{
+		`<title>item</title>`,
+		`<a xlink:href="list.svg" href="list.svg"><rect class="rule"`,
+		`>ALPHA</text>`, // not part of the set, so no link
+		`>&#34;&lt;&#34;</text>`,
+		`>%x30-39</text>`,
+		`>1-3</text>`,
+	} {
+		if !strings.Contains(b.String(), expected) {
+			t.Errorf("expected %q in:\n%s", expected, b)
+		}
+	}
+
+	if err := set.WriteRailroadDiagram(ioutil.Discard, "unknown"); err == nil {
+		t.Error("expected an error for an unknown rule")
+	}
+}
+
+func TestRuleSetWriteRailroadIndex(t *testing.T) {
+	rawABNF, err := ioutil.ReadFile("./testdata/definition.abnf")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	b := &bytes.Buffer{}
+	if err := NewRuleSet(rawABNF).WriteRailroadIndex(b, "ABNF <definition>"); err != nil {
+		t.Error(err)
+		return
+	}
+	for _, expected := range []string{
+		`<title>ABNF &lt;definition&gt;</title>`,
+		`<li><a href="#rule-rulelist">rulelist</a></li>`,
+		`<h2 id="rule-rulelist">rulelist</h2>`,
+		`<a xlink:href="#rule-rule" href="#rule-rule">`,
+	} {
+		if !strings.Contains(b.String(), expected) {
+			t.Errorf("expected %q", expected)
+		}
+	}
+	// the diagrams are valid XML as well
+	for _, svg := range strings.Split(b.String(), "<svg")[1:] {
+		svg = "<svg xmlns:xlink=\"http://www.w3.org/1999/xlink\"" + svg[:strings.Index(svg, "</svg>")] + "</svg>"
+		if err := wellFormed([]byte(svg)); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func wellFormed(data []byte) error {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		if _, err := decoder.Token(); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package abnf
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/elimity-com/abnf/operators"
+)
+
+// maxRegexpRepeat is the maximum repetition count supported by RE2.
+const maxRegexpRepeat = 1000
+
+// precedence of a regular expression, used to decide whether it needs to be grouped.
+const (
+	regexpAlternation = iota
+	regexpConcatenation
+	regexpAtom
+)
+
+// Regexp converts the rule with the given name to an (unanchored) RE2 compatible regular expression, which can be used
+// by the regexp package. Referenced rules get inlined, core rules that are not part of the set are taken from the
+// (lenient) CoreRules. Recursive rules and values outside of the ASCII range are not supported.
+func (set RuleSet) Regexp(rule string) (string, error) {
+	core := CoreRules(false)
+	r := &regexpBuilder{
+		lookup: func(name string) (Rule, bool) {
+			if rule, ok := set[name]; ok {
+				return rule, true
+			}
+			rule, ok := core[name]
+			return rule, ok
+		},
+		visiting: make(map[string]bool),
+	}
+	re, _, err := r.rule(rule)
+	return re, err
+}
+
+type regexpBuilder struct {
+	lookup   func(name string) (Rule, bool)
+	visiting map[string]bool
+}
+
+// rule returns the regular expression of the rule with the given name, and its precedence.
+func (r *regexpBuilder) rule(name string) (string, int, error) {
+	rule, ok := r.lookup(name)
+	if !ok {
+		return "", 0, fmt.Errorf("unknown rule: %s", name)
+	}
+	if r.visiting[name] {
+		return "", 0, fmt.Errorf("recursive rule: %s", name)
+	}
+	r.visiting[name] = true
+	defer delete(r.visiting, name)
+	return r.operator(rule.operator)
+}
+
+// operator returns the regular expression of the given operator, and its precedence.
+func (r *regexpBuilder) operator(operator Operator) (string, int, error) {
+	if bytes, ok := r.byteSet(operator); ok {
+		class, err := regexpClass(bytes)
+		if err != nil {
+			return "", 0, fmt.Errorf("%s: %s", operator.Key(), err)
+		}
+		return class, regexpAtom, nil
+	}
+	switch operator := operator.(type) {
+	case AlternationOperator:
+		var alternatives []string
+		for _, subOperator := range operator.subOperators {
+			re, _, err := r.operator(subOperator)
+			if err != nil {
+				return "", 0, err
+			}
+			alternatives = append(alternatives, re)
+		}
+		return strings.Join(alternatives, "|"), regexpAlternation, nil
+	case ConcatenationOperator:
+		var re string
+		for _, subOperator := range operator.subOperators {
+			sub, err := r.group(subOperator, regexpConcatenation)
+			if err != nil {
+				return "", 0, err
+			}
+			re += sub
+		}
+		return re, regexpConcatenation, nil
+	case RepetitionOperator:
+		if maxRegexpRepeat < operator.min || maxRegexpRepeat < operator.max {
+			return "", 0, fmt.Errorf("repetition exceeds %d: %s", maxRegexpRepeat, operator.key)
+		}
+		sub, err := r.group(operator.subOperator, regexpAtom)
+		if err != nil {
+			return "", 0, err
+		}
+		switch {
+		case operator.min == 0 && operator.max == -1:
+			return sub + "*", regexpAtom, nil
+		case operator.min == 1 && operator.max == -1:
+			return sub + "+", regexpAtom, nil
+		case operator.min == 0 && operator.max == 1:
+			return sub + "?", regexpAtom, nil
+		case operator.min == operator.max:
+			return fmt.Sprintf("%s{%d}", sub, operator.min), regexpAtom, nil
+		case operator.max == -1:
+			return fmt.Sprintf("%s{%d,}", sub, operator.min), regexpAtom, nil
+		default:
+			return fmt.Sprintf("%s{%d,%d}", sub, operator.min, operator.max), regexpAtom, nil
+		}
+	case RuleNameOperator:
+		return r.rule(operator.key)
+	case OptionOperator:
+		sub, err := r.group(operator.subOperator, regexpAtom)
+		if err != nil {
+			return "", 0, err
+		}
+		return sub + "?", regexpAtom, nil
+	case CharacterValueOperator, NumericValueOperator:
+		sets, err := terminalBytes(operator)
+		if err != nil {
+			return "", 0, err
+		}
+		var re string
+		for _, bytes := range sets {
+			class, err := regexpClass(bytes)
+			if err != nil {
+				return "", 0, fmt.Errorf("%s: %s", operator.Key(), err)
+			}
+			re += class
+		}
+		// single bytes are handled by byteSet
+		return re, regexpConcatenation, nil
+	default:
+		return "", 0, fmt.Errorf("unsupported operator: %s", operator.Key())
+	}
+}
+
+// byteSet returns the bytes matched by the given operator, if it always matches a single byte. This way alternations
+// of bytes can be converted to a single character class.
+func (r *regexpBuilder) byteSet(operator Operator) (operators.ByteSet, bool) {
+	switch operator := operator.(type) {
+	case AlternationOperator:
+		var bytes operators.ByteSet
+		for _, subOperator := range operator.subOperators {
+			sub, ok := r.byteSet(subOperator)
+			if !ok {
+				return operators.ByteSet{}, false
+			}
+			for i := range bytes {
+				bytes[i] |= sub[i]
+			}
+		}
+		return bytes, true
+	case RuleNameOperator:
+		rule, ok := r.lookup(operator.key)
+		if !ok || r.visiting[operator.key] {
+			return operators.ByteSet{}, false
+		}
+		r.visiting[operator.key] = true
+		defer delete(r.visiting, operator.key)
+		return r.byteSet(rule.operator)
+	case CharacterValueOperator, NumericValueOperator:
+		sets, err := terminalBytes(operator)
+		if err != nil || len(sets) != 1 {
+			return operators.ByteSet{}, false
+		}
+		return sets[0], true
+	default:
+		return operators.ByteSet{}, false
+	}
+}
+
+// group returns the regular expression of the given operator, grouped if its precedence is lower than the given one.
+func (r *regexpBuilder) group(operator Operator, precedence int) (string, error) {
+	re, p, err := r.operator(operator)
+	if err != nil {
+		return "", err
+	}
+	if p < precedence {
+		return "(?:" + re + ")", nil
+	}
+	return re, nil
+}
+
+// regexpClass returns a character (class) that matches the given bytes.
+func regexpClass(bytes operators.ByteSet) (string, error) {
+	var ranges [][2]int
+	for b := 0; b < 256; b++ {
+		if !bytes.Contains(byte(b)) {
+			continue
+		}
+		if 0x7F < b {
+			return "", fmt.Errorf("values outside of the ASCII range are not supported")
+		}
+		if l := len(ranges); l != 0 && ranges[l-1][1] == b-1 {
+			ranges[l-1][1] = b
+			continue
+		}
+		ranges = append(ranges, [2]int{b, b})
+	}
+	switch {
+	case len(ranges) == 0:
+		// matches nothing
+		return `[^\x00-\x{10FFFF}]`, nil
+	case len(ranges) == 1 && ranges[0][0] == ranges[0][1]:
+		return regexpByte(byte(ranges[0][0])), nil
+	}
+	class := "["
+	for _, r := range ranges {
+		class += regexpByte(byte(r[0]))
+		if r[0] != r[1] {
+			class += "-" + regexpByte(byte(r[1]))
+		}
+	}
+	return class + "]", nil
+}
+
+// regexpByte returns the given byte, escaped if it is not a letter or a digit.
+func regexpByte(b byte) string {
+	if 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z' || '0' <= b && b <= '9' {
+		return string(b)
+	}
+	return fmt.Sprintf(`\x%02X`, b)
+}
@@ -0,0 +1,86 @@
+package abnf
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/di-wu/regen"
+)
+
+func TestRuleSetRegexp(t *testing.T) {
+	set := NewRuleSet([]byte(strings.Join([]string{
+		"word = 2*3(\"a\" / \"ab\") [DIGIT]",
+		"hex = \"0x\" 1*HEXDIG",
+		"points = %x61.62 / %d97",
+		"token = 1*tchar",
+		"tchar = \"!\" / \"#\" / \"$\" / \"%\" / \"&\" / \"'\" / \"*\" / \"+\" / \"-\" / \".\" / \"^\" / \"_\" / \"`\" / \"|\" / \"~\" / DIGIT / ALPHA",
+		"version = 1*DIGIT *(\".\" 1*DIGIT) 0*1(\"-\" 1*3ALPHA)",
+		"space = *(SP / HTAB) CRLF",
+	}, "\n") + "\n"))
+	for _, test := range []struct {
+		rule, regexp string
+	}{
+		{"word", `(?:a|ab){2,3}[0-9]?`},
+		{"hex", `0x[0-9A-Fa-f]+`},
+		{"points", `ab|a`},
+		{"token", `[\x21\x23-\x27\x2A-\x2B\x2D-\x2E0-9A-Z\x5E-z\x7C\x7E]+`},
+		{"space", `[\x09\x20]*(?:\x0D\x0A|\x0A)`},
+	} {
+		re, err := set.Regexp(test.rule)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if re != test.regexp {
+			t.Errorf("%s: expected %s, got %s", test.rule, test.regexp, re)
+		}
+	}
+
+	operators := new(ParserGenerator).generate(func() RuleSet {
+		all := CoreRules(false)
+		for name, rule := range set {
+			all[name] = rule
+		}
+		return all
+	}())
+	// inputs that are not generated from the regular expressions themselves
+	random, _ := regen.New(`[\x00-\x7F]{0,4}|[a!0-9.\-x]{1,6}|[\x09\x0A\x0D\x20]{1,3}`)
+	for _, name := range append(set.names(), "ALPHA", "CRLF", "HEXDIG", "LWSP", "VCHAR") {
+		re, err := set.Regexp(name)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		anchored := regexp.MustCompile("^(?:" + re + ")$")
+		valid, err := regen.New(re)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		for i := 0; i < 500; i++ {
+			for _, input := range []string{valid.Generate(), random.Generate()} {
+				var matches bool
+				for _, node := range operators[name]([]byte(input)) {
+					matches = matches || len(node.Value) == len(input)
+				}
+				if matches != anchored.MatchString(input) {
+					t.Errorf("%s %q: operator %t, regexp %s %t", name, input, matches, re, !matches)
+				}
+			}
+		}
+	}
+
+	for _, test := range []struct {
+		abnf, rule string
+	}{
+		{"a = \"a\" [a]\n", "a"},
+		{"a = b\n", "a"},
+		{"a = OCTET\n", "a"},
+		{"a = 1001\"a\"\n", "a"},
+	} {
+		if _, err := NewRuleSet([]byte(test.abnf)).Regexp(test.rule); err == nil {
+			t.Errorf("expected an error for %s", test.abnf)
+		}
+	}
+}
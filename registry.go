@@ -0,0 +1,116 @@
+package abnf
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/elimity-com/abnf/definition"
+)
+
+// registry writes a constant for every rule name, together with the types and variables of the registry. They get
+// filled in at the start of the program (see registryInit), after the operators are initialized.
+func (g *CodeGenerator) registry(ruleSet RuleSet) {
+	names := ruleSet.names()
+	var width int
+	for _, name := range names {
+		if l := len(g.registryName(name)); width < l {
+			width = l
+		}
+	}
+
+	g.ln()
+	g.c("RuleName is the name of a rule, convert it to a string to look up nodes (e.g. GetSubRule(string(RuleFoo))).")
+	g.wln("type RuleName string")
+	g.ln()
+	g.wln("const (")
+	g.in(func() {
+		for _, name := range names {
+			g.wlnf("%-*s RuleName = %q", width, g.registryName(name), name)
+		}
+	})
+	g.wln(")")
+	g.ln()
+	g.c("RuleMetadata describes a rule.")
+	g.wln("type RuleMetadata struct {")
+	g.in(func() {
+		g.c("ABNF is the definition of the rule, as written in the source.")
+		g.wln("ABNF string")
+		g.c("References are the (sorted) names of the rules it refers to, including the external ones.")
+		g.wln("References []RuleName")
+	})
+	g.wln("}")
+	g.ln()
+	g.c("Rules contains the operators of all the rules, by name.")
+	g.wlnf("var Rules = make(map[RuleName]%s.Operator)", g.pkg())
+	g.ln()
+	g.c("Metadata contains the metadata of all the rules, by name.")
+	g.wln("var Metadata = make(map[RuleName]RuleMetadata)")
+}
+
+// registryInit writes the statements that fill the registry.
+func (g *CodeGenerator) registryInit(ruleSet RuleSet) {
+	sources := ruleSources(g.RawABNF)
+	for _, name := range ruleSet.names() {
+		if g.isOperator {
+			g.wlnf("Rules[%s] = %s()", g.registryName(name), g.name(name))
+		} else {
+			g.wlnf("Rules[%s] = %s", g.registryName(name), g.name(name))
+		}
+	}
+	for _, name := range ruleSet.names() {
+		var references []string
+		for _, reference := range ruleReferences(ruleSet[name]) {
+			if _, ok := ruleSet[reference]; ok {
+				if _, external := g.ExternalABNF[reference]; !external {
+					references = append(references, g.registryName(reference))
+					continue
+				}
+			}
+			references = append(references, fmt.Sprintf("%q", reference))
+		}
+		g.wf("Metadata[%s] = RuleMetadata{ABNF: %q", g.registryName(name), sources[name])
+		if len(references) != 0 {
+			g.wf(", References: []RuleName{%s}", strings.Join(references, ", "))
+		}
+		g.wln("}")
+	}
+}
+
+// registryName returns the name of the constant of the rule with the given name.
+func (g *CodeGenerator) registryName(name string) string {
+	if !g.exported(name) {
+		return "rule" + g.base(name)
+	}
+	return "Rule" + g.base(name)
+}
+
+// ruleReferences returns the sorted names of the rules the given rule refers to.
+func ruleReferences(rule Rule) []string {
+	unique := make(map[string]bool)
+	_ = walkOperators(rule.operator, func(operator Operator) error {
+		if reference, ok := operator.(RuleNameOperator); ok {
+			unique[reference.key] = true
+		}
+		return nil
+	})
+	var references []string
+	for reference := range unique {
+		references = append(references, reference)
+	}
+	sort.Strings(references)
+	return references
+}
+
+// ruleSources returns the ABNF text of every rule in the given syntax, without the trailing white space.
+func ruleSources(rawABNF []byte) map[string]string {
+	sources := make(map[string]string)
+	for _, line := range definition.Rulelist(rawABNF).Best().Children {
+		if rawRule := line.GetSubRule("rule"); rawRule != nil {
+			sources[rawRule.GetSubRule("rulename").String()] = strings.TrimRightFunc(string(rawRule.Value), func(r rune) bool {
+				return r == ' ' || r == '\t' || r == '\r' || r == '\n'
+			})
+		}
+	}
+	return sources
+}
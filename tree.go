@@ -3,6 +3,7 @@ package abnf
 import (
 	"fmt"
 	"reflect"
+	"sort"
 	"strconv"
 
 	"github.com/elimity-com/abnf/definition"
@@ -15,8 +16,8 @@ func NewRuleSet(rawABNF []byte) RuleSet {
 
 	ruleSet := make(RuleSet)
 	for _, line := range rawRuleList.Children {
-		if line.Contains("rule") {
-			rule := parseRule(line)
+		if rawRule := line.GetSubRule("rule"); rawRule != nil {
+			rule := parseRule(rawRule)
 			ruleSet[rule.name] = rule
 		}
 	}
@@ -47,6 +48,48 @@ func (set RuleSet) RuleList() RuleList {
 	return ruleList
 }
 
+// names returns the (sorted) names of all the rules in the set.
+func (set RuleSet) names() []string {
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Element returns the operator that created the given node, see operators.Element. For the root node of a rule, the
+// operator of that rule is returned.
+func (set RuleSet) Element(node *operators.Node) (Operator, bool) {
+	rule, ok := set[node.Element.Rule]
+	if !ok {
+		return nil, false
+	}
+	var elements []Operator
+	_ = walkOperators(rule.operator, func(operator Operator) error {
+		elements = append(elements, operator)
+		return nil
+	})
+	if index := node.Element.Index; 0 <= index && index < len(elements) {
+		return elements[index], true
+	}
+	return nil, false
+}
+
+// subElements returns the elements of the sub operators of the given operator, which is the given element.
+func subElements(operator Operator, element operators.Element) []operators.Element {
+	var elements []operators.Element
+	index := element.Index + 1
+	for _, subOperator := range subOperators(operator) {
+		elements = append(elements, operators.Element{Rule: element.Rule, Index: index})
+		_ = walkOperators(subOperator, func(Operator) error {
+			index++
+			return nil
+		})
+	}
+	return elements
+}
+
 // Rule represents an ABNF rule.
 type Rule struct {
 	name     string
@@ -70,11 +113,28 @@ func (r Rule) Name() string {
 // ABNF: rule = rulename defined-as elements c-nl
 func parseRule(rawNode *operators.Node) Rule {
 	return Rule{
-		name:     rawNode.GetSubNode("rulename").String(),
-		operator: parseAlternation(rawNode.GetSubNode("alternation")),
+		name:     rawNode.GetSubRule("rulename").String(),
+		operator: parseAlternation(rawNode.GetSubRule("alternation")),
 	}
 }
 
+// outerSubNodes returns all the sub nodes that are accepted by the given filter and that are not nested within another
+// accepted node. e.g. the concatenations of an alternation (operators.Rules("concatenation")), without the ones within
+// groups and options.
+func outerSubNodes(rawNode *operators.Node, filter func(node *operators.Node) bool) operators.Children {
+	var nodes operators.Children
+	for _, child := range rawNode.Children {
+		_ = operators.Walker{
+			Pre: func(node *operators.Node, _ []*operators.Node) error {
+				nodes = append(nodes, node)
+				return operators.SkipChildren
+			},
+			Filter: filter,
+		}.Walk(child)
+	}
+	return nodes
+}
+
 // Operator represents a node of a rule.
 type Operator interface {
 	// Key returns that key (name) of the operator.s
@@ -86,6 +146,42 @@ type Operator interface {
 	parserGeneratorNode // parser generator
 }
 
+// operatorKind returns the kind of the nodes that get created by the given operator.
+func operatorKind(operator Operator) operators.Kind {
+	switch operator.(type) {
+	case AlternationOperator:
+		return operators.KindAlternation
+	case ConcatenationOperator:
+		return operators.KindConcatenation
+	case RepetitionOperator:
+		return operators.KindRepetition
+	case RuleNameOperator:
+		return operators.KindRule
+	case OptionOperator:
+		return operators.KindOption
+	case CharacterValueOperator, NumericValueOperator:
+		return operators.KindTerminal
+	default:
+		return operators.KindUnknown
+	}
+}
+
+// subOperators returns the direct sub operators of the given operator.
+func subOperators(operator Operator) []Operator {
+	switch operator := operator.(type) {
+	case AlternationOperator:
+		return operator.subOperators
+	case ConcatenationOperator:
+		return operator.subOperators
+	case RepetitionOperator:
+		return []Operator{operator.subOperator}
+	case OptionOperator:
+		return []Operator{operator.subOperator}
+	default:
+		return nil
+	}
+}
+
 // AlternationOperator represents an alternation node of a rule.
 type AlternationOperator struct {
 	key          string
@@ -119,14 +215,9 @@ func (alt AlternationOperator) equals(other Operator) error {
 // ABNF: alternation = concatenation *(*c-wsp "/" *c-wsp concatenation)
 func parseAlternation(rawNode *operators.Node) Operator {
 	// an alternation has at least one concatenation node
-	subOperators := []Operator{
-		parseConcatenation(rawNode.GetSubNode("concatenation")),
-	}
-	// get all other concatenation nodes
-	for _, other := range rawNode.GetSubNodesBefore(`*c-wsp "/" *c-wsp concatenation`, "(", "[") {
-		if rawConcat := other.GetNode("concatenation"); rawConcat != nil {
-			subOperators = append(subOperators, parseConcatenation(rawConcat))
-		}
+	var subOperators []Operator
+	for _, rawConcat := range outerSubNodes(rawNode, operators.Rules("concatenation")) {
+		subOperators = append(subOperators, parseConcatenation(rawConcat))
 	}
 	// not need to return an alternation of one element
 	if len(subOperators) == 1 {
@@ -171,14 +262,9 @@ func (concat ConcatenationOperator) equals(other Operator) error {
 // ABNF: concatenation = repetition *(1*c-wsp repetition)
 func parseConcatenation(rawNode *operators.Node) Operator {
 	// a concatenation has at least one repetition node
-	subOperators := []Operator{
-		parseRepetition(rawNode.GetSubNode("repetition")),
-	}
-	// get all other repetition nodes
-	for _, other := range rawNode.GetSubNodesBefore(`1*c-wsp repetition`, "(", "[") {
-		if rawConcat := other.GetNode("repetition"); rawConcat != nil {
-			subOperators = append(subOperators, parseRepetition(rawConcat))
-		}
+	var subOperators []Operator
+	for _, rawRep := range outerSubNodes(rawNode, operators.Rules("repetition")) {
+		subOperators = append(subOperators, parseRepetition(rawRep))
 	}
 	// not need to return a concatenation of one element
 	if len(subOperators) == 1 {
@@ -223,37 +309,30 @@ func (rep RepetitionOperator) equals(other Operator) error {
 func parseRepetition(rawNode *operators.Node) Operator {
 	if rawNode.Children[0].IsEmpty() {
 		// no repeat
-		return parseElement(rawNode.GetSubNode("element"))
+		return parseElement(rawNode.GetSubRule("element"))
 	}
-	min, max := parseRepeat(rawNode.GetSubNode("repeat"))
+	min, max := parseRepeat(rawNode.GetSubRule("repeat"))
 	return RepetitionOperator{
 		key: rawNode.String(),
 		min: min, max: max,
-		subOperator: parseElement(rawNode.GetSubNode("element")),
+		subOperator: parseElement(rawNode.GetSubRule("element")),
 	}
 }
 
 // parseRepetition converts a raw (nested) repetition node to a two their respective min and max values.
 // ABNF: repeat = 1*DIGIT / (*DIGIT "*" *DIGIT)
 func parseRepeat(rawNode *operators.Node) (int, int) {
-	if rawNode.Children[0].Key == "1*DIGIT" {
+	digits := outerSubNodes(rawNode, operators.Kinds(operators.KindRepetition))
+	if len(digits) == 1 {
 		i, _ := strconv.Atoi(rawNode.String())
 		return i, i
 	}
-	min, max, asterisk := 0, -1, false
-	for _, child := range rawNode.Children[0].Children {
-		if child.Key == "*DIGIT" {
-			if child.IsEmpty() {
-				continue
-			}
-			if !asterisk {
-				min, _ = strconv.Atoi(child.String())
-			} else {
-				max, _ = strconv.Atoi(child.String())
-			}
-		} else {
-			asterisk = true
-		}
+	min, max := 0, -1
+	if !digits[0].IsEmpty() {
+		min, _ = strconv.Atoi(digits[0].String())
+	}
+	if !digits[1].IsEmpty() {
+		max, _ = strconv.Atoi(digits[1].String())
 	}
 	return min, max
 }
@@ -310,7 +389,7 @@ func parseRuleName(rawNode *operators.Node) Operator {
 // parseGroup converts a raw (nested) group node to a (more) readable one.
 // ABNF: group = "(" *c-wsp alternation *c-wsp ")"
 func parseGroup(rawNode *operators.Node) Operator {
-	return parseAlternation(rawNode.GetSubNode("alternation"))
+	return parseAlternation(rawNode.GetSubRule("alternation"))
 }
 
 // OptionOperator represents an option node of a rule.
@@ -337,7 +416,7 @@ func (opt OptionOperator) equals(other Operator) error {
 // parseOption converts a raw (nested) option node to a (more) readable one.
 // ABNF: option = "[" *c-wsp alternation *c-wsp "]"
 func parseOption(rawNode *operators.Node) Operator {
-	rawAlternation := rawNode.GetSubNode("alternation")
+	rawAlternation := rawNode.GetSubRule("alternation")
 	return OptionOperator{
 		key:         rawNode.String(),
 		subOperator: parseAlternation(rawAlternation),
@@ -367,7 +446,8 @@ func (value CharacterValueOperator) equals(other Operator) error {
 // parseCharacterValue converts a raw (nested) character value node to a (more) readable one.
 // ABNF: char-val = DQUOTE *(%x20-21 / %x23-7E) DQUOTE
 func parseCharacterValue(rawNode *operators.Node) Operator {
-	rawValue := rawNode.GetSubNode("*(%x20-21 / %x23-7E)")
+	// the quotes are rules, the characters in between are matched by the only repetition
+	rawValue := outerSubNodes(rawNode, operators.Kinds(operators.KindRepetition))[0]
 	return CharacterValueOperator{
 		value: rawValue.String(),
 	}
@@ -423,7 +503,7 @@ func (value NumericValueOperator) equals(other Operator) error {
 // parseCharacterValue converts a raw (nested) numeric value node to a (more) readable one.
 // ABNF: num-val = "%" (bin-val / dec-val / hex-val)
 func parseNumericValue(rawNode *operators.Node) Operator {
-	rawValue := rawNode.Children[1].Children[0]
+	rawValue := outerSubNodes(rawNode, operators.Kinds(operators.KindRule))[0]
 	var numericType numericType
 	switch rawValue.Key {
 	case "bin-val":
@@ -433,28 +513,21 @@ func parseNumericValue(rawNode *operators.Node) Operator {
 	case "hex-val":
 		numericType = hexadecimal
 	}
-	values, hasHyphen, hasPoints := make([]string, 0), false, false
-	for _, child := range rawValue.Children {
-		if rawNumericValue := child.GetNode(string(numericType)); rawNumericValue != nil {
-			// encountered hyphen
-			if child.Contains("-") {
-				hasHyphen = true
-			}
-			// encountered point(s)
-			if child.Contains(".") {
-				hasPoints = true
-			}
-
-			if hasHyphen {
-				values = append(values, rawNumericValue.String())
-			} else if hasPoints {
-				for _, part := range child.GetSubNodes(string(numericType)) {
-					values = append(values, part.String())
-				}
-			} else {
-				values = []string{rawNumericValue.String()}
-			}
+	// the digits are rules, they are preceded by the type (e.g. "x") or by a hyphen or point that starts the next value
+	var values []string
+	var hasHyphen, hasPoints bool
+	for _, node := range outerSubNodes(rawValue, operators.Kinds(operators.KindRule, operators.KindTerminal)) {
+		if node.IsRule() {
+			values[len(values)-1] += node.String()
+			continue
+		}
+		switch string(node.Value) {
+		case "-":
+			hasHyphen = true
+		case ".":
+			hasPoints = true
 		}
+		values = append(values, "")
 	}
 
 	return NumericValueOperator{
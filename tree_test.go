@@ -3,6 +3,9 @@ package abnf
 import (
 	"io/ioutil"
 	"testing"
+
+	"github.com/elimity-com/abnf/definition"
+	"github.com/elimity-com/abnf/operators"
 )
 
 func TestNewRuleList(t *testing.T) {
@@ -305,3 +308,117 @@ func TestRepetition(t *testing.T) {
 		}
 	}
 }
+
+func TestRuleSetElement(t *testing.T) {
+	rawABNF := []byte("range = 1*DIGIT \"-\" 1*DIGIT\nDIGIT = %x30-39\n")
+	set := NewRuleSet(rawABNF)
+	node := (&ParserGenerator{RawABNF: rawABNF}).GenerateABNFAsOperators()["range"]([]byte("12-34")).Best()
+
+	concat := set["range"].operator.(ConcatenationOperator)
+	repetitions := node.GetSubNodes("1*DIGIT")
+	if len(repetitions) != 2 {
+		t.Fatalf("expected two repetitions, got %d", len(repetitions))
+	}
+	for _, test := range []struct {
+		node     *operators.Node
+		expected Operator
+	}{
+		{node, concat},
+		{node.GetSubRule("DIGIT"), set["DIGIT"].operator},
+		{node.GetSubNode("-"), concat.subOperators[1]},
+		// the repetitions have the same text, but they are different elements
+		{repetitions[0], concat.subOperators[0]},
+		{repetitions[1], concat.subOperators[2]},
+	} {
+		element, ok := set.Element(test.node)
+		if !ok {
+			t.Errorf("no element found for %s", test.node.Key)
+			continue
+		}
+		if err := element.equals(test.expected); err != nil {
+			t.Errorf("%s: %s", test.node.Key, err)
+		}
+	}
+	if repetitions[0].Element.Index != 1 || repetitions[1].Element.Index != 4 {
+		t.Errorf("expected the elements 1 and 4, got %d and %d", repetitions[0].Element.Index, repetitions[1].Element.Index)
+	}
+
+	if _, ok := set.Element(&operators.Node{Key: "-", Kind: operators.KindTerminal}); ok {
+		t.Error("found an element of a node without element")
+	}
+	if _, ok := set.Element(&operators.Node{Element: operators.Element{Rule: "range", Index: 6}}); ok {
+		t.Error("found an element outside of the rule")
+	}
+	if _, ok := set.Element(&operators.Node{Element: operators.Element{Rule: "unknown"}}); ok {
+		t.Error("found an element of an unknown rule")
+	}
+}
+
+func TestRuleSetElementModes(t *testing.T) {
+	rawABNF := []byte("range = 1*DIGIT \"-\" [1*DIGIT] / 1*2(\"a\" / %x62-63)\nDIGIT = %x30-39\n")
+	set := NewRuleSet(rawABNF)
+	earley, err := NewEarleyParser(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	program, err := (&ParserGenerator{RawABNF: rawABNF}).GenerateProgram()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	trees := make(map[string]operators.Alternatives)
+	for _, s := range []string{"12-3", "12-", "ab"} {
+		input := []byte(s)
+		for name, g := range map[string]*ParserGenerator{
+			"operators": {RawABNF: rawABNF},
+			"dispatch":  {RawABNF: rawABNF, Dispatch: true},
+			"peg":       {RawABNF: rawABNF, PEG: true},
+		} {
+			trees[name] = append(trees[name], g.GenerateABNFAsOperators()["range"](input)...)
+		}
+		forest, err := earley.Parse("range", input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		trees["earley"] = append(trees["earley"], forest.Trees()...)
+		node, err := program.Parse("range", input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		trees["vm"] = append(trees["vm"], node)
+	}
+
+	// generated code, in operators mode (definition) and in recursive descent mode (core)
+	definitionABNF, err := ioutil.ReadFile("./testdata/definition.abnf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	definitionSet := NewRuleSet(definitionABNF)
+	for name, rule := range CoreRules(false) {
+		definitionSet[name] = rule
+	}
+	trees["generated"] = definition.Rulelist(definitionABNF)
+
+	for name, nodes := range trees {
+		ruleSet := set
+		if name == "generated" {
+			ruleSet = definitionSet
+		}
+		for _, node := range nodes {
+			operators.Inspect(node, func(node *operators.Node) bool {
+				element, ok := ruleSet.Element(node)
+				switch {
+				case !ok:
+					t.Errorf("%s: no element found for %s", name, node.Key)
+				case node.IsRule():
+					if node.Element != (operators.Element{Rule: node.Key}) {
+						t.Errorf("%s: rule %s refers to %v", name, node.Key, node.Element)
+					}
+				case element.Key() != node.Key || operatorKind(element) != node.Kind:
+					t.Errorf("%s: %s (%s) refers to %s (%s)", name, node.Key, node.Kind, element.Key(), operatorKind(element))
+				}
+				return true
+			})
+		}
+	}
+}